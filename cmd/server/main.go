@@ -1,83 +1,305 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mtavano/admoai-takehome/internal/alerting"
 	"github.com/mtavano/admoai-takehome/internal/api"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/config"
+	"github.com/mtavano/admoai-takehome/internal/expiry"
+	"github.com/mtavano/admoai-takehome/internal/idempotency"
+	"github.com/mtavano/admoai-takehome/internal/logging"
 	"github.com/mtavano/admoai-takehome/internal/metrics"
+	"github.com/mtavano/admoai-takehome/internal/serving"
 	"github.com/mtavano/admoai-takehome/internal/store"
-	_ "github.com/mtavano/admoai-takehome/migrations"
-	"github.com/pressly/goose/v3"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/mtavano/admoai-takehome/internal/tracing"
+	"github.com/mtavano/admoai-takehome/internal/tracking"
+	"github.com/mtavano/admoai-takehome/migrations"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func main() {
-	fmt.Println("admoai-take-home-test initialization")
+	flags, err := config.ParseFlags(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fail(err)
+	}
+	cfg, err := config.Load(flags)
+	if err != nil {
+		fail(err)
+	}
+	if flags.Print {
+		if err := cfg.Write(os.Stdout); err != nil {
+			fail(err)
+		}
+		return
+	}
 
-	// Initialize metrics collector
-	metrics.Init()
+	// Every log line, the ones of the standard log package included, goes
+	// through the structured logger
+	logger, err := logging.New(os.Stdout, logging.Config{
+		Level:  cfg.Logging.Level,
+		Format: cfg.Logging.Format,
+	})
+	if err != nil {
+		fail(err)
+	}
+	slog.SetDefault(logger)
 
-	// Initialize database
-	dbDriver := os.Getenv("DB_DRIVER")
-	dbDSN := os.Getenv("DB_DSN")
+	slog.Info("admoai-take-home-test initialization", slog.Any("config", cfg))
 
-	if dbDriver == "" {
-		dbDriver = "sqlite3"
+	// The context is cancelled on SIGINT/SIGTERM and starts the shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, cfg); err != nil {
+		slog.Error("admoai-take-home-test failed", slog.String("error", err.Error()))
+		os.Exit(1)
 	}
-	if dbDSN == "" {
-		dbDSN = "./data/admoai.db"
+
+	slog.Info("admoai-take-home-test stopped")
+}
+
+// fail reports an error found before the logger is configured
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "admoai-take-home-test failed: %v\n", err)
+	os.Exit(1)
+}
+
+// run starts every component, blocks until ctx is cancelled or the http
+// server fails, and then tears everything down in reverse order
+func run(ctx context.Context, cfg *config.Config) error {
+	// Initialize metrics collector
+	metrics.Init()
+
+	// Tracing is set up first so its spans are flushed last
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Exporter: cfg.Tracing.Exporter,
+	})
+	if err != nil {
+		return err
 	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			slog.Error("failed to flush traces", slog.String("error", err.Error()))
+		}
+	}()
 
-	// Create data directory if it doesn't exist
-	if err := os.MkdirAll("./data", 0755); err != nil {
-		panic(fmt.Sprintf("Failed to create data directory: %v", err))
+	// Alert rules and notifiers, the built-in rule logs when unset
+	var alertingEngine *alerting.Engine
+	if cfg.Alerting.Enabled {
+		alertingConfig, err := cfg.Alerting.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load alerting rules: %w", err)
+		}
+		alertingEngine, err = alerting.NewEngine(prometheus.DefaultGatherer, alertingConfig)
+		if err != nil {
+			return fmt.Errorf("failed to configure alerting: %w", err)
+		}
 	}
 
 	// Initialize database store
-	dbStore, err := store.NewSqlStore(dbDriver, dbDSN)
+	dbStore, err := store.NewSqlStore(cfg.Database.Driver, cfg.Database.DSN, cfg.Database.Pool())
 	if err != nil {
-		panic(fmt.Sprintf("Failed to initialize database: %v", err))
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	dbStore.QueryTimeout = cfg.Database.QueryTimeout
+	defer func() {
+		if err := dbStore.Close(); err != nil {
+			slog.Error("failed to close database", slog.String("error", err.Error()))
+		}
+		slog.Info("database closed")
+	}()
+
+	// Create the directory of the SQLite database if it doesn't exist
+	if dir := sqliteDir(dbStore.Dialect, cfg.Database.DSN); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create data directory: %w", err)
+		}
 	}
 
 	// Run migrations
-	if err := goose.SetDialect(dbDriver); err != nil {
-		panic(fmt.Sprintf("Failed to set dialect: %v", err))
+	if cfg.Database.Migrate {
+		if err := migrations.Up(dbStore.DB.DB, dbStore.Dialect, cfg.Database.MigrationsDir); err != nil {
+			return fmt.Errorf("failed to run migrations: %w", err)
+		}
 	}
 
-	if err := goose.Up(dbStore.DB.DB, "./migrations"); err != nil {
-		panic(fmt.Sprintf("Failed to run migrations: %v", err))
+	slog.Info("database initialized", slog.String("driver", cfg.Database.Driver), slog.Bool("migrated", cfg.Database.Migrate))
+
+	// Make sure an admin key exists to manage the other keys of its account
+	if cfg.Admin.APIKey != "" {
+		if err := ensureAdminKey(ctx, dbStore, cfg.Admin.AccountID, cfg.Admin.APIKey); err != nil {
+			return fmt.Errorf("failed to bootstrap admin api key: %w", err)
+		}
 	}
 
-	fmt.Println("Database initialized and migrations completed")
+	// Background workers share a context that is cancelled once the http
+	// server has drained, so the events of the last requests are flushed
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	runWorker := func(run func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workersCtx)
+		}()
+	}
+	defer func() {
+		stopWorkers()
+		workers.Wait()
+		slog.Info("background workers stopped")
+	}()
+
+	// Load the ad serving index and keep it fresh in background
+	servingIndex := serving.NewIndex(dbStore, cfg.Serving.RefreshInterval)
+	if err := servingIndex.Refresh(ctx); err != nil {
+		return fmt.Errorf("failed to load serving index: %w", err)
+	}
+	runWorker(servingIndex.Run)
+
+	// Buffer tracking events and write them in batches
+	trackingRecorder := tracking.NewRecorder(dbStore, cfg.Tracking.Options())
+	runWorker(trackingRecorder.Run)
+
+	// Transition expired ads to the expired status in background
+	if cfg.Expiry.Enabled {
+		runWorker(expiry.NewReaper(dbStore, cfg.Expiry.SweepInterval).Run)
+	}
+
+	// Drop the idempotency keys past their window, whatever the expiry of
+	// the ads, since they hold whole response bodies
+	runWorker(idempotency.NewPurger(dbStore, cfg.Idempotency.PurgeInterval).Run)
 
 	// api server specifics
 	apiCtx := &api.Context{
-		Db: dbStore,
+		Db:             dbStore,
+		Serving:        servingIndex,
+		Tracking:       trackingRecorder,
+		AllowedOrigins: cfg.Server.CORSAllowedOrigins,
+		IdempotencyTTL: cfg.Idempotency.KeyTTL,
+		AdCountsMaxAge: cfg.Metrics.AdCountsMaxAge,
+		// The account of the bootstrap admin key operates the service
+		OperatorAccountID: cfg.Admin.AccountID,
+		LinkTokens:        middleware.NewLinkTokens([]byte(cfg.Dashboard.LinkSigningKey), cfg.Dashboard.LinkTTL),
+		Logger:            slog.Default(),
 	}
-	router := gin.Default()
+	// Requests are logged by the access log of RegisterRoutes
+	router := gin.New()
+	router.Use(gin.Recovery())
 	api.RegisterRoutes(apiCtx, router)
 
-	port := os.Getenv("API_PORT")
+	// Evaluate the alert rules on the metrics, including the ad counts
+	// registered above
+	if alertingEngine != nil {
+		runWorker(alertingEngine.Run)
+	}
 
 	// Configure and execute the http server
 	srv := &http.Server{
-		Addr:    fmt.Sprintf(":%s", port),
+		Addr:    fmt.Sprintf(":%d", cfg.Server.Port),
 		Handler: router,
 	}
 
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-
+	serveErr := make(chan error, 1)
 	go func() {
-		fmt.Println(fmt.Sprintf("Start listening now on port %s", port))
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			panic(err)
+		slog.Info("start listening", slog.Int("port", cfg.Server.Port))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
 		}
+		close(serveErr)
 	}()
 
-	<-sigs
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("http server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	// Stop accepting connections and wait for in-flight requests
+	slog.Info("shutting down, draining requests", slog.Duration("timeout", cfg.Server.ShutdownTimeout))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to drain http server: %w", err)
+	}
+	slog.Info("http server stopped")
+
+	return nil
+}
+
+// sqliteDir returns the directory of a SQLite database file, empty for the
+// other dialects and in-memory databases
+func sqliteDir(dialect store.Dialect, dsn string) string {
+	if dialect.Name != store.DialectSQLite.Name {
+		return ""
+	}
+	path, _, _ := strings.Cut(strings.TrimPrefix(dsn, "file:"), "?")
+	if path == "" || path == ":memory:" {
+		return ""
+	}
+	return filepath.Dir(path)
+}
+
+// ensureAdminKey stores the given plain key as an admin key of the account,
+// creating the account when needed. Known keys, even revoked, are kept as is.
+func ensureAdminKey(ctx context.Context, db store.Transaction, accountID, plain string) error {
+	accounts, err := query.SelectAccounts(ctx, db, &query.SelectAccountsArgs{ID: accountID})
+	if err != nil {
+		return err
+	}
+	if len(accounts) == 0 {
+		err = query.InsertAccounts(ctx, db, &store.AccountRecord{
+			ID:        accountID,
+			Name:      accountID,
+			CreatedAt: time.Now().Unix(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	hash := middleware.HashAPIKey(plain)
+
+	records, err := query.SelectAPIKeys(ctx, db, &query.SelectAPIKeysArgs{AllAccounts: true, KeyHash: hash})
+	if err != nil {
+		return err
+	}
+	if len(records) > 0 {
+		if records[0].AccountID != accountID {
+			return fmt.Errorf("key already belongs to account %s", records[0].AccountID)
+		}
+		return nil
+	}
+
+	return query.InsertAPIKeys(ctx, db, &store.APIKeyRecord{
+		ID:        uuid.NewString(),
+		AccountID: accountID,
+		Name:      "bootstrap admin",
+		KeyHash:   hash,
+		KeyPrefix: middleware.KeyPrefix(plain),
+		Role:      store.APIKeyRoleAdmin,
+		CreatedAt: time.Now().Unix(),
+	})
 }
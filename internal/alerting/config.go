@@ -0,0 +1,167 @@
+package alerting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultInterval is how often the rules are evaluated
+const DefaultInterval = 30 * time.Second
+
+// DefaultAlertmanagerRepeatInterval re-sends the firing alerts to
+// Alertmanager, which resolves the alerts not sent again within its
+// resolve_timeout (5m by default)
+const DefaultAlertmanagerRepeatInterval = time.Minute
+
+// defaultNotifyTimeout bounds each request of the HTTP notifiers
+const defaultNotifyTimeout = 10 * time.Second
+
+// Types of the notifiers
+const (
+	NotifierLog          = "log"
+	NotifierWebhook      = "webhook"
+	NotifierAlertmanager = "alertmanager"
+)
+
+// Config is the alerting configuration, decoded from JSON. The config package
+// reads it from a YAML, TOML or JSON file.
+type Config struct {
+	// Interval is how often the rules are evaluated, DefaultInterval when
+	// zero
+	Interval  Duration         `json:"interval,omitempty"`
+	Rules     []Rule           `json:"rules"`
+	Notifiers []NotifierConfig `json:"notifiers"`
+}
+
+// NotifierConfig describes where the alerts are sent
+type NotifierConfig struct {
+	// Type is one of log, webhook or alertmanager
+	Type string `json:"type"`
+	// URL is the endpoint of webhooks and the base URL of Alertmanager
+	URL string `json:"url,omitempty"`
+	// RepeatInterval sends the firing alerts again, zero only sends the
+	// changes of state. Alertmanager defaults to
+	// DefaultAlertmanagerRepeatInterval.
+	RepeatInterval Duration `json:"repeat_interval,omitempty"`
+	// Timeout bounds each request of the HTTP notifiers
+	Timeout Duration `json:"timeout,omitempty"`
+}
+
+// Duration is a time.Duration written like "30s" in JSON
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\"")
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+	*d = Duration(value)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// DefaultConfig logs when an account has more than 10 active ads in a
+// placement, the alert of the service before rules were configurable
+func DefaultConfig() *Config {
+	return &Config{
+		Interval: Duration(DefaultInterval),
+		Rules: []Rule{{
+			Name:       "HighActiveAds",
+			Metric:     "admoai_ads_active_current",
+			Comparator: ComparatorGreater,
+			Threshold:  10,
+			Severity:   "warning",
+			Summary:    "High number of active ads",
+		}},
+		Notifiers: []NotifierConfig{{Type: NotifierLog}},
+	}
+}
+
+// ParseConfig decodes and validates a JSON configuration, unknown fields are
+// rejected
+func ParseConfig(raw []byte) (*Config, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+
+	cfg := &Config{}
+	if err := decoder.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse alerting config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the rules and notifiers of the configuration
+func (c *Config) Validate() error {
+	if c.Interval < 0 {
+		return fmt.Errorf("alerting interval must not be negative")
+	}
+
+	names := map[string]bool{}
+	for i, rule := range c.Rules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("alerting rule %d: %w", i, err)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("alerting rule %d: duplicate name %q", i, rule.Name)
+		}
+		names[rule.Name] = true
+	}
+
+	for i, notifier := range c.Notifiers {
+		if _, err := notifier.build(); err != nil {
+			return fmt.Errorf("alerting notifier %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// build returns the notifier the configuration describes
+func (n NotifierConfig) build() (Notifier, error) {
+	if n.RepeatInterval < 0 || n.Timeout < 0 {
+		return nil, fmt.Errorf("durations must not be negative")
+	}
+
+	timeout := time.Duration(n.Timeout)
+	if timeout == 0 {
+		timeout = defaultNotifyTimeout
+	}
+
+	switch n.Type {
+	case NotifierLog:
+		return &LogNotifier{}, nil
+	case NotifierWebhook, NotifierAlertmanager:
+		if n.URL == "" {
+			return nil, fmt.Errorf("%s notifier requires a url", n.Type)
+		}
+		client := &http.Client{Timeout: timeout}
+		if n.Type == NotifierWebhook {
+			return &WebhookNotifier{URL: n.URL, Client: client}, nil
+		}
+		return &AlertmanagerNotifier{URL: n.URL, Client: client}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q", n.Type)
+	}
+}
+
+// repeatInterval returns how often the firing alerts are sent again
+func (n NotifierConfig) repeatInterval() time.Duration {
+	if n.RepeatInterval == 0 && n.Type == NotifierAlertmanager {
+		return DefaultAlertmanagerRepeatInterval
+	}
+	return time.Duration(n.RepeatInterval)
+}
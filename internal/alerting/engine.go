@@ -0,0 +1,319 @@
+// Package alerting evaluates alert rules against the metrics of the service
+// and sends the alerts to notifiers.
+package alerting
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// State of an alert
+type State string
+
+const (
+	// StatePending is an alert whose condition holds for less than the For
+	// of its rule, it is not notified
+	StatePending State = "pending"
+	StateFiring  State = "firing"
+	// StateResolved is notified once when the condition of a firing alert
+	// stops holding
+	StateResolved State = "resolved"
+)
+
+// Alert is the state of a rule for one series
+type Alert struct {
+	Rule string
+	// Labels are the labels of the series plus alertname and severity
+	Labels  map[string]string
+	Summary string
+	// Value is the last value of the series
+	Value float64
+	State State
+	// ActiveAt is when the condition started holding, FiredAt and
+	// ResolvedAt are zero until the alert reaches those states
+	ActiveAt   time.Time
+	FiredAt    time.Time
+	ResolvedAt time.Time
+
+	// key identifies the alert among the ones of the engine
+	key string
+}
+
+// route is a notifier and the firing alerts already sent to it
+type route struct {
+	notifier Notifier
+	repeat   time.Duration
+	// sent is when each firing alert was last sent, by alert key
+	sent map[string]time.Time
+}
+
+// Engine evaluates the rules on the metrics of a gatherer and keeps the
+// state of their alerts. Each notifier is sent the alerts that started
+// firing and that resolved, and the firing ones again every repeat interval
+// of the notifier.
+type Engine struct {
+	gatherer prometheus.Gatherer
+	rules    []Rule
+	routes   []*route
+	interval time.Duration
+
+	// now returns the current time, replaced in tests
+	now func() time.Time
+
+	mu     sync.Mutex
+	alerts map[string]*Alert
+}
+
+// NewEngine returns an engine for the rules and notifiers of a validated
+// configuration
+func NewEngine(gatherer prometheus.Gatherer, cfg *Config) (*Engine, error) {
+	interval := time.Duration(cfg.Interval)
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	e := &Engine{
+		gatherer: gatherer,
+		rules:    cfg.Rules,
+		interval: interval,
+		now:      time.Now,
+		alerts:   map[string]*Alert{},
+	}
+	for _, notifierCfg := range cfg.Notifiers {
+		notifier, err := notifierCfg.build()
+		if err != nil {
+			return nil, err
+		}
+		e.routes = append(e.routes, &route{
+			notifier: notifier,
+			repeat:   notifierCfg.repeatInterval(),
+			sent:     map[string]time.Time{},
+		})
+	}
+	return e, nil
+}
+
+// Run evaluates the rules right away and then every interval until ctx is
+// cancelled
+func (e *Engine) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Evaluate(ctx); err != nil {
+			slog.Error("alerting evaluation failed", slog.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Alerts returns the pending and firing alerts sorted by rule and labels
+func (e *Engine) Alerts() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	keys := make([]string, 0, len(e.alerts))
+	for key := range e.alerts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	alerts := make([]Alert, 0, len(keys))
+	for _, key := range keys {
+		alerts = append(alerts, *e.alerts[key])
+	}
+	return alerts
+}
+
+// Evaluate gathers the metrics once, updates the state of the alerts of
+// every rule and notifies the changes. Series missing from the metrics
+// count as not meeting their condition.
+func (e *Engine) Evaluate(ctx context.Context) error {
+	families, gatherErr := e.gatherer.Gather()
+	byName := make(map[string]*dto.MetricFamily, len(families))
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+
+	e.mu.Lock()
+	now := e.now()
+	seen := map[string]bool{}
+	var resolved []Alert
+	for i := range e.rules {
+		rule := &e.rules[i]
+		family, ok := byName[rule.Metric]
+		if !ok {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			value, ok := metricValue(family.GetType(), metric)
+			if !ok {
+				continue
+			}
+			labels := seriesLabels(metric)
+			if !rule.matches(labels) || !rule.Comparator.Compare(value, rule.Threshold) {
+				continue
+			}
+
+			key := alertKey(rule.Name, labels)
+			seen[key] = true
+			alert, ok := e.alerts[key]
+			if !ok {
+				alert = newAlert(rule, key, labels, now)
+				e.alerts[key] = alert
+			}
+			alert.Value = value
+			if alert.State == StatePending && now.Sub(alert.ActiveAt) >= time.Duration(rule.For) {
+				alert.State = StateFiring
+				alert.FiredAt = now
+			}
+		}
+	}
+
+	// Alerts whose condition stopped holding are dropped, the firing ones
+	// are notified as resolved first
+	for key, alert := range e.alerts {
+		if seen[key] {
+			continue
+		}
+		delete(e.alerts, key)
+		if alert.State == StateFiring {
+			alert.State = StateResolved
+			alert.ResolvedAt = now
+			resolved = append(resolved, *alert)
+		}
+	}
+
+	// Decide what each notifier is sent before releasing the lock, the
+	// notifications themselves can be slow
+	batches := make([][]Alert, len(e.routes))
+	for i, route := range e.routes {
+		batches[i] = route.due(e.alerts, resolved, now)
+	}
+	e.mu.Unlock()
+
+	errs := []error{gatherErr}
+	for i, route := range e.routes {
+		if len(batches[i]) == 0 {
+			continue
+		}
+		if err := route.notifier.Notify(ctx, batches[i]); err != nil {
+			// Firing alerts are sent again on the next evaluation
+			e.mu.Lock()
+			route.forget(batches[i])
+			e.mu.Unlock()
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// due returns the alerts a route is sent at now and records the firing ones
+// as sent. The routes are only used with the lock of the engine held.
+func (r *route) due(alerts map[string]*Alert, resolved []Alert, now time.Time) []Alert {
+	var batch []Alert
+	for key, alert := range alerts {
+		if alert.State != StateFiring {
+			continue
+		}
+		last, ok := r.sent[key]
+		if ok && (r.repeat <= 0 || now.Sub(last) < r.repeat) {
+			continue
+		}
+		r.sent[key] = now
+		batch = append(batch, *alert)
+	}
+	for _, alert := range resolved {
+		if _, ok := r.sent[alert.key]; !ok {
+			// Never sent as firing, nothing to resolve
+			continue
+		}
+		delete(r.sent, alert.key)
+		batch = append(batch, alert)
+	}
+
+	sort.Slice(batch, func(i, j int) bool {
+		return batch[i].key < batch[j].key
+	})
+	return batch
+}
+
+// forget marks the firing alerts of a failed notification as not sent
+func (r *route) forget(batch []Alert) {
+	for _, alert := range batch {
+		if alert.State == StateFiring {
+			delete(r.sent, alert.key)
+		}
+	}
+}
+
+func newAlert(rule *Rule, key string, series map[string]string, now time.Time) *Alert {
+	labels := make(map[string]string, len(series)+2)
+	for name, value := range series {
+		labels[name] = value
+	}
+	labels["alertname"] = rule.Name
+	if rule.Severity != "" {
+		labels["severity"] = rule.Severity
+	}
+
+	return &Alert{
+		Rule:     rule.Name,
+		Labels:   labels,
+		Summary:  rule.Summary,
+		State:    StatePending,
+		ActiveAt: now,
+		key:      key,
+	}
+}
+
+// metricValue returns the value of a gauge, counter or untyped series
+func metricValue(kind dto.MetricType, metric *dto.Metric) (float64, bool) {
+	switch kind {
+	case dto.MetricType_GAUGE:
+		return metric.GetGauge().GetValue(), true
+	case dto.MetricType_COUNTER:
+		return metric.GetCounter().GetValue(), true
+	case dto.MetricType_UNTYPED:
+		return metric.GetUntyped().GetValue(), true
+	}
+	return 0, false
+}
+
+func seriesLabels(metric *dto.Metric) map[string]string {
+	labels := make(map[string]string, len(metric.GetLabel()))
+	for _, label := range metric.GetLabel() {
+		labels[label.GetName()] = label.GetValue()
+	}
+	return labels
+}
+
+// alertKey identifies the alert of a rule for a series, so each series
+// raises a single alert however many evaluations it meets the condition
+func alertKey(rule string, labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var key strings.Builder
+	key.WriteString(rule)
+	for _, name := range names {
+		key.WriteString("\x00" + name + "=" + labels[name])
+	}
+	return key.String()
+}
@@ -0,0 +1,170 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingNotifier keeps the batches it is sent, failing while err is set
+type recordingNotifier struct {
+	batches [][]Alert
+	err     error
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, alerts []Alert) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.batches = append(n.batches, alerts)
+	return nil
+}
+
+// testEngine returns an engine on a registry with an ads gauge by placement,
+// sending to a recording notifier, and a clock advanced by the tests
+func testEngine(t *testing.T, rule Rule, repeat time.Duration) (*Engine, *prometheus.GaugeVec, *recordingNotifier, *time.Time) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "ads", Help: "ads"}, []string{"placement"})
+	registry.MustRegister(gauge)
+
+	engine, err := NewEngine(registry, &Config{Rules: []Rule{rule}})
+	require.NoError(t, err)
+
+	notifier := &recordingNotifier{}
+	engine.routes = append(engine.routes, &route{notifier: notifier, repeat: repeat, sent: map[string]time.Time{}})
+
+	now := time.Unix(1700000000, 0)
+	engine.now = func() time.Time { return now }
+	return engine, gauge, notifier, &now
+}
+
+func TestEngineEvaluate(t *testing.T) {
+	engine, gauge, notifier, now := testEngine(t, Rule{
+		Name:       "HighAds",
+		Metric:     "ads",
+		Comparator: ComparatorGreater,
+		Threshold:  10,
+		For:        Duration(time.Minute),
+		Severity:   "warning",
+		Summary:    "Too many ads",
+	}, 0)
+	start := *now
+	evaluate := func(after time.Duration) {
+		*now = start.Add(after)
+		require.NoError(t, engine.Evaluate(context.Background()))
+	}
+
+	gauge.WithLabelValues("homepage").Set(11)
+	gauge.WithLabelValues("sidebar").Set(5)
+	evaluate(0)
+	alerts := engine.Alerts()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, StatePending, alerts[0].State)
+	assert.Equal(t, map[string]string{"alertname": "HighAds", "severity": "warning", "placement": "homepage"}, alerts[0].Labels)
+	assert.Empty(t, notifier.batches)
+
+	// Fires once the condition held for the For of the rule
+	evaluate(30 * time.Second)
+	assert.Empty(t, notifier.batches)
+	evaluate(time.Minute)
+	require.Len(t, notifier.batches, 1)
+	require.Len(t, notifier.batches[0], 1)
+	assert.Equal(t, StateFiring, notifier.batches[0][0].State)
+	assert.Equal(t, float64(11), notifier.batches[0][0].Value)
+	assert.Equal(t, start, notifier.batches[0][0].ActiveAt)
+	assert.Equal(t, start.Add(time.Minute), notifier.batches[0][0].FiredAt)
+
+	// Deduplicated while it keeps firing
+	gauge.WithLabelValues("homepage").Set(12)
+	evaluate(2 * time.Minute)
+	assert.Len(t, notifier.batches, 1)
+	assert.Equal(t, float64(12), engine.Alerts()[0].Value)
+
+	// A pending alert that clears is never notified
+	gauge.WithLabelValues("sidebar").Set(20)
+	evaluate(3 * time.Minute)
+	gauge.WithLabelValues("sidebar").Set(1)
+	evaluate(4 * time.Minute)
+	assert.Len(t, notifier.batches, 1)
+
+	// Resolved once, when the series stops meeting the condition
+	gauge.WithLabelValues("homepage").Set(3)
+	evaluate(5 * time.Minute)
+	require.Len(t, notifier.batches, 2)
+	assert.Equal(t, StateResolved, notifier.batches[1][0].State)
+	assert.Equal(t, start.Add(5*time.Minute), notifier.batches[1][0].ResolvedAt)
+	assert.Empty(t, engine.Alerts())
+
+	evaluate(6 * time.Minute)
+	assert.Len(t, notifier.batches, 2)
+}
+
+func TestEngineRepeatAndRetry(t *testing.T) {
+	engine, gauge, notifier, now := testEngine(t, Rule{
+		Name:       "NoAds",
+		Metric:     "ads",
+		Labels:     map[string]string{"placement": "homepage"},
+		Comparator: ComparatorLessOrEqual,
+		Threshold:  0,
+	}, time.Minute)
+	start := *now
+	evaluate := func(after time.Duration) error {
+		*now = start.Add(after)
+		return engine.Evaluate(context.Background())
+	}
+
+	// Only the series with the labels of the rule are evaluated
+	gauge.WithLabelValues("homepage").Set(0)
+	gauge.WithLabelValues("sidebar").Set(0)
+
+	// A failed notification is sent again on the next evaluation
+	notifier.err = errors.New("unavailable")
+	assert.Error(t, evaluate(0))
+	notifier.err = nil
+	require.NoError(t, evaluate(10*time.Second))
+	require.Len(t, notifier.batches, 1)
+	require.Len(t, notifier.batches[0], 1)
+	assert.Equal(t, "homepage", notifier.batches[0][0].Labels["placement"])
+
+	// Firing alerts are sent again every repeat interval
+	require.NoError(t, evaluate(30*time.Second))
+	assert.Len(t, notifier.batches, 1)
+	require.NoError(t, evaluate(70*time.Second))
+	assert.Len(t, notifier.batches, 2)
+	assert.Equal(t, StateFiring, notifier.batches[1][0].State)
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`{
+		"interval": "15s",
+		"rules": [{"name": "HighAds", "metric": "ads", "labels": {"placement": "homepage"}, "comparator": ">=", "threshold": 5, "for": "2m"}],
+		"notifiers": [{"type": "log"}, {"type": "alertmanager", "url": "http://localhost:9093"}]
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, Duration(15*time.Second), cfg.Interval)
+	require.Len(t, cfg.Rules, 1)
+	assert.Equal(t, Duration(2*time.Minute), cfg.Rules[0].For)
+	assert.Equal(t, ComparatorGreaterOrEqual, cfg.Rules[0].Comparator)
+	assert.Equal(t, DefaultAlertmanagerRepeatInterval, cfg.Notifiers[1].repeatInterval())
+
+	invalid := map[string]string{
+		"unknown comparator": `{"rules": [{"name": "a", "metric": "ads", "comparator": "=>"}]}`,
+		"missing metric":     `{"rules": [{"name": "a", "comparator": ">"}]}`,
+		"duplicate rule":     `{"rules": [{"name": "a", "metric": "ads", "comparator": ">"}, {"name": "a", "metric": "ads", "comparator": "<"}]}`,
+		"invalid duration":   `{"rules": [{"name": "a", "metric": "ads", "comparator": ">", "for": 60}]}`,
+		"missing url":        `{"notifiers": [{"type": "webhook"}]}`,
+		"unknown notifier":   `{"notifiers": [{"type": "email"}]}`,
+		"unknown field":      `{"rules": [{"name": "a", "metric": "ads", "comparator": ">", "treshold": 5}]}`,
+	}
+	for name, content := range invalid {
+		_, err := ParseConfig([]byte(content))
+		assert.Error(t, err, name)
+	}
+
+	require.NoError(t, DefaultConfig().Validate())
+}
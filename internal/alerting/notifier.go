@@ -0,0 +1,183 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notifier sends alerts somewhere. Each call carries the alerts that
+// started firing or resolved, and the firing ones sent again.
+type Notifier interface {
+	Notify(ctx context.Context, alerts []Alert) error
+}
+
+// LogNotifier writes a line per alert to a logger, at warn level while
+// firing and info once resolved
+type LogNotifier struct {
+	// Logger is slog.Default() when nil
+	Logger *slog.Logger
+}
+
+// Notify implements Notifier
+func (n *LogNotifier) Notify(ctx context.Context, alerts []Alert) error {
+	logger := n.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	for _, alert := range alerts {
+		level := slog.LevelWarn
+		if alert.State == StateResolved {
+			level = slog.LevelInfo
+		}
+		logger.LogAttrs(ctx, level, "alert",
+			slog.String("alertname", alert.Rule),
+			slog.String("state", string(alert.State)),
+			slog.String("labels", formatLabels(alert.Labels)),
+			slog.Float64("value", alert.Value),
+			slog.String("summary", alert.Summary),
+		)
+	}
+	return nil
+}
+
+// WebhookNotifier posts the alerts as JSON to a URL
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// WebhookPayload is the body posted by WebhookNotifier
+type WebhookPayload struct {
+	Alerts []WebhookAlert `json:"alerts"`
+}
+
+// WebhookAlert is an alert in the body of a webhook
+type WebhookAlert struct {
+	Name       string            `json:"name"`
+	State      State             `json:"state"`
+	Labels     map[string]string `json:"labels"`
+	Summary    string            `json:"summary,omitempty"`
+	Value      float64           `json:"value"`
+	ActiveAt   time.Time         `json:"activeAt"`
+	FiredAt    *time.Time        `json:"firedAt,omitempty"`
+	ResolvedAt *time.Time        `json:"resolvedAt,omitempty"`
+}
+
+// Notify implements Notifier
+func (n *WebhookNotifier) Notify(ctx context.Context, alerts []Alert) error {
+	payload := WebhookPayload{Alerts: make([]WebhookAlert, 0, len(alerts))}
+	for _, alert := range alerts {
+		payload.Alerts = append(payload.Alerts, WebhookAlert{
+			Name:       alert.Rule,
+			State:      alert.State,
+			Labels:     alert.Labels,
+			Summary:    alert.Summary,
+			Value:      alert.Value,
+			ActiveAt:   alert.ActiveAt,
+			FiredAt:    optionalTime(alert.FiredAt),
+			ResolvedAt: optionalTime(alert.ResolvedAt),
+		})
+	}
+
+	return postJSON(ctx, n.Client, n.URL, payload)
+}
+
+// AlertmanagerNotifier posts the alerts to the v2 API of Alertmanager, which
+// groups, deduplicates and routes them
+type AlertmanagerNotifier struct {
+	// URL is the base URL of Alertmanager, like http://alertmanager:9093
+	URL    string
+	Client *http.Client
+}
+
+// AlertmanagerAlert is an alert as accepted by POST /api/v2/alerts
+type AlertmanagerAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	StartsAt    time.Time         `json:"startsAt"`
+	// EndsAt is only set on resolved alerts, Alertmanager resolves the
+	// firing ones not sent again within its resolve_timeout
+	EndsAt *time.Time `json:"endsAt,omitempty"`
+}
+
+// Notify implements Notifier
+func (n *AlertmanagerNotifier) Notify(ctx context.Context, alerts []Alert) error {
+	payload := make([]AlertmanagerAlert, 0, len(alerts))
+	for _, alert := range alerts {
+		annotations := map[string]string{
+			"value": strconv.FormatFloat(alert.Value, 'g', -1, 64),
+		}
+		if alert.Summary != "" {
+			annotations["summary"] = alert.Summary
+		}
+		payload = append(payload, AlertmanagerAlert{
+			Labels:      alert.Labels,
+			Annotations: annotations,
+			StartsAt:    alert.FiredAt,
+			EndsAt:      optionalTime(alert.ResolvedAt),
+		})
+	}
+
+	return postJSON(ctx, n.Client, strings.TrimRight(n.URL, "/")+"/api/v2/alerts", payload)
+}
+
+// postJSON posts a JSON body and fails on responses other than 2xx
+func postJSON(ctx context.Context, client *http.Client, url string, body any) error {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode alerts: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("failed to build alerts request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send alerts to %s: %w", url, err)
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("failed to send alerts to %s: status %d", url, res.StatusCode)
+	}
+	return nil
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// formatLabels writes labels like {placement="homepage"}, sorted by name
+func formatLabels(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, labels[name]))
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
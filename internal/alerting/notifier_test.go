@@ -0,0 +1,140 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// standIn is a local HTTP server keeping the bodies posted to it
+type standIn struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	paths  []string
+	bodies [][]byte
+	status int
+}
+
+func newStandIn(t *testing.T) *standIn {
+	s := &standIn{status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body bytes.Buffer
+		body.ReadFrom(r.Body)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.paths = append(s.paths, r.Method+" "+r.URL.Path)
+		s.bodies = append(s.bodies, body.Bytes())
+		w.WriteHeader(s.status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func firingAlert() Alert {
+	activeAt := time.Unix(1700000000, 0).UTC()
+	return Alert{
+		Rule:     "HighAds",
+		Labels:   map[string]string{"alertname": "HighAds", "placement": "homepage"},
+		Summary:  "Too many ads",
+		Value:    11,
+		State:    StateFiring,
+		ActiveAt: activeAt,
+		FiredAt:  activeAt.Add(time.Minute),
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	server := newStandIn(t)
+	notifier := &WebhookNotifier{URL: server.URL + "/hooks/alerts"}
+
+	require.NoError(t, notifier.Notify(context.Background(), []Alert{firingAlert()}))
+	require.Equal(t, []string{"POST /hooks/alerts"}, server.paths)
+
+	var payload WebhookPayload
+	require.NoError(t, json.Unmarshal(server.bodies[0], &payload))
+	require.Len(t, payload.Alerts, 1)
+	assert.Equal(t, "HighAds", payload.Alerts[0].Name)
+	assert.Equal(t, StateFiring, payload.Alerts[0].State)
+	assert.Equal(t, "homepage", payload.Alerts[0].Labels["placement"])
+	assert.Equal(t, float64(11), payload.Alerts[0].Value)
+	require.NotNil(t, payload.Alerts[0].FiredAt)
+	assert.Nil(t, payload.Alerts[0].ResolvedAt)
+
+	server.status = http.StatusServiceUnavailable
+	assert.Error(t, notifier.Notify(context.Background(), []Alert{firingAlert()}))
+}
+
+func TestAlertmanagerNotifier(t *testing.T) {
+	server := newStandIn(t)
+
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "ads", Help: "ads"})
+	registry.MustRegister(gauge)
+
+	engine, err := NewEngine(registry, &Config{
+		Rules:     []Rule{{Name: "HighAds", Metric: "ads", Comparator: ComparatorGreater, Threshold: 10, Summary: "Too many ads"}},
+		Notifiers: []NotifierConfig{{Type: NotifierAlertmanager, URL: server.URL + "/"}},
+	})
+	require.NoError(t, err)
+	start := time.Unix(1700000000, 0)
+	now := start
+	engine.now = func() time.Time { return now }
+
+	decode := func(i int) []AlertmanagerAlert {
+		var alerts []AlertmanagerAlert
+		require.NoError(t, json.Unmarshal(server.bodies[i], &alerts))
+		return alerts
+	}
+
+	gauge.Set(11)
+	require.NoError(t, engine.Evaluate(context.Background()))
+	require.Equal(t, []string{"POST /api/v2/alerts"}, server.paths)
+	alerts := decode(0)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, map[string]string{"alertname": "HighAds"}, alerts[0].Labels)
+	assert.Equal(t, map[string]string{"summary": "Too many ads", "value": "11"}, alerts[0].Annotations)
+	assert.True(t, start.Equal(alerts[0].StartsAt))
+	assert.Nil(t, alerts[0].EndsAt)
+
+	// Sent again so Alertmanager keeps it firing
+	now = start.Add(DefaultAlertmanagerRepeatInterval)
+	require.NoError(t, engine.Evaluate(context.Background()))
+	assert.Len(t, server.paths, 2)
+
+	gauge.Set(1)
+	now = start.Add(2 * DefaultAlertmanagerRepeatInterval)
+	require.NoError(t, engine.Evaluate(context.Background()))
+	require.Len(t, server.paths, 3)
+	alerts = decode(2)
+	require.Len(t, alerts, 1)
+	require.NotNil(t, alerts[0].EndsAt)
+	assert.True(t, now.Equal(*alerts[0].EndsAt))
+}
+
+func TestLogNotifier(t *testing.T) {
+	var out bytes.Buffer
+	// Without the time, so the line is the same on every run
+	notifier := &LogNotifier{Logger: slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	}))}
+
+	require.NoError(t, notifier.Notify(context.Background(), []Alert{firingAlert()}))
+	assert.Equal(t, `level=WARN msg=alert alertname=HighAds state=firing labels="{alertname=\"HighAds\", placement=\"homepage\"}" value=11 summary="Too many ads"`+"\n", out.String())
+}
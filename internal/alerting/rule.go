@@ -0,0 +1,83 @@
+package alerting
+
+import (
+	"fmt"
+)
+
+// Comparator compares the value of a series to the threshold of a rule
+type Comparator string
+
+// Comparators of the rules
+const (
+	ComparatorGreater        Comparator = ">"
+	ComparatorGreaterOrEqual Comparator = ">="
+	ComparatorLess           Comparator = "<"
+	ComparatorLessOrEqual    Comparator = "<="
+	ComparatorEqual          Comparator = "=="
+	ComparatorNotEqual       Comparator = "!="
+)
+
+// Compare reports whether value meets the condition against threshold
+func (c Comparator) Compare(value, threshold float64) bool {
+	switch c {
+	case ComparatorGreater:
+		return value > threshold
+	case ComparatorGreaterOrEqual:
+		return value >= threshold
+	case ComparatorLess:
+		return value < threshold
+	case ComparatorLessOrEqual:
+		return value <= threshold
+	case ComparatorEqual:
+		return value == threshold
+	case ComparatorNotEqual:
+		return value != threshold
+	}
+	return false
+}
+
+// Rule raises one alert per series of Metric whose value meets the
+// condition, once the condition has held for For
+type Rule struct {
+	Name string `json:"name"`
+	// Metric is the name of a gauge, counter or untyped metric
+	Metric string `json:"metric"`
+	// Labels keeps only the series with these label values
+	Labels     map[string]string `json:"labels,omitempty"`
+	Comparator Comparator        `json:"comparator"`
+	Threshold  float64           `json:"threshold"`
+	// For is how long the condition holds before the alert fires, the
+	// alert is pending meanwhile. Zero fires on the first evaluation.
+	For      Duration `json:"for,omitempty"`
+	Severity string   `json:"severity,omitempty"`
+	Summary  string   `json:"summary,omitempty"`
+}
+
+func (r *Rule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if r.Metric == "" {
+		return fmt.Errorf("metric is required")
+	}
+	switch r.Comparator {
+	case ComparatorGreater, ComparatorGreaterOrEqual, ComparatorLess,
+		ComparatorLessOrEqual, ComparatorEqual, ComparatorNotEqual:
+	default:
+		return fmt.Errorf("unknown comparator %q", r.Comparator)
+	}
+	if r.For < 0 {
+		return fmt.Errorf("for must not be negative")
+	}
+	return nil
+}
+
+// matches reports whether a series has the label values of the rule
+func (r *Rule) matches(labels map[string]string) bool {
+	for name, value := range r.Labels {
+		if labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLog(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		var out bytes.Buffer
+		srv.ctx.Logger = slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		srv.engine = gin.New()
+		RegisterRoutes(srv.ctx, srv.engine)
+
+		// lines returns the log lines of a message written since the last call
+		lines := func(msg string) []map[string]any {
+			var found []map[string]any
+			decoder := json.NewDecoder(&out)
+			for decoder.More() {
+				var line map[string]any
+				require.NoError(t, decoder.Decode(&line))
+				if line["msg"] == msg {
+					found = append(found, line)
+				}
+			}
+			out.Reset()
+			return found
+		}
+
+		status, _ := srv.doWithHeaders(http.MethodGet, "/v1/ads/missing", map[string]string{"X-Request-ID": "req-123"}, nil, nil)
+		require.Equal(t, http.StatusNotFound, status)
+
+		requestLines := lines("request")
+		require.Len(t, requestLines, 1)
+		line := requestLines[0]
+		assert.Equal(t, "INFO", line["level"])
+		assert.Equal(t, "req-123", line["request_id"])
+		assert.Equal(t, http.MethodGet, line["method"])
+		assert.Equal(t, "/v1/ads/:id", line["route"])
+		assert.Equal(t, "/v1/ads/missing", line["path"])
+		assert.Equal(t, float64(http.StatusNotFound), line["status"])
+		assert.NotEmpty(t, line["client_ip"])
+		assert.Contains(t, line, "latency_ms")
+
+		// Routes that do not exist are logged without route
+		status = srv.do(http.MethodGet, "/v1/nothing", nil, nil)
+		require.Equal(t, http.StatusNotFound, status)
+		requestLines = lines("request")
+		require.Len(t, requestLines, 1)
+		assert.Equal(t, "", requestLines[0]["route"])
+		assert.NotEmpty(t, requestLines[0]["request_id"])
+	})
+}
+
+func TestAccessLogQueries(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		var out bytes.Buffer
+		srv.ctx.Logger = slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		srv.engine = gin.New()
+		RegisterRoutes(srv.ctx, srv.engine)
+
+		srv.doWithHeaders(http.MethodGet, "/v1/ads/missing", map[string]string{"X-Request-ID": "req-456"}, nil, nil)
+
+		queries := 0
+		decoder := json.NewDecoder(&out)
+		for decoder.More() {
+			var line map[string]any
+			require.NoError(t, decoder.Decode(&line))
+			if line["msg"] != "query" {
+				continue
+			}
+			queries++
+			assert.Equal(t, "DEBUG", line["level"])
+			assert.Equal(t, "req-456", line["request_id"])
+			assert.NotEmpty(t, line["sql"])
+		}
+		// The lookup of the API key and the read of the ad
+		assert.GreaterOrEqual(t, queries, 2)
+	})
+}
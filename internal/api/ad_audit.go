@@ -0,0 +1,27 @@
+package api
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/audit"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+)
+
+// recordAdAudit writes the audit entry of a mutation made by the request, in
+// the transaction of the mutation
+func recordAdAudit(c *gin.Context, tx store.Transaction, action string, before, after *store.AdvertiseRecord) error {
+	actor := "anonymous"
+	if key := middleware.CurrentAPIKey(c); key != nil {
+		actor = audit.APIKeyActor(key.ID)
+	}
+
+	entry, err := audit.NewAdEntry(action, actor, c.GetString("request_id"), before, after, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return query.InsertAdAuditLog(c.Request.Context(), tx, entry)
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mtavano/admoai-takehome/internal/metrics"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadAdCounts(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		create := func(placement string) string {
+			var rec store.AdvertiseRecord
+			status := srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+				Title:     "Counted",
+				ImageURL:  "https://example.com/ad.jpg",
+				Placement: placement,
+			}, &rec)
+			require.Equal(t, http.StatusCreated, status)
+			return rec.ID
+		}
+
+		create("homepage")
+		create("homepage")
+		deactivated := create("homepage")
+		create("sidebar")
+		require.Equal(t, http.StatusOK, srv.do(http.MethodPost, "/v1/ads/"+deactivated+"/deactivate", nil, nil))
+
+		// Expired before and after the reaper, and an ad of another account
+		past := time.Now().Add(-time.Hour).Unix()
+		for _, rec := range []*store.AdvertiseRecord{
+			{AccountID: store.AccountDefaultID, Status: store.AdvertiseStatusActive},
+			{AccountID: store.AccountDefaultID, Status: store.AdvertiseStatusExpired},
+			{AccountID: "other", Status: store.AdvertiseStatusExpired},
+		} {
+			rec.ID = uuid.NewString()
+			rec.Title = "Expired"
+			rec.ImageURL = "https://example.com/ad.jpg"
+			rec.Placement = "sidebar"
+			rec.Weight = store.AdvertiseDefaultWeight
+			rec.CreatedAt = past
+			rec.ExpiresAt = &past
+			require.NoError(t, query.InsertAds(context.Background(), srv.ctx.Db, rec))
+		}
+
+		counts, err := readAdCounts(srv.ctx)(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []metrics.AdCounts{
+			{Account: store.AccountDefaultID, Placement: "homepage", Active: 2, Inactive: 1},
+			{Account: store.AccountDefaultID, Placement: "sidebar", Active: 1, Expired: 2},
+			{Account: "other", Placement: "sidebar", Expired: 1},
+		}, counts)
+	})
+}
+
+func TestAdCountsScrapeByAccount(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		ownerKey := srv.apiKey
+		otherKey := srv.insertAPIKey("acme-admin", "acme", store.APIKeyRoleAdmin)
+
+		for _, key := range []string{ownerKey, otherKey, otherKey} {
+			srv.apiKey = key
+			status := srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+				Title:     "Counted",
+				ImageURL:  "https://example.com/ad.jpg",
+				Placement: "homepage",
+			}, nil)
+			require.Equal(t, http.StatusCreated, status)
+		}
+
+		scrape := func(key string) string {
+			srv.apiKey = key
+			var body []byte
+			status, _ := srv.doWithHeaders(http.MethodGet, "/metrics", nil, nil, &body)
+			require.Equal(t, http.StatusOK, status)
+			return string(body)
+		}
+
+		owner := scrape(ownerKey)
+		assert.Contains(t, owner, `admoai_ads_active_current{account="default",placement="homepage"} 1`)
+		assert.NotContains(t, owner, `account="acme"`)
+
+		other := scrape(otherKey)
+		assert.Contains(t, other, `admoai_ads_active_current{account="acme",placement="homepage"} 2`)
+		assert.NotContains(t, other, `account="default"`)
+
+		// Anonymous scrapes see no ad counts at all
+		assert.NotContains(t, scrape(""), "admoai_ads_active_current")
+	})
+}
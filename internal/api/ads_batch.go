@@ -0,0 +1,185 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/metrics"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+// Modes of the batch endpoints
+const (
+	// BatchModeAtomic applies the batch only when every item is valid
+	BatchModeAtomic = "atomic"
+	// BatchModePartial applies the valid items and reports the others
+	BatchModePartial = "partial"
+)
+
+// Results of the items of a batch
+const (
+	BatchItemCreated     = "created"
+	BatchItemDeactivated = "deactivated"
+	BatchItemFailed      = "failed"
+	// BatchItemValid is an item of a dry run that would have been applied
+	BatchItemValid = "valid"
+	// BatchItemSkipped is a valid item not applied because the atomic batch
+	// had invalid ones
+	BatchItemSkipped = "skipped"
+	// BatchItemUnchanged is an ad to deactivate that already was inactive
+	BatchItemUnchanged = "unchanged"
+)
+
+// maxBatchSize bounds the items of a batch, they share one transaction
+const maxBatchSize = 1000
+
+// BatchItemResult is the outcome of one item, in the order of the request
+type BatchItemResult struct {
+	Index int `json:"index"`
+	// Line is the line of the item in an imported file
+	Line    int    `json:"line,omitempty"`
+	ID      string `json:"id,omitempty"`
+	Status  string `json:"status"`
+	Error   any    `json:"error,omitempty"`
+	Details any    `json:"details,omitempty"`
+	Fields  any    `json:"fields,omitempty"`
+	Ad      any    `json:"ad,omitempty"`
+}
+
+// BatchResponse reports every item of a batch
+type BatchResponse struct {
+	Mode string `json:"mode"`
+	// DryRun reports a batch validated without applying it
+	DryRun    bool               `json:"dryRun,omitempty"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Results   []*BatchItemResult `json:"results"`
+}
+
+// PostAdsActionHandler dispatches the custom methods of the ads collection,
+// POST /v1/ads:batch and POST /v1/ads:batchDeactivate
+func PostAdsActionHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	switch c.Param("action") {
+	case ":batch":
+		return Idempotent(PostAdsBatchHandler)(c, ctx)
+	case ":batchDeactivate":
+		return PostAdsBatchDeactivateHandler(c, ctx)
+	default:
+		return map[string]any{
+			"error": "Not found",
+		}, http.StatusNotFound, nil
+	}
+}
+
+// failedItem turns the error payload of an item into its result
+func failedItem(index int, id string, payload map[string]any) *BatchItemResult {
+	return &BatchItemResult{
+		Index:   index,
+		ID:      id,
+		Status:  BatchItemFailed,
+		Error:   payload["error"],
+		Details: payload["details"],
+		Fields:  payload["fields"],
+	}
+}
+
+// validateAdItem validates one creation item like PostAdsHandler, against the
+// createAd schema of the OpenAPI document first. It returns the ad to insert,
+// or the result of the item when it is invalid.
+func validateAdItem(c *gin.Context, ctx *Context, index int, item *PostAdsHandlerRequest, createdAt time.Time) (*store.AdvertiseRecord, *BatchItemResult, error) {
+	fields, err := validateBodyOf(http.MethodPost, "/v1/ads", item)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(fields) > 0 {
+		return nil, failedItem(index, "", validationFailed(fields)), nil
+	}
+
+	if err := binding.Validator.ValidateStruct(item); err != nil {
+		return nil, failedItem(index, "", map[string]any{
+			"error":   "Validation failed",
+			"details": err.Error(),
+		}), nil
+	}
+
+	rec, payload, err := newAdFromRequest(c, ctx, item, createdAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	if payload != nil {
+		return nil, failedItem(index, "", payload), nil
+	}
+	return rec, nil, nil
+}
+
+// insertAdBatch inserts the validated ads of a batch with their audit entries
+// in the transaction of the request, and counts them as created
+func insertAdBatch(c *gin.Context, ctx *Context, records []*store.AdvertiseRecord) error {
+	for _, rec := range records {
+		if err := query.InsertAds(c.Request.Context(), ctx.Db, rec); err != nil {
+			return err
+		}
+		if err := recordAdAudit(c, ctx.Db, store.AdAuditActionCreate, nil, rec); err != nil {
+			return errors.Wrap(err, "audit error")
+		}
+	}
+
+	// Increment metrics for ad creation, once committed
+	afterCommit(c, func() {
+		if collector := metrics.GetCollector(); collector != nil {
+			for _, rec := range records {
+				collector.IncrementAdCreated(rec.AccountID)
+			}
+		}
+	})
+	return nil
+}
+
+// batchStatus returns the status of a processed batch. Atomic batches with
+// failed items respond 422 and are rolled back by Transactional, as are
+// partial batches where no item succeeded.
+func batchStatus(resp *BatchResponse, success int) int {
+	switch {
+	case resp.Failed == 0:
+		return success
+	case resp.Mode == BatchModeAtomic || resp.Succeeded == 0:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusMultiStatus
+	}
+}
+
+// skipValidItems marks the valid items of an atomic batch with failures as
+// skipped, nothing is applied
+func skipValidItems(resp *BatchResponse) {
+	for _, result := range resp.Results {
+		if result.Status != BatchItemFailed {
+			result.Status = BatchItemSkipped
+			result.Ad = nil
+		}
+	}
+	resp.Succeeded = 0
+}
+
+// recordBatch reports the items of a batch to the metrics collector. An
+// applied batch is reported once committed, a rejected one is rolled back
+// without being retried and is reported right away.
+func recordBatch(c *gin.Context, operation string, resp *BatchResponse) {
+	accountID := middleware.CurrentAccountID(c)
+	record := func() {
+		if collector := metrics.GetCollector(); collector != nil {
+			collector.RecordBatchItems(accountID, operation, resp.Succeeded, resp.Failed)
+		}
+	}
+
+	if batchStatus(resp, http.StatusOK) == http.StatusUnprocessableEntity {
+		record()
+		return
+	}
+	afterCommit(c, record)
+}
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostAdsBatch(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		valid := func(title string) PostAdsHandlerRequest {
+			return PostAdsHandlerRequest{
+				Title:     title,
+				ImageURL:  "https://example.com/ad.jpg",
+				Placement: "homepage",
+			}
+		}
+		invalid := PostAdsHandlerRequest{Title: "bad", ImageURL: "https://example.com/ad.jpg", Placement: "unknown"}
+		countAds := func() int {
+			var page struct {
+				Ads []*store.AdvertiseRecord `json:"ads"`
+			}
+			require.Equal(t, http.StatusOK, srv.do(http.MethodGet, "/v1/ads", nil, &page))
+			return len(page.Ads)
+		}
+
+		// Atomic batches with an invalid item create nothing
+		var resp BatchResponse
+		status := srv.do(http.MethodPost, "/v1/ads:batch", PostAdsBatchHandlerRequest{
+			Ads: []PostAdsHandlerRequest{valid("one"), invalid, {Title: "missing image"}},
+		}, &resp)
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+		assert.Equal(t, BatchModeAtomic, resp.Mode)
+		assert.Equal(t, 0, resp.Succeeded)
+		assert.Equal(t, 2, resp.Failed)
+		require.Len(t, resp.Results, 3)
+		assert.Equal(t, BatchItemSkipped, resp.Results[0].Status)
+		assert.Equal(t, BatchItemFailed, resp.Results[1].Status)
+		assert.NotEmpty(t, resp.Results[1].Fields)
+		assert.Equal(t, BatchItemFailed, resp.Results[2].Status)
+		assert.Equal(t, 0, countAds())
+
+		// Partial batches create the valid items
+		resp = BatchResponse{}
+		status = srv.do(http.MethodPost, "/v1/ads:batch", PostAdsBatchHandlerRequest{
+			Mode: BatchModePartial,
+			Ads:  []PostAdsHandlerRequest{valid("one"), invalid, valid("two")},
+		}, &resp)
+		require.Equal(t, http.StatusMultiStatus, status)
+		assert.Equal(t, 2, resp.Succeeded)
+		assert.Equal(t, 1, resp.Failed)
+		assert.Equal(t, BatchItemCreated, resp.Results[2].Status)
+		assert.NotEmpty(t, resp.Results[2].ID)
+		assert.Equal(t, 2, countAds())
+
+		resp = BatchResponse{}
+		status = srv.do(http.MethodPost, "/v1/ads:batch", PostAdsBatchHandlerRequest{
+			Ads: []PostAdsHandlerRequest{valid("three")},
+		}, &resp)
+		require.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, 3, countAds())
+
+		status = srv.do(http.MethodPost, "/v1/ads:batch", PostAdsBatchHandlerRequest{}, nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		status = srv.do(http.MethodPost, "/v1/ads:unknown", nil, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+}
+
+func TestPostAdsBatchDeactivate(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		var created BatchResponse
+		status := srv.do(http.MethodPost, "/v1/ads:batch", PostAdsBatchHandlerRequest{
+			Ads: []PostAdsHandlerRequest{
+				{Title: "home 1", ImageURL: "https://example.com/1.jpg", Placement: "homepage"},
+				{Title: "home 2", ImageURL: "https://example.com/2.jpg", Placement: "homepage"},
+				{Title: "side", ImageURL: "https://example.com/3.jpg", Placement: "sidebar"},
+			},
+		}, &created)
+		require.Equal(t, http.StatusCreated, status)
+		home1, home2, side := created.Results[0].ID, created.Results[1].ID, created.Results[2].ID
+
+		statusOf := func(id string) string {
+			var rec store.AdvertiseRecord
+			require.Equal(t, http.StatusOK, srv.do(http.MethodGet, "/v1/ads/"+id, nil, &rec))
+			return rec.Status
+		}
+
+		// An unknown id fails the whole atomic batch
+		var resp BatchResponse
+		status = srv.do(http.MethodPost, "/v1/ads:batchDeactivate", PostAdsBatchDeactivateHandlerRequest{
+			IDs: []string{home1, "missing"},
+		}, &resp)
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+		assert.Equal(t, BatchItemSkipped, resp.Results[0].Status)
+		assert.Equal(t, BatchItemFailed, resp.Results[1].Status)
+		assert.Equal(t, store.AdvertiseStatusActive, statusOf(home1))
+
+		// Partial batches deactivate the known ids
+		resp = BatchResponse{}
+		status = srv.do(http.MethodPost, "/v1/ads:batchDeactivate", PostAdsBatchDeactivateHandlerRequest{
+			Mode: BatchModePartial,
+			IDs:  []string{side, "missing", side},
+		}, &resp)
+		require.Equal(t, http.StatusMultiStatus, status)
+		assert.Equal(t, 1, resp.Succeeded)
+		assert.Equal(t, 2, resp.Failed)
+		assert.Equal(t, store.AdvertiseStatusInactive, statusOf(side))
+
+		// Filters deactivate every active ad matching them
+		resp = BatchResponse{}
+		status = srv.do(http.MethodPost, "/v1/ads:batchDeactivate", PostAdsBatchDeactivateHandlerRequest{
+			Filter: &AdsBatchFilter{Placement: "homepage"},
+		}, &resp)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, 2, resp.Succeeded)
+		assert.Equal(t, store.AdvertiseStatusInactive, statusOf(home1))
+		assert.Equal(t, store.AdvertiseStatusInactive, statusOf(home2))
+
+		var history AdHistoryResponse
+		srv.do(http.MethodGet, "/v1/ads/"+home1+"/history", nil, &history)
+		require.Len(t, history.History, 2)
+		assert.Equal(t, store.AdAuditActionDeactivate, history.History[1].Action)
+
+		for name, body := range map[string]any{
+			"neither ids nor filter": map[string]any{},
+			"ids and filter":         map[string]any{"ids": []string{home1}, "filter": map[string]any{"placement": "homepage"}},
+			"empty filter":           map[string]any{"filter": map[string]any{}},
+		} {
+			status = srv.do(http.MethodPost, "/v1/ads:batchDeactivate", body, nil)
+			assert.Equal(t, http.StatusBadRequest, status, name)
+		}
+
+		// Ads of other accounts are not found
+		srv.apiKey = srv.insertAPIKey("other-editor", "other", store.APIKeyRoleEditor)
+		status = srv.do(http.MethodPost, "/v1/ads:batchDeactivate", PostAdsBatchDeactivateHandlerRequest{IDs: []string{home1}}, nil)
+		assert.Equal(t, http.StatusUnprocessableEntity, status)
+	})
+}
+
+func TestAdItemsSchema(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		// Items report the fields of the schema like createAd
+		const badURL = "example.com/ad.jpg"
+
+		status := srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{Title: "single", ImageURL: badURL, Placement: "homepage"}, nil)
+		require.Equal(t, http.StatusBadRequest, status)
+
+		var resp BatchResponse
+		status = srv.do(http.MethodPost, "/v1/ads:batch", PostAdsBatchHandlerRequest{
+			Mode: BatchModePartial,
+			Ads: []PostAdsHandlerRequest{
+				{Title: "image", ImageURL: badURL, Placement: "homepage"},
+				{Title: "click", ImageURL: "https://example.com/ad.jpg", ClickURL: badURL, Placement: "homepage"},
+				{Title: "valid", ImageURL: "https://example.com/ad.jpg", Placement: "homepage"},
+			},
+		}, &resp)
+		require.Equal(t, http.StatusMultiStatus, status)
+		assert.Equal(t, 1, resp.Succeeded)
+		assert.Equal(t, []any{map[string]any{
+			"field": "image_url", "code": "invalid_format", "message": "image_url must be a valid URL",
+		}}, resp.Results[0].Fields)
+		assert.Equal(t, []any{map[string]any{
+			"field": "click_url", "code": "invalid_format", "message": "click_url must be a valid URL",
+		}}, resp.Results[1].Fields)
+
+		for format, file := range map[string]string{
+			"csv":   "title,image_url,placement\nImported," + badURL + ",homepage\n",
+			"jsonl": `{"title":"Imported","image_url":"` + badURL + `","placement":"homepage"}` + "\n",
+		} {
+			resp = BatchResponse{}
+			status, _ = srv.doWithHeaders(http.MethodPost, "/v1/ads/import?format="+format, nil, []byte(file), &resp)
+			require.Equal(t, http.StatusUnprocessableEntity, status, format)
+			require.Len(t, resp.Results, 1)
+			assert.Equal(t, "Validation failed", resp.Results[0].Error, format)
+			assert.NotEmpty(t, resp.Results[0].Fields, format)
+		}
+	})
+}
+
+func TestPostAdsBatchDeactivateChangedAds(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		createAds := func() []string {
+			var created BatchResponse
+			require.Equal(t, http.StatusCreated, srv.do(http.MethodPost, "/v1/ads:batch", PostAdsBatchHandlerRequest{
+				Ads: []PostAdsHandlerRequest{
+					{Title: "first", ImageURL: "https://example.com/1.jpg", Placement: "homepage"},
+					{Title: "raced", ImageURL: "https://example.com/2.jpg", Placement: "homepage"},
+					{Title: "last", ImageURL: "https://example.com/3.jpg", Placement: "homepage"},
+				},
+			}, &created))
+			return []string{created.Results[0].ID, created.Results[1].ID, created.Results[2].ID}
+		}
+		statusOf := func(id string) string {
+			var rec store.AdvertiseRecord
+			require.Equal(t, http.StatusOK, srv.do(http.MethodGet, "/v1/ads/"+id, nil, &rec))
+			return rec.Status
+		}
+
+		// The second ad expires between the read and the update of the batch
+		db := srv.ctx.Db
+		defer func() { srv.ctx.Db = db }()
+		raceExpiry := func(id string) {
+			srv.ctx.Db = &expiringDatabase{Database: db, expire: id}
+		}
+
+		ids := createAds()
+		raceExpiry(ids[1])
+		var resp BatchResponse
+		status := srv.do(http.MethodPost, "/v1/ads:batchDeactivate", PostAdsBatchDeactivateHandlerRequest{
+			Mode: BatchModePartial,
+			IDs:  []string{ids[0], "missing", ids[1], ids[2]},
+		}, &resp)
+		require.Equal(t, http.StatusMultiStatus, status)
+		assert.Equal(t, BatchItemDeactivated, resp.Results[0].Status)
+		assert.Equal(t, BatchItemFailed, resp.Results[1].Status)
+		assert.Equal(t, BatchItemFailed, resp.Results[2].Status)
+		assert.Equal(t, "Ad not found or expired", resp.Results[2].Error)
+		assert.Equal(t, ids[1], resp.Results[2].ID)
+		assert.Equal(t, BatchItemDeactivated, resp.Results[3].Status)
+		assert.Equal(t, 2, resp.Succeeded)
+		assert.Equal(t, 2, resp.Failed)
+
+		srv.ctx.Db = db
+		assert.Equal(t, store.AdvertiseStatusInactive, statusOf(ids[0]))
+		assert.Equal(t, store.AdvertiseStatusExpired, statusOf(ids[1]))
+		assert.Equal(t, store.AdvertiseStatusInactive, statusOf(ids[2]))
+
+		// An atomic batch applies nothing
+		ids = createAds()
+		raceExpiry(ids[1])
+		status = srv.do(http.MethodPost, "/v1/ads:batchDeactivate", PostAdsBatchDeactivateHandlerRequest{IDs: ids}, nil)
+		require.Equal(t, http.StatusConflict, status)
+
+		srv.ctx.Db = db
+		assert.Equal(t, store.AdvertiseStatusActive, statusOf(ids[0]))
+		assert.Equal(t, store.AdvertiseStatusActive, statusOf(ids[2]))
+	})
+}
+
+// expiringDatabase expires an ad, as the reaper does, in the transaction of
+// the request right before its first update
+type expiringDatabase struct {
+	store.Database
+	expire string
+}
+
+func (d *expiringDatabase) BeginTx(ctx context.Context) (store.Transactioner, error) {
+	tx, err := d.Database.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &expiringTx{Transactioner: tx, db: d}, nil
+}
+
+type expiringTx struct {
+	store.Transactioner
+	db *expiringDatabase
+}
+
+func (tx *expiringTx) ExecContext(ctx context.Context, statement string, params ...any) (sql.Result, error) {
+	if id := tx.db.expire; id != "" && strings.HasPrefix(statement, "UPDATE ads") {
+		tx.db.expire = ""
+		expired := store.AdvertiseStatusExpired
+		err := query.UpdateAds(ctx, tx.Transactioner, &query.UpdateAdsArgs{
+			AccountID: store.AccountDefaultID,
+			ID:        id,
+			Status:    &expired,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tx.Transactioner.ExecContext(ctx, statement, params...)
+}
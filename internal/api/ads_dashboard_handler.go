@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
 	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
 )
 
 // DashboardData contiene los datos para el template
@@ -20,9 +22,11 @@ type DashboardData struct {
 
 // AdsDashboardHandler maneja el endpoint para mostrar el dashboard de anuncios
 func AdsDashboardHandler(c *gin.Context, ctx *Context) (any, int, error) {
-	// Obtener todos los anuncios
-	var ads []*store.AdvertiseRecord
-	err := ctx.Db.Select(&ads, "SELECT * FROM ads ORDER BY created_at DESC")
+	// Obtener todos los anuncios de la cuenta autenticada
+	ads, err := query.SelectAds(c.Request.Context(), ctx.Db, &query.SelectAdsArgs{
+		AccountID: middleware.CurrentAccountID(c),
+		Order:     &query.SortOrder{Field: "created_at", Desc: true},
+	})
 	if err != nil {
 		return nil, http.StatusInternalServerError, err
 	}
@@ -75,18 +79,18 @@ func calculateStats(ads []*store.AdvertiseRecord) Stats {
 		stats.TotalAds++
 
 		// Calcular si está expirado
-		if ad.ExpiresAt != nil {
-			ad.CalculateAndSetExpired()
-			if ad.Expired {
-				stats.ExpiredAds++
-			}
-		}
-
-		// Contar por estado
-		switch ad.Status {
-		case store.AdvertiseStatusActive:
+		ad.CalculateAndSetExpired()
+
+		// Contar por estado, cada anuncio cuenta una sola vez. Los activos ya
+		// expirados que el reaper todavía no procesó cuentan como expirados
+		switch {
+		case ad.Status == store.AdvertiseStatusExpired:
+			stats.ExpiredAds++
+		case ad.Status == store.AdvertiseStatusActive && ad.Expired:
+			stats.ExpiredAds++
+		case ad.Status == store.AdvertiseStatusActive:
 			stats.ActiveAds++
-		case store.AdvertiseStatusInactive:
+		case ad.Status == store.AdvertiseStatusInactive:
 			stats.InactiveAds++
 		}
 	}
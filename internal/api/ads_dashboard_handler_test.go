@@ -0,0 +1,32 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateStats(t *testing.T) {
+	past := time.Now().Add(-time.Hour).Unix()
+	future := time.Now().Add(time.Hour).Unix()
+
+	ads := []*store.AdvertiseRecord{
+		{ID: "1", Status: store.AdvertiseStatusActive},
+		{ID: "2", Status: store.AdvertiseStatusActive, ExpiresAt: &future},
+		{ID: "3", Status: store.AdvertiseStatusActive, ExpiresAt: &past}, // not reaped yet
+		{ID: "4", Status: store.AdvertiseStatusExpired, ExpiresAt: &past},
+		{ID: "5", Status: store.AdvertiseStatusInactive},
+		{ID: "6", Status: store.AdvertiseStatusInactive, ExpiresAt: &past},
+	}
+
+	stats := calculateStats(ads)
+
+	assert.Equal(t, Stats{
+		TotalAds:    6,
+		ActiveAds:   2,
+		InactiveAds: 2,
+		ExpiredAds:  2,
+	}, stats)
+}
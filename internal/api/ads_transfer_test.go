@@ -0,0 +1,287 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostAdsImport(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		countAds := func() int {
+			var page struct {
+				Ads []*store.AdvertiseRecord `json:"ads"`
+			}
+			require.Equal(t, http.StatusOK, srv.do(http.MethodGet, "/v1/ads", nil, &page))
+			return len(page.Ads)
+		}
+		csvHeaders := map[string]string{"Content-Type": "text/csv"}
+
+		file := []byte("\ufefftitle,image_url,placement,weight,ttl\n" +
+			"One,https://example.com/1.jpg,homepage,2,60\n" +
+			"Two,https://example.com/2.jpg,unknown,1,\n" +
+			"Three,https://example.com/3.jpg,sidebar,heavy,\n" +
+			"\"Four, quoted\",https://example.com/4.jpg,sidebar,,\n")
+
+		// Dry runs only report
+		var resp BatchResponse
+		status, _ := srv.doWithHeaders(http.MethodPost, "/v1/ads/import?dry_run=true", csvHeaders, file, &resp)
+		require.Equal(t, http.StatusOK, status)
+		assert.True(t, resp.DryRun)
+		assert.Equal(t, 2, resp.Succeeded)
+		assert.Equal(t, 2, resp.Failed)
+		require.Len(t, resp.Results, 4)
+		assert.Equal(t, BatchItemValid, resp.Results[0].Status)
+		assert.Equal(t, 2, resp.Results[0].Line)
+		assert.Equal(t, BatchItemFailed, resp.Results[1].Status)
+		assert.Equal(t, 3, resp.Results[1].Line)
+		assert.Equal(t, BatchItemFailed, resp.Results[2].Status)
+		assert.Contains(t, resp.Results[2].Details, "weight must be an integer")
+		assert.Equal(t, 0, countAds())
+
+		// Atomic imports with invalid rows create nothing
+		resp = BatchResponse{}
+		status, _ = srv.doWithHeaders(http.MethodPost, "/v1/ads/import", csvHeaders, file, &resp)
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+		assert.Equal(t, BatchItemSkipped, resp.Results[0].Status)
+		assert.Equal(t, 0, countAds())
+
+		resp = BatchResponse{}
+		status, _ = srv.doWithHeaders(http.MethodPost, "/v1/ads/import?mode=partial", csvHeaders, file, &resp)
+		require.Equal(t, http.StatusMultiStatus, status)
+		assert.Equal(t, BatchItemCreated, resp.Results[3].Status)
+		assert.Equal(t, 2, countAds())
+
+		var created store.AdvertiseRecord
+		require.Equal(t, http.StatusOK, srv.do(http.MethodGet, "/v1/ads/"+resp.Results[0].ID, nil, &created))
+		assert.Equal(t, int64(2), created.Weight)
+		assert.NotNil(t, created.ExpiresAt)
+
+		// JSON Lines, the format given by the query
+		lines := []byte(`{"title":"Five","image_url":"https://example.com/5.jpg","placement":"homepage"}` + "\n\n" +
+			`{"title":"Six","image_url":"https://example.com/6.jpg","placement":"homepage"}` + "\n")
+		resp = BatchResponse{}
+		status, _ = srv.doWithHeaders(http.MethodPost, "/v1/ads/import?format=jsonl", nil, lines, &resp)
+		require.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, 3, resp.Results[1].Line)
+		assert.Equal(t, 4, countAds())
+
+		resp = BatchResponse{}
+		status, _ = srv.doWithHeaders(http.MethodPost, "/v1/ads/import?format=jsonl&mode=partial", nil, []byte("{not json\n"), &resp)
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+		assert.Equal(t, "Invalid JSON", resp.Results[0].Error)
+
+		for name, file := range map[string]string{
+			"missing column": "title,image_url\nOne,https://example.com/1.jpg\n",
+			"broken quote":   "title,image_url,placement\n\"One,https://example.com/1.jpg,homepage\n",
+			"no rows":        "title,image_url,placement\n",
+		} {
+			status, _ = srv.doWithHeaders(http.MethodPost, "/v1/ads/import", csvHeaders, []byte(file), nil)
+			assert.Equal(t, http.StatusBadRequest, status, name)
+		}
+
+		status, _ = srv.doWithHeaders(http.MethodPost, "/v1/ads/import", nil, lines, nil)
+		assert.Equal(t, http.StatusBadRequest, status, "unknown format")
+	})
+}
+
+func TestGetAdsExport(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		// More ads than an export page
+		ads := make([]PostAdsHandlerRequest, 0, adsExportPageSize+2)
+		for i := 0; i < adsExportPageSize+2; i++ {
+			placement := "homepage"
+			if i%2 == 1 {
+				placement = "sidebar"
+			}
+			ads = append(ads, PostAdsHandlerRequest{
+				Title:     "Ad, quoted",
+				ImageURL:  "https://example.com/ad.jpg",
+				Placement: placement,
+				Ttl:       60,
+			})
+		}
+		require.Equal(t, http.StatusCreated, srv.do(http.MethodPost, "/v1/ads:batch", PostAdsBatchHandlerRequest{Ads: ads}, nil))
+
+		var body []byte
+		status, headers := srv.doWithHeaders(http.MethodGet, "/v1/ads/export", nil, nil, &body)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "text/csv; charset=utf-8", headers.Get("Content-Type"))
+		assert.Contains(t, headers.Get("Content-Disposition"), "ads.csv")
+
+		records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, len(ads)+1)
+		assert.Equal(t, adExportColumns, records[0])
+		assert.Equal(t, "Ad, quoted", records[1][1])
+
+		ids := map[string]bool{}
+		for _, record := range records[1:] {
+			ids[record[0]] = true
+		}
+		assert.Len(t, ids, len(ads), "every ad exported once")
+
+		status, headers = srv.doWithHeaders(http.MethodGet, "/v1/ads/export?format=jsonl&placement=sidebar", nil, nil, &body)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "application/x-ndjson", headers.Get("Content-Type"))
+		lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+		assert.Len(t, lines, len(ads)/2)
+		assert.Contains(t, lines[0], `"placement":"sidebar"`)
+
+		// An export imports back
+		var resp BatchResponse
+		status, _ = srv.doWithHeaders(http.MethodPost, "/v1/ads/import?format=jsonl", nil, body, &resp)
+		require.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, len(ads)/2, resp.Succeeded)
+
+		// Empty exports still carry the CSV header
+		status, _ = srv.doWithHeaders(http.MethodGet, "/v1/ads/export?placement=footer", nil, nil, &body)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, strings.Join(adExportColumns, ",")+"\n", string(body))
+
+		status = srv.do(http.MethodGet, "/v1/ads/export?format=xml", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+		status = srv.do(http.MethodGet, "/v1/ads/export?lifecycle=unknown", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+}
+
+func TestGetAdsExportFormulas(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		escaped := map[string]string{
+			`=HYPERLINK("https://evil.example","open")`: `'=HYPERLINK("https://evil.example","open")`,
+			"+1":        "'+1",
+			"-1":        "'-1",
+			"@SUM(A1)":  "'@SUM(A1)",
+			"'=quoted":  "''=quoted",
+			"'plain":    "'plain",
+			"Plain, ad": "Plain, ad",
+		}
+		ads := make([]PostAdsHandlerRequest, 0, len(escaped))
+		for title := range escaped {
+			ads = append(ads, PostAdsHandlerRequest{Title: title, ImageURL: "https://example.com/ad.jpg", Placement: "homepage"})
+		}
+		require.Equal(t, http.StatusCreated, srv.do(http.MethodPost, "/v1/ads:batch", PostAdsBatchHandlerRequest{Ads: ads}, nil))
+
+		var body []byte
+		status, _ := srv.doWithHeaders(http.MethodGet, "/v1/ads/export", nil, nil, &body)
+		require.Equal(t, http.StatusOK, status)
+		records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, len(ads)+1)
+
+		exported := map[string]bool{}
+		for _, record := range records[1:] {
+			exported[record[1]] = true
+		}
+		for _, cell := range escaped {
+			assert.True(t, exported[cell], cell)
+		}
+
+		// The escaped titles import back as they were
+		var resp BatchResponse
+		status, _ = srv.doWithHeaders(http.MethodPost, "/v1/ads/import?format=csv", nil, body, &resp)
+		require.Equal(t, http.StatusCreated, status)
+		for _, result := range resp.Results {
+			var rec store.AdvertiseRecord
+			require.Equal(t, http.StatusOK, srv.do(http.MethodGet, "/v1/ads/"+result.ID, nil, &rec))
+			assert.Contains(t, escaped, rec.Title)
+		}
+		assert.Len(t, resp.Results, len(ads))
+	})
+}
+
+func TestAdsExportImportRoundTrip(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		now := time.Now()
+		startsAt := now.Add(time.Hour).Unix()
+		expiresAt := now.Add(3 * time.Hour).Unix()
+		expiredAt := now.Add(-time.Hour).Unix()
+
+		create := func(req PostAdsHandlerRequest) string {
+			var rec store.AdvertiseRecord
+			req.ImageURL = "https://example.com/" + req.Title + ".jpg"
+			require.Equal(t, http.StatusCreated, srv.do(http.MethodPost, "/v1/ads", req, &rec))
+			return rec.ID
+		}
+		create(PostAdsHandlerRequest{Title: "plain", Placement: "homepage", Weight: 3})
+		inactive := create(PostAdsHandlerRequest{Title: "inactive", Placement: "homepage"})
+		require.Equal(t, http.StatusOK, srv.do(http.MethodPost, "/v1/ads/"+inactive+"/deactivate", nil, nil))
+		absolute := create(PostAdsHandlerRequest{Title: "absolute", Placement: "sidebar", StartsAt: startsAt})
+		require.Equal(t, http.StatusOK, srv.do(http.MethodPatch, "/v1/ads/"+absolute, map[string]any{"expires_at": expiresAt}, nil))
+		// Expired by the reaper
+		require.NoError(t, query.InsertAds(context.Background(), srv.ctx.Db, &store.AdvertiseRecord{
+			ID:        "expired",
+			AccountID: store.AccountDefaultID,
+			Title:     "expired",
+			ImageURL:  "https://example.com/expired.jpg",
+			Placement: "homepage",
+			Status:    store.AdvertiseStatusExpired,
+			Weight:    store.AdvertiseDefaultWeight,
+			CreatedAt: now.Add(-2 * time.Hour).Unix(),
+			ExpiresAt: &expiredAt,
+		}))
+
+		type state struct {
+			Status    string
+			Weight    int64
+			StartsAt  *int64
+			ExpiresAt *int64
+		}
+		want := map[string]state{
+			"plain":    {Status: store.AdvertiseStatusActive, Weight: 3},
+			"inactive": {Status: store.AdvertiseStatusInactive, Weight: 1},
+			"absolute": {Status: store.AdvertiseStatusActive, Weight: 1, StartsAt: &startsAt, ExpiresAt: &expiresAt},
+			"expired":  {Status: store.AdvertiseStatusExpired, Weight: 1, ExpiresAt: &expiredAt},
+		}
+
+		// Both files are exported before importing any of them
+		exports := map[string][]byte{}
+		for _, format := range []string{AdsFormatCSV, AdsFormatJSONL} {
+			var body []byte
+			status, _ := srv.doWithHeaders(http.MethodGet, "/v1/ads/export?format="+format, nil, nil, &body)
+			require.Equal(t, http.StatusOK, status)
+			exports[format] = body
+		}
+
+		for format, body := range exports {
+			var resp BatchResponse
+			status, _ := srv.doWithHeaders(http.MethodPost, "/v1/ads/import?format="+format, nil, body, &resp)
+			require.Equal(t, http.StatusCreated, status, format)
+			require.Equal(t, len(want), resp.Succeeded, format)
+
+			for _, result := range resp.Results {
+				var rec store.AdvertiseRecord
+				require.Equal(t, http.StatusOK, srv.do(http.MethodGet, "/v1/ads/"+result.ID, nil, &rec))
+				assert.Equal(t, want[rec.Title], state{
+					Status:    rec.Status,
+					Weight:    rec.Weight,
+					StartsAt:  rec.StartsAt,
+					ExpiresAt: rec.ExpiresAt,
+				}, format+" "+rec.Title)
+			}
+		}
+
+		// A state that does not fit the ad is reported on its row
+		file := "title,image_url,placement,status,ttl,expires_at\n" +
+			"a,https://example.com/a.jpg,homepage,paused,,\n" +
+			"b,https://example.com/b.jpg,homepage,expired,,\n" +
+			fmt.Sprintf("c,https://example.com/c.jpg,homepage,,60,%d\n", expiresAt)
+		var resp BatchResponse
+		status, _ := srv.doWithHeaders(http.MethodPost, "/v1/ads/import?format=csv&mode=partial", nil, []byte(file), &resp)
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+		for i, code := range []string{"invalid_status", "not_expired", "exclusive_fields"} {
+			assert.Contains(t, fmt.Sprint(resp.Results[i].Fields), code)
+		}
+	})
+}
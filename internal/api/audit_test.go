@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/mtavano/admoai-takehome/internal/audit"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdsHistory(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		var created store.AdvertiseRecord
+		status := srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title:     "Original",
+			ImageURL:  "https://example.com/original.jpg",
+			Placement: "homepage",
+		}, &created)
+		require.Equal(t, http.StatusCreated, status)
+
+		status = srv.do(http.MethodPatch, "/v1/ads/"+created.ID, map[string]any{"title": "Renamed", "weight": 5}, nil)
+		require.Equal(t, http.StatusOK, status)
+
+		// A rejected update leaves no trace
+		status = srv.do(http.MethodPatch, "/v1/ads/"+created.ID, map[string]any{"placement": "unknown"}, nil)
+		require.Equal(t, http.StatusBadRequest, status)
+
+		status = srv.do(http.MethodPost, "/v1/ads/"+created.ID+"/deactivate", nil, nil)
+		require.Equal(t, http.StatusOK, status)
+
+		var page AdHistoryResponse
+		status = srv.do(http.MethodGet, "/v1/ads/"+created.ID+"/history", nil, &page)
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, page.History, 3)
+
+		actions := make([]string, 0, len(page.History))
+		for _, entry := range page.History {
+			actions = append(actions, entry.Action)
+			assert.Equal(t, created.ID, entry.AdID)
+			assert.Equal(t, store.AccountDefaultID, entry.AccountID)
+			assert.Equal(t, audit.APIKeyActor("test-admin"), entry.Actor)
+			assert.NotEmpty(t, entry.RequestID)
+			assert.NotNil(t, entry.After)
+		}
+		assert.Equal(t, []string{
+			store.AdAuditActionCreate,
+			store.AdAuditActionUpdate,
+			store.AdAuditActionDeactivate,
+		}, actions)
+
+		// Creation has no previous snapshot
+		assert.Nil(t, page.History[0].Before)
+
+		var changes map[string]audit.Change
+		require.NoError(t, json.Unmarshal(page.History[1].Changes, &changes))
+		assert.Equal(t, audit.Change{From: "Original", To: "Renamed"}, changes["title"])
+		assert.Equal(t, audit.Change{From: float64(1), To: float64(5)}, changes["weight"])
+		assert.Len(t, changes, 2)
+
+		changes = nil
+		require.NoError(t, json.Unmarshal(page.History[2].Changes, &changes))
+		assert.Equal(t, map[string]audit.Change{
+			"status": {From: store.AdvertiseStatusActive, To: store.AdvertiseStatusInactive},
+		}, changes)
+
+		// Other accounts cannot read the history
+		srv.apiKey = srv.insertAPIKey("other-reader", "other", store.APIKeyRoleReader)
+		status = srv.do(http.MethodGet, "/v1/ads/"+created.ID+"/history", nil, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+}
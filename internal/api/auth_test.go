@@ -0,0 +1,171 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyAuthorization(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		adminKey := srv.apiKey
+
+		createKey := func(role string) PostAPIKeysHandlerResponse {
+			var created PostAPIKeysHandlerResponse
+			status := srv.do(http.MethodPost, "/v1/api-keys", PostAPIKeysHandlerRequest{
+				Name: role + " key",
+				Role: role,
+			}, &created)
+			require.Equal(t, http.StatusCreated, status)
+			require.NotEmpty(t, created.Key)
+			assert.Equal(t, created.KeyPrefix, created.Key[:len(created.KeyPrefix)])
+			return created
+		}
+
+		reader := createKey(store.APIKeyRoleReader)
+		editor := createKey(store.APIKeyRoleEditor)
+
+		status := srv.do(http.MethodPost, "/v1/api-keys", PostAPIKeysHandlerRequest{
+			Name: "bad role",
+			Role: "owner",
+		}, nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		newAd := PostAdsHandlerRequest{
+			Title:     "Summer sale",
+			ImageURL:  "https://example.com/summer.jpg",
+			Placement: "homepage",
+		}
+
+		testCases := []struct {
+			name     string
+			key      string
+			method   string
+			path     string
+			body     any
+			expected int
+		}{
+			{"missing key", "", http.MethodGet, "/v1/ads", nil, http.StatusUnauthorized},
+			{"unknown key", "admoai_unknown", http.MethodGet, "/v1/ads", nil, http.StatusUnauthorized},
+			{"reader lists ads", reader.Key, http.MethodGet, "/v1/ads", nil, http.StatusOK},
+			{"dashboard requires key", "", http.MethodGet, "/dashboard", nil, http.StatusUnauthorized},
+			{"reader cannot create ads", reader.Key, http.MethodPost, "/v1/ads", newAd, http.StatusForbidden},
+			{"editor creates ads", editor.Key, http.MethodPost, "/v1/ads", newAd, http.StatusCreated},
+			{"editor cannot manage keys", editor.Key, http.MethodGet, "/v1/api-keys", nil, http.StatusForbidden},
+			{"serving is public", "", http.MethodGet, "/v1/serve/homepage", nil, http.StatusNoContent},
+			{"health is public", "", http.MethodGet, "/health", nil, http.StatusOK},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				srv.apiKey = tc.key
+				var payload map[string]any
+				if tc.expected == http.StatusUnauthorized || tc.expected == http.StatusForbidden {
+					status := srv.do(tc.method, tc.path, tc.body, &payload)
+					assert.Equal(t, tc.expected, status)
+					assert.NotEmpty(t, payload["error"])
+					return
+				}
+				assert.Equal(t, tc.expected, srv.do(tc.method, tc.path, tc.body, nil))
+			})
+		}
+
+		// Revoked keys are rejected right away
+		srv.apiKey = adminKey
+		status = srv.do(http.MethodDelete, "/v1/api-keys/"+reader.ID, nil, nil)
+		assert.Equal(t, http.StatusOK, status)
+		status = srv.do(http.MethodDelete, "/v1/api-keys/"+reader.ID, nil, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+		status = srv.do(http.MethodDelete, "/v1/api-keys/test-admin", nil, nil)
+		assert.Equal(t, http.StatusConflict, status)
+
+		var listed struct {
+			APIKeys []*store.APIKeyRecord `json:"api_keys"`
+		}
+		status = srv.do(http.MethodGet, "/v1/api-keys?active=true", nil, &listed)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Len(t, listed.APIKeys, 2)
+
+		srv.apiKey = reader.Key
+		assert.Equal(t, http.StatusUnauthorized, srv.do(http.MethodGet, "/v1/ads", nil, nil))
+	})
+}
+
+func TestDashboardLinks(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		readerKey := srv.insertAPIKey("reader", store.AccountDefaultID, store.APIKeyRoleReader)
+
+		// API keys are never read from the URL
+		srv.apiKey = ""
+		assert.Equal(t, http.StatusUnauthorized, srv.do(http.MethodGet, "/dashboard?api_key="+readerKey, nil, nil))
+		assert.Equal(t, http.StatusUnauthorized, srv.do(http.MethodGet, "/v1/ads?api_key="+readerKey, nil, nil))
+		assert.Equal(t, http.StatusUnauthorized, srv.do(http.MethodGet, "/dashboard?token="+readerKey, nil, nil))
+
+		srv.apiKey = readerKey
+		var link DashboardLinkResponse
+		require.Equal(t, http.StatusCreated, srv.do(http.MethodPost, "/v1/dashboard/links", nil, &link))
+		assert.Greater(t, link.ExpiresAt, time.Now().Unix())
+		parsed, err := url.Parse(link.URL)
+		require.NoError(t, err)
+		assert.Equal(t, "/dashboard", parsed.Path)
+		token := parsed.Query().Get("token")
+		require.NotEmpty(t, token)
+
+		// The dashboard forwards the token with the role of its key, on its
+		// own routes only
+		// The template is read relative to the repository root, so only the
+		// authentication is checked here
+		srv.apiKey = ""
+		assert.NotContains(t, []int{http.StatusUnauthorized, http.StatusForbidden}, srv.do(http.MethodGet, "/dashboard?token="+url.QueryEscape(token), nil, nil))
+		srv.apiKey = token
+		assert.Equal(t, http.StatusForbidden, srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title:     "Summer sale",
+			ImageURL:  "https://example.com/summer.jpg",
+			Placement: "homepage",
+		}, nil))
+		assert.Equal(t, http.StatusForbidden, srv.do(http.MethodGet, "/v1/ads", nil, nil))
+		assert.Equal(t, http.StatusForbidden, srv.do(http.MethodPost, "/v1/dashboard/links", nil, nil))
+
+		srv.apiKey = ""
+		assert.Equal(t, http.StatusUnauthorized, srv.do(http.MethodGet, "/dashboard?token="+url.QueryEscape(token+"x"), nil, nil))
+
+		// Links expire and die with their key
+		_, ok := srv.ctx.LinkTokens.Verify(token, time.Unix(link.ExpiresAt, 0))
+		assert.False(t, ok)
+		srv.apiKey = srv.insertAPIKey("admin", store.AccountDefaultID, store.APIKeyRoleAdmin)
+		require.Equal(t, http.StatusOK, srv.do(http.MethodDelete, "/v1/api-keys/reader", nil, nil))
+		srv.apiKey = ""
+		assert.Equal(t, http.StatusUnauthorized, srv.do(http.MethodGet, "/dashboard?token="+url.QueryEscape(token), nil, nil))
+
+		// The link of an admin key is capped at editor on the routes of the
+		// dashboard and never reaches the API keys
+		adminKey := srv.insertAPIKey("admin-links", store.AccountDefaultID, store.APIKeyRoleAdmin)
+		srv.apiKey = adminKey
+		require.Equal(t, http.StatusCreated, srv.do(http.MethodPost, "/v1/dashboard/links", nil, &link))
+		parsed, err = url.Parse(link.URL)
+		require.NoError(t, err)
+		adminToken := parsed.Query().Get("token")
+
+		srv.apiKey = adminToken
+		var ad store.AdvertiseRecord
+		require.Equal(t, http.StatusCreated, srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title:     "Summer sale",
+			ImageURL:  "https://example.com/summer.jpg",
+			Placement: "homepage",
+		}, &ad))
+		assert.Equal(t, http.StatusOK, srv.do(http.MethodPost, "/v1/ads/"+ad.ID+"/deactivate", nil, nil))
+		assert.Equal(t, http.StatusForbidden, srv.do(http.MethodPost, "/v1/api-keys", PostAPIKeysHandlerRequest{
+			Name: "minted",
+			Role: store.APIKeyRoleAdmin,
+		}, nil))
+		assert.Equal(t, http.StatusForbidden, srv.do(http.MethodGet, "/v1/api-keys", nil, nil))
+		assert.Equal(t, http.StatusForbidden, srv.do(http.MethodDelete, "/v1/api-keys/admin", nil, nil))
+		assert.Equal(t, http.StatusForbidden, srv.do(http.MethodPatch, "/v1/ads/"+ad.ID, map[string]any{"title": "Winter sale"}, nil))
+		assert.Equal(t, http.StatusForbidden, srv.do(http.MethodGet, "/metrics", nil, nil))
+	})
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCampaignPauseHidesAds(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		var advertiser store.AdvertiserRecord
+		status := srv.do(http.MethodPost, "/v1/advertisers", PostAdvertisersHandlerRequest{Name: "Acme"}, &advertiser)
+		require.Equal(t, http.StatusCreated, status)
+
+		var campaign store.CampaignRecord
+		status = srv.do(http.MethodPost, "/v1/campaigns", PostCampaignsHandlerRequest{
+			AdvertiserID: advertiser.ID,
+			Name:         "Summer",
+		}, &campaign)
+		require.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, store.CampaignStatusActive, campaign.Status)
+
+		status = srv.do(http.MethodPost, "/v1/campaigns", PostCampaignsHandlerRequest{
+			AdvertiserID: "unknown",
+			Name:         "Orphan",
+		}, nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		var inCampaign, standalone store.AdvertiseRecord
+		status = srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title:      "Summer sale",
+			ImageURL:   "https://example.com/summer.jpg",
+			Placement:  "homepage",
+			CampaignID: campaign.ID,
+		}, &inCampaign)
+		require.Equal(t, http.StatusCreated, status)
+		require.NotNil(t, inCampaign.CampaignID)
+		assert.Equal(t, campaign.ID, *inCampaign.CampaignID)
+
+		status = srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title:     "Evergreen",
+			ImageURL:  "https://example.com/evergreen.jpg",
+			Placement: "homepage",
+		}, &standalone)
+		require.Equal(t, http.StatusCreated, status)
+
+		status = srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title:      "Unknown campaign",
+			ImageURL:   "https://example.com/unknown.jpg",
+			Placement:  "homepage",
+			CampaignID: "unknown",
+		}, nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		liveAds := func() []string {
+			var page struct {
+				Ads []*store.AdvertiseRecord `json:"ads"`
+			}
+			require.Equal(t, http.StatusOK, srv.do(http.MethodGet, "/v1/ads?lifecycle=live&sort=title:asc", nil, &page))
+			ids := make([]string, 0, len(page.Ads))
+			for _, ad := range page.Ads {
+				ids = append(ids, ad.ID)
+			}
+			return ids
+		}
+		servable := func() bool {
+			require.NoError(t, srv.ctx.Serving.Refresh(context.Background()))
+			return srv.ctx.Serving.Lookup(inCampaign.ID) != nil
+		}
+
+		assert.Equal(t, []string{standalone.ID, inCampaign.ID}, liveAds())
+		assert.True(t, servable())
+
+		// Pausing the campaign hides its ads in a single call
+		paused := store.CampaignStatusPaused
+		status = srv.do(http.MethodPatch, "/v1/campaigns/"+campaign.ID, PatchCampaignsHandlerRequest{Status: &paused}, &campaign)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, store.CampaignStatusPaused, campaign.Status)
+
+		assert.Equal(t, []string{standalone.ID}, liveAds())
+		assert.False(t, servable())
+
+		// The ads keep their own status and come back when resuming
+		var fetched store.AdvertiseRecord
+		srv.do(http.MethodGet, "/v1/ads/"+inCampaign.ID, nil, &fetched)
+		assert.Equal(t, store.AdvertiseStatusActive, fetched.Status)
+
+		active := store.CampaignStatusActive
+		status = srv.do(http.MethodPatch, "/v1/campaigns/"+campaign.ID, PatchCampaignsHandlerRequest{Status: &active}, nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{standalone.ID, inCampaign.ID}, liveAds())
+		assert.True(t, servable())
+
+		// Campaigns and advertisers in use cannot be deleted
+		assert.Equal(t, http.StatusConflict, srv.do(http.MethodDelete, "/v1/campaigns/"+campaign.ID, nil, nil))
+		assert.Equal(t, http.StatusConflict, srv.do(http.MethodDelete, "/v1/advertisers/"+advertiser.ID, nil, nil))
+
+		detach := ""
+		status = srv.do(http.MethodPatch, "/v1/ads/"+inCampaign.ID, PatchAdsHandlerRequest{CampaignID: &detach}, &fetched)
+		require.Equal(t, http.StatusOK, status)
+		assert.Nil(t, fetched.CampaignID)
+
+		assert.Equal(t, http.StatusOK, srv.do(http.MethodDelete, "/v1/campaigns/"+campaign.ID, nil, nil))
+		assert.Equal(t, http.StatusOK, srv.do(http.MethodDelete, "/v1/advertisers/"+advertiser.ID, nil, nil))
+		assert.Equal(t, http.StatusNotFound, srv.do(http.MethodGet, "/v1/campaigns/"+campaign.ID, nil, nil))
+	})
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+// DeleteAdvertisersHandler removes an advertiser without campaigns
+func DeleteAdvertisersHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	id := c.Param("id")
+	if id == "" {
+		return map[string]any{
+			"error": "ID parameter is required",
+		}, http.StatusBadRequest, nil
+	}
+
+	accountID := middleware.CurrentAccountID(c)
+
+	campaigns, err := query.SelectCampaigns(c.Request.Context(), ctx.Db, &query.SelectCampaignsArgs{
+		AccountID:    accountID,
+		AdvertiserID: id,
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: DeleteAdvertisersHandler query error")
+	}
+	if len(campaigns) > 0 {
+		return map[string]any{
+			"error": "Advertiser still has campaigns",
+		}, http.StatusConflict, nil
+	}
+
+	err = query.DeleteAdvertisers(c.Request.Context(), ctx.Db, accountID, id)
+	if errors.Is(err, query.ErrAdvertiserNotFound) {
+		return map[string]any{
+			"error": "Advertiser not found",
+		}, http.StatusNotFound, nil
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: DeleteAdvertisersHandler delete error")
+	}
+
+	return map[string]any{
+		"message": "Advertiser deleted successfully",
+		"id":      id,
+	}, http.StatusOK, nil
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+// DeleteAPIKeysHandler revokes a key, the record is kept for auditing
+func DeleteAPIKeysHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	id := c.Param("id")
+	if id == "" {
+		return map[string]any{
+			"error": "ID parameter is required",
+		}, http.StatusBadRequest, nil
+	}
+
+	// Revoking the key in use would lock the caller out
+	if current := middleware.CurrentAPIKey(c); current != nil && current.ID == id {
+		return map[string]any{
+			"error": "An API key cannot revoke itself",
+		}, http.StatusConflict, nil
+	}
+
+	err := query.RevokeAPIKeys(c.Request.Context(), ctx.Db, middleware.CurrentAccountID(c), id, time.Now().Unix())
+	if errors.Is(err, query.ErrAPIKeyNotFound) {
+		return map[string]any{
+			"error": "API key not found",
+		}, http.StatusNotFound, nil
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: DeleteAPIKeysHandler revoke error")
+	}
+
+	return map[string]any{
+		"message": "API key revoked successfully",
+		"id":      id,
+	}, http.StatusOK, nil
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+// DeleteCampaignsHandler removes a campaign without ads
+func DeleteCampaignsHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	id := c.Param("id")
+	if id == "" {
+		return map[string]any{
+			"error": "ID parameter is required",
+		}, http.StatusBadRequest, nil
+	}
+
+	accountID := middleware.CurrentAccountID(c)
+
+	ads, err := query.CountAds(c.Request.Context(), ctx.Db, &query.SelectAdsArgs{
+		AccountID:  accountID,
+		CampaignID: id,
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: DeleteCampaignsHandler count error")
+	}
+	if ads > 0 {
+		return map[string]any{
+			"error": "Campaign still has ads",
+		}, http.StatusConflict, nil
+	}
+
+	err = query.DeleteCampaigns(c.Request.Context(), ctx.Db, accountID, id)
+	if errors.Is(err, query.ErrCampaignNotFound) {
+		return map[string]any{
+			"error": "Campaign not found",
+		}, http.StatusNotFound, nil
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: DeleteCampaignsHandler delete error")
+	}
+
+	return map[string]any{
+		"message": "Campaign deleted successfully",
+		"id":      id,
+	}, http.StatusOK, nil
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+// DeletePlacementsHandler removes a placement no ad of any account uses
+func DeletePlacementsHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	name := c.Param("name")
+	if name == "" {
+		return map[string]any{
+			"error": "Name parameter is required",
+		}, http.StatusBadRequest, nil
+	}
+
+	ads, err := query.CountAds(c.Request.Context(), ctx.Db, &query.SelectAdsArgs{
+		AllAccounts: true,
+		Placement:   name,
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: DeletePlacementsHandler count error")
+	}
+	if ads > 0 {
+		return map[string]any{
+			"error": "Placement still has ads",
+		}, http.StatusConflict, nil
+	}
+
+	err = query.DeletePlacements(c.Request.Context(), ctx.Db, name)
+	if errors.Is(err, query.ErrPlacementNotFound) {
+		return map[string]any{
+			"error": "Placement not found",
+		}, http.StatusNotFound, nil
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: DeletePlacementsHandler delete error")
+	}
+
+	return map[string]any{
+		"message": "Placement deleted successfully",
+		"name":    name,
+	}, http.StatusOK, nil
+}
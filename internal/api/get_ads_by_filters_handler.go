@@ -2,43 +2,130 @@ package api
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
 	"github.com/mtavano/admoai-takehome/internal/store"
 	"github.com/mtavano/admoai-takehome/internal/store/query"
 	"github.com/pkg/errors"
 )
 
+const (
+	defaultAdsPageSize = 50
+	maxAdsPageSize     = 500
+)
+
 func GetAdsByFiltersHandler(c *gin.Context, ctx *Context) (any, int, error) {
-	// Get query parameters
-	placement := c.Query("placement")
-	status := c.Query("status")
+	args, payload := adsFilterArgs(c)
+	if payload != nil {
+		return payload, http.StatusBadRequest, nil
+	}
+	order := *args.Order
 
-	var filterExpired bool
-	if status == store.AdvertiseStatusInactive {
-		filterExpired = true
+	// Get pagination parameters
+	limit := uint64(defaultAdsPageSize)
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || parsed == 0 || parsed > maxAdsPageSize {
+			return map[string]any{
+				"error":   "Invalid limit",
+				"details": "limit must be an integer between 1 and " + strconv.Itoa(maxAdsPageSize),
+			}, http.StatusBadRequest, nil
+		}
+		limit = parsed
 	}
 
-	// Create arguments for SelectAds
-	args := &query.SelectAdsArgs{
-		Placement:       placement,
-		Status:          status,
-		FilterByExpired: filterExpired,
+	var cursor *query.Cursor
+	if raw := c.Query("cursor"); raw != "" {
+		var err error
+		cursor, err = query.DecodeCursor(raw)
+		if err != nil {
+			return map[string]any{
+				"error":   "Invalid cursor",
+				"details": err.Error(),
+			}, http.StatusBadRequest, nil
+		}
+		if cursor.Sort != order.String() {
+			return map[string]any{
+				"error":   "Invalid cursor",
+				"details": "cursor was issued for sort " + cursor.Sort,
+			}, http.StatusBadRequest, nil
+		}
 	}
 
+	// Fetch one extra row to know whether there is a next page
+	args.After = cursor
+	args.Limit = limit + 1
+
 	// Query the database
-	records, err := query.SelectAds(ctx.Db, args)
+	records, err := query.SelectAds(c.Request.Context(), ctx.Db, args)
 	if err != nil {
 		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: GetAdsByFiltersHandler query error")
 	}
 
+	total, err := query.CountAds(c.Request.Context(), ctx.Db, args)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: GetAdsByFiltersHandler count error")
+	}
+
+	var nextCursor *string
+	if uint64(len(records)) > limit {
+		records = records[:limit]
+		next := query.NewCursor(order, records[len(records)-1]).Encode()
+		nextCursor = &next
+	}
+
 	for idx, rec := range records {
 		rec.CalculateAndSetExpired()
 		records[idx] = rec
 	}
 
-	// Return all records found (could be empty array)
+	// Return the page found (could be empty array)
 	return map[string]any{
-		"ads": records,
+		"ads":         records,
+		"next_cursor": nextCursor,
+		"total":       total,
 	}, http.StatusOK, nil
 }
+
+// adsFilterArgs parses the filters and sort shared by the ads listing and
+// export. It returns the error payload of an invalid one.
+func adsFilterArgs(c *gin.Context) (*query.SelectAdsArgs, map[string]any) {
+	// Get query parameters
+	placement := c.Query("placement")
+	status := c.Query("status")
+
+	var filterExpired bool
+	if status == store.AdvertiseStatusInactive {
+		filterExpired = true
+	}
+
+	lifecycle := c.Query("lifecycle")
+	switch lifecycle {
+	case "", store.AdvertiseLifecycleScheduled, store.AdvertiseLifecycleLive, store.AdvertiseLifecycleExpired:
+	default:
+		return nil, map[string]any{
+			"error":   "Invalid lifecycle",
+			"details": "lifecycle must be one of scheduled, live or expired",
+		}
+	}
+
+	order, err := query.ParseSortOrder(c.Query("sort"))
+	if err != nil {
+		return nil, map[string]any{
+			"error":   "Invalid sort",
+			"details": err.Error(),
+		}
+	}
+
+	return &query.SelectAdsArgs{
+		AccountID:       middleware.CurrentAccountID(c),
+		Placement:       placement,
+		CampaignID:      c.Query("campaign_id"),
+		Status:          status,
+		FilterByExpired: filterExpired,
+		Lifecycle:       lifecycle,
+		Order:           &order,
+	}, nil
+}
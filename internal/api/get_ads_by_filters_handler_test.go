@@ -2,6 +2,7 @@ package api
 
 import (
 	"database/sql"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -11,12 +12,23 @@ import (
 	"github.com/mtavano/admoai-takehome/internal/store"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_one(t *testing.T) {
 	assert.True(t, true)
 }
 
+// mockCountAds expects the total count query issued after the page select
+func mockCountAds(mockDB *MockDatabase) {
+	mockDB.On("GetContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			dest := args.Get(1).(*int64)
+			*dest = 0
+		}).
+		Return(nil)
+}
+
 // Test cases for GetAdsByFiltersHandler
 type testCase struct {
 	name           string
@@ -58,13 +70,14 @@ func TestGetAdsByFiltersHandler(t *testing.T) {
 					},
 				}
 
-				mockDB.On("Select", mock.Anything, mock.Anything, mock.Anything).
+				mockDB.On("SelectContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Run(func(args mock.Arguments) {
 						// Set the dest argument to our sample data
-						dest := args.Get(0).(*[]*store.AdvertiseRecord)
+						dest := args.Get(1).(*[]*store.AdvertiseRecord)
 						*dest = sampleAds
 					}).
 					Return(nil)
+				mockCountAds(mockDB)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -111,12 +124,13 @@ func TestGetAdsByFiltersHandler(t *testing.T) {
 					},
 				}
 
-				mockDB.On("Select", mock.Anything, mock.Anything, mock.Anything).
+				mockDB.On("SelectContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Run(func(args mock.Arguments) {
-						dest := args.Get(0).(*[]*store.AdvertiseRecord)
+						dest := args.Get(1).(*[]*store.AdvertiseRecord)
 						*dest = sampleAds
 					}).
 					Return(nil)
+				mockCountAds(mockDB)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -153,12 +167,13 @@ func TestGetAdsByFiltersHandler(t *testing.T) {
 					},
 				}
 
-				mockDB.On("Select", mock.Anything, mock.Anything, mock.Anything).
+				mockDB.On("SelectContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Run(func(args mock.Arguments) {
-						dest := args.Get(0).(*[]*store.AdvertiseRecord)
+						dest := args.Get(1).(*[]*store.AdvertiseRecord)
 						*dest = sampleAds
 					}).
 					Return(nil)
+				mockCountAds(mockDB)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -195,12 +210,13 @@ func TestGetAdsByFiltersHandler(t *testing.T) {
 					},
 				}
 
-				mockDB.On("Select", mock.Anything, mock.Anything, mock.Anything).
+				mockDB.On("SelectContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Run(func(args mock.Arguments) {
-						dest := args.Get(0).(*[]*store.AdvertiseRecord)
+						dest := args.Get(1).(*[]*store.AdvertiseRecord)
 						*dest = sampleAds
 					}).
 					Return(nil)
+				mockCountAds(mockDB)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -238,12 +254,13 @@ func TestGetAdsByFiltersHandler(t *testing.T) {
 					},
 				}
 
-				mockDB.On("Select", mock.Anything, mock.Anything, mock.Anything).
+				mockDB.On("SelectContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Run(func(args mock.Arguments) {
-						dest := args.Get(0).(*[]*store.AdvertiseRecord)
+						dest := args.Get(1).(*[]*store.AdvertiseRecord)
 						*dest = sampleAds
 					}).
 					Return(nil)
+				mockCountAds(mockDB)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -269,12 +286,13 @@ func TestGetAdsByFiltersHandler(t *testing.T) {
 			},
 			mockSetup: func(mockDB *MockDatabase) {
 				// Mock empty result
-				mockDB.On("Select", mock.Anything, mock.Anything, mock.Anything).
+				mockDB.On("SelectContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Run(func(args mock.Arguments) {
-						dest := args.Get(0).(*[]*store.AdvertiseRecord)
+						dest := args.Get(1).(*[]*store.AdvertiseRecord)
 						*dest = []*store.AdvertiseRecord{}
 					}).
 					Return(nil)
+				mockCountAds(mockDB)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -287,7 +305,7 @@ func TestGetAdsByFiltersHandler(t *testing.T) {
 			queryParams: map[string]string{},
 			mockSetup: func(mockDB *MockDatabase) {
 				// Mock database error
-				mockDB.On("Select", mock.Anything, mock.Anything, mock.Anything).
+				mockDB.On("SelectContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(sql.ErrConnDone)
 			},
 			expectedStatus: http.StatusInternalServerError,
@@ -323,12 +341,13 @@ func TestGetAdsByFiltersHandler(t *testing.T) {
 					},
 				}
 
-				mockDB.On("Select", mock.Anything, mock.Anything, mock.Anything).
+				mockDB.On("SelectContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Run(func(args mock.Arguments) {
-						dest := args.Get(0).(*[]*store.AdvertiseRecord)
+						dest := args.Get(1).(*[]*store.AdvertiseRecord)
 						*dest = sampleAds
 					}).
 					Return(nil)
+				mockCountAds(mockDB)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -380,6 +399,7 @@ func TestGetAdsByFiltersHandler(t *testing.T) {
 			}
 			req.URL.RawQuery = q.Encode()
 			c.Request = req
+			authenticateTestContext(c, store.AccountDefaultID)
 
 			// Call the handler
 			payload, statusCode, err := GetAdsByFiltersHandler(c, ctx)
@@ -398,6 +418,8 @@ func TestGetAdsByFiltersHandler(t *testing.T) {
 				response, ok := payload.(map[string]interface{})
 				assert.True(t, ok)
 				assert.Contains(t, response, "ads")
+				assert.Contains(t, response, "next_cursor")
+				assert.Contains(t, response, "total")
 
 				// For non-empty responses, check the ads array
 				if ads, ok := response["ads"].([]*store.AdvertiseRecord); ok && len(ads) > 0 {
@@ -480,12 +502,13 @@ func TestGetAdsByFiltersHandlerValidation(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Create mock database
 			mockDB := new(MockDatabase)
-			mockDB.On("Select", mock.Anything, mock.Anything, mock.Anything).
+			mockDB.On("SelectContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 				Run(func(args mock.Arguments) {
-					dest := args.Get(0).(*[]*store.AdvertiseRecord)
+					dest := args.Get(1).(*[]*store.AdvertiseRecord)
 					*dest = []*store.AdvertiseRecord{}
 				}).
 				Return(nil)
+			mockCountAds(mockDB)
 
 			// Create context
 			ctx := &Context{Db: mockDB}
@@ -502,6 +525,7 @@ func TestGetAdsByFiltersHandlerValidation(t *testing.T) {
 			}
 			req.URL.RawQuery = q.Encode()
 			c.Request = req
+			authenticateTestContext(c, store.AccountDefaultID)
 
 			// Call the handler
 			payload, statusCode, err := GetAdsByFiltersHandler(c, ctx)
@@ -520,3 +544,85 @@ func TestGetAdsByFiltersHandlerValidation(t *testing.T) {
 		})
 	}
 }
+
+// TestGetAdsByFiltersHandlerPagination walks every page against a real database
+func TestGetAdsByFiltersHandlerPagination(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		titles := []string{"delta", "alpha", "golf", "charlie", "echo", "bravo", "foxtrot"}
+		for idx, title := range titles {
+			srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+				Title:     title,
+				ImageURL:  "https://example.com/ad.jpg",
+				Placement: "homepage",
+				Ttl:       int64(idx % 3 * 10), // mix of ads with and without expiration
+			}, nil)
+		}
+
+		type page struct {
+			Ads        []*store.AdvertiseRecord `json:"ads"`
+			NextCursor *string                  `json:"next_cursor"`
+			Total      int64                    `json:"total"`
+		}
+
+		walk := func(t *testing.T, sort string) []*store.AdvertiseRecord {
+			var all []*store.AdvertiseRecord
+			path := "/v1/ads?limit=3&sort=" + sort
+			for pages := 0; ; pages++ {
+				require.Less(t, pages, 5, "pagination does not terminate")
+
+				var p page
+				status := srv.do(http.MethodGet, path, nil, &p)
+				require.Equal(t, http.StatusOK, status)
+				assert.Equal(t, int64(len(titles)), p.Total)
+				all = append(all, p.Ads...)
+
+				if p.NextCursor == nil {
+					return all
+				}
+				path = fmt.Sprintf("/v1/ads?limit=3&sort=%s&cursor=%s", sort, *p.NextCursor)
+			}
+		}
+
+		t.Run("title ascending", func(t *testing.T) {
+			ads := walk(t, "title:asc")
+			require.Len(t, ads, len(titles))
+			for idx, want := range []string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf"} {
+				assert.Equal(t, want, ads[idx].Title)
+			}
+		})
+
+		t.Run("expires_at descending keeps ads without expiration first", func(t *testing.T) {
+			ads := walk(t, "expires_at:desc")
+			require.Len(t, ads, len(titles))
+
+			seen := map[string]bool{}
+			for idx, ad := range ads {
+				assert.False(t, seen[ad.ID], "duplicated ad %s", ad.ID)
+				seen[ad.ID] = true
+				if idx > 0 && ads[idx-1].ExpiresAt != nil {
+					require.NotNil(t, ad.ExpiresAt)
+					assert.GreaterOrEqual(t, *ads[idx-1].ExpiresAt, *ad.ExpiresAt)
+				}
+			}
+			assert.Nil(t, ads[0].ExpiresAt)
+		})
+
+		t.Run("invalid parameters", func(t *testing.T) {
+			for _, path := range []string{
+				"/v1/ads?limit=0",
+				"/v1/ads?limit=abc",
+				"/v1/ads?sort=image_url",
+				"/v1/ads?sort=title:sideways",
+				"/v1/ads?cursor=not-a-cursor",
+			} {
+				assert.Equal(t, http.StatusBadRequest, srv.do(http.MethodGet, path, nil, nil), path)
+			}
+
+			var p page
+			srv.do(http.MethodGet, "/v1/ads?limit=1&sort=title", nil, &p)
+			require.NotNil(t, p.NextCursor)
+			status := srv.do(http.MethodGet, "/v1/ads?sort=created_at&cursor="+*p.NextCursor, nil, nil)
+			assert.Equal(t, http.StatusBadRequest, status)
+		})
+	})
+}
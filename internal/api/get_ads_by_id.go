@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
 	"github.com/mtavano/admoai-takehome/internal/store/query"
 	"github.com/pkg/errors"
 )
@@ -19,11 +20,12 @@ func GetAdsByIDHandler(c *gin.Context, ctx *Context) (any, int, error) {
 
 	// Create arguments for SelectAds
 	args := &query.SelectAdsArgs{
-		ID: id,
+		AccountID: middleware.CurrentAccountID(c),
+		ID:        id,
 	}
 
 	// Query the database
-	records, err := query.SelectAds(ctx.Db, args)
+	records, err := query.SelectAds(c.Request.Context(), ctx.Db, args)
 	if err != nil {
 		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: GetAdsByIDHandler query error")
 	}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/logging"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+// Formats of the ads import and export
+const (
+	AdsFormatCSV   = "csv"
+	AdsFormatJSONL = "jsonl"
+)
+
+// adsExportPageSize is the number of ads read per query while exporting
+const adsExportPageSize = maxAdsPageSize
+
+// adExportColumns are the CSV columns of an export, named after the fields of
+// a creation request so an export can be imported back. The import ignores
+// id and created_at, and restores status and expires_at.
+var adExportColumns = []string{
+	"id", "title", "image_url", "click_url", "campaign_id", "placement", "status",
+	"weight", "width", "height", "created_at", "starts_at", "expires_at",
+}
+
+// adExportRow is one exported ad, a JSONL line
+type adExportRow struct {
+	ID         string  `json:"id"`
+	Title      string  `json:"title"`
+	ImageURL   string  `json:"image_url"`
+	ClickURL   *string `json:"click_url"`
+	CampaignID *string `json:"campaign_id"`
+	Placement  string  `json:"placement"`
+	Status     string  `json:"status"`
+	Weight     int64   `json:"weight"`
+	Width      *int64  `json:"width"`
+	Height     *int64  `json:"height"`
+	CreatedAt  int64   `json:"created_at"`
+	StartsAt   *int64  `json:"starts_at"`
+	ExpiresAt  *int64  `json:"expires_at"`
+}
+
+func newAdExportRow(rec *store.AdvertiseRecord) *adExportRow {
+	return &adExportRow{
+		ID:         rec.ID,
+		Title:      rec.Title,
+		ImageURL:   rec.ImageURL,
+		ClickURL:   rec.ClickURL,
+		CampaignID: rec.CampaignID,
+		Placement:  rec.Placement,
+		Status:     rec.Status,
+		Weight:     rec.Weight,
+		Width:      rec.Width,
+		Height:     rec.Height,
+		CreatedAt:  rec.CreatedAt,
+		StartsAt:   rec.StartsAt,
+		ExpiresAt:  rec.ExpiresAt,
+	}
+}
+
+// csvRecord returns the row in the order of adExportColumns, its cells
+// escaped with csvEscapeFormula
+func (row *adExportRow) csvRecord() []string {
+	record := []string{
+		row.ID, row.Title, row.ImageURL, csvString(row.ClickURL), csvString(row.CampaignID),
+		row.Placement, row.Status, strconv.FormatInt(row.Weight, 10), csvInt(row.Width),
+		csvInt(row.Height), strconv.FormatInt(row.CreatedAt, 10), csvInt(row.StartsAt), csvInt(row.ExpiresAt),
+	}
+	for i, cell := range record {
+		record[i] = csvEscapeFormula(cell)
+	}
+	return record
+}
+
+// GetAdsExportHandler streams every ad matching the filters of
+// GetAdsByFiltersHandler as CSV or JSON Lines. The ads are read page by page
+// with the keyset pagination of the listing, so the file is not a snapshot
+// of a single instant.
+func GetAdsExportHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	format := c.DefaultQuery("format", AdsFormatCSV)
+	var contentType string
+	switch format {
+	case AdsFormatCSV:
+		contentType = "text/csv; charset=utf-8"
+	case AdsFormatJSONL:
+		contentType = "application/x-ndjson"
+	default:
+		return map[string]any{
+			"error":   "Invalid format",
+			"details": "format must be one of csv or jsonl",
+		}, http.StatusBadRequest, nil
+	}
+
+	args, payload := adsFilterArgs(c)
+	if payload != nil {
+		return payload, http.StatusBadRequest, nil
+	}
+	args.Limit = adsExportPageSize
+
+	// Read the first page before writing so its errors get a proper response
+	records, err := query.SelectAds(c.Request.Context(), ctx.Db, args)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: GetAdsExportHandler query error")
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="ads.`+format+`"`)
+	c.Writer.WriteHeaderNow()
+
+	csvWriter := csv.NewWriter(c.Writer)
+	encoder := json.NewEncoder(c.Writer)
+	if format == AdsFormatCSV {
+		if err := csvWriter.Write(adExportColumns); err != nil {
+			logging.FromContext(c.Request.Context()).Warn("ads export interrupted", slog.String("error", err.Error()))
+			return nil, http.StatusOK, nil
+		}
+	}
+
+	for {
+		for _, rec := range records {
+			row := newAdExportRow(rec)
+			if format == AdsFormatCSV {
+				err = csvWriter.Write(row.csvRecord())
+			} else {
+				err = encoder.Encode(row)
+			}
+			if err != nil {
+				// The client went away, the status is already sent
+				logging.FromContext(c.Request.Context()).Warn("ads export interrupted", slog.String("error", err.Error()))
+				return nil, http.StatusOK, nil
+			}
+		}
+		// The CSV writer is buffered, its write errors show on flush
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			logging.FromContext(c.Request.Context()).Warn("ads export interrupted", slog.String("error", err.Error()))
+			return nil, http.StatusOK, nil
+		}
+		c.Writer.Flush()
+
+		if uint64(len(records)) < args.Limit {
+			return nil, http.StatusOK, nil
+		}
+
+		args.After = query.NewCursor(*args.Order, records[len(records)-1])
+		records, err = query.SelectAds(c.Request.Context(), ctx.Db, args)
+		if err != nil {
+			// Too late to respond an error, the truncated file is reported
+			// through the status recorded in the metrics
+			logging.FromContext(c.Request.Context()).Error("ads export failed", slog.String("error", err.Error()))
+			return nil, http.StatusInternalServerError, nil
+		}
+	}
+}
+
+// csvFormulaPrefixes start the cells that spreadsheets evaluate as formulas
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvEscapeFormula prefixes with a quote the cells a spreadsheet would run as
+// a formula, and the cells csvUnescapeFormula would strip, so an export opens
+// safely and imports back unchanged
+func csvEscapeFormula(cell string) string {
+	if csvFormula(cell) || csvFormulaEscaped(cell) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// csvUnescapeFormula reverts csvEscapeFormula on an imported cell
+func csvUnescapeFormula(cell string) string {
+	if csvFormulaEscaped(cell) {
+		return cell[1:]
+	}
+	return cell
+}
+
+func csvFormula(cell string) bool {
+	return cell != "" && strings.ContainsRune(csvFormulaPrefixes, rune(cell[0]))
+}
+
+// csvFormulaEscaped reports whether a cell is a quote before an escaped cell
+func csvFormulaEscaped(cell string) bool {
+	rest, ok := strings.CutPrefix(cell, "'")
+	return ok && (csvFormula(rest) || csvFormulaEscaped(rest))
+}
+
+// csvString returns the value of an optional text column
+func csvString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// csvInt returns the value of an optional integer column
+func csvInt(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+// GetAdsHistoryHandler returns the audit log of an ad, oldest change first
+func GetAdsHistoryHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	// Get ID from path parameters
+	id := c.Param("id")
+	if id == "" {
+		return map[string]any{
+			"error": "ID parameter is required",
+		}, http.StatusBadRequest, nil
+	}
+
+	accountID := middleware.CurrentAccountID(c)
+
+	// Make sure the ad exists in the account
+	ads, err := query.SelectAds(c.Request.Context(), ctx.Db, &query.SelectAdsArgs{AccountID: accountID, ID: id})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: GetAdsHistoryHandler query error")
+	}
+	if len(ads) == 0 {
+		return map[string]any{
+			"error": "Ad not found",
+		}, http.StatusNotFound, nil
+	}
+
+	records, err := query.SelectAdAuditLog(c.Request.Context(), ctx.Db, &query.SelectAdAuditLogArgs{
+		AccountID: accountID,
+		AdID:      id,
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: GetAdsHistoryHandler audit log error")
+	}
+
+	return map[string]any{
+		"adId":    id,
+		"history": records,
+	}, http.StatusOK, nil
+}
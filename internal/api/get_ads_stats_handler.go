@@ -0,0 +1,161 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+// statsBucket is a supported time bucket width and the range reported by
+// default with it
+type statsBucket struct {
+	width         time.Duration
+	defaultWindow time.Duration
+}
+
+var statsBuckets = map[string]statsBucket{
+	"minute": {width: time.Minute, defaultWindow: time.Hour},
+	"hour":   {width: time.Hour, defaultWindow: 24 * time.Hour},
+	"day":    {width: 24 * time.Hour, defaultWindow: 30 * 24 * time.Hour},
+}
+
+// maxStatsBuckets bounds the size of a single stats response
+const maxStatsBuckets = 1000
+
+// AdStatsBucket are the counters of one time bucket
+type AdStatsBucket struct {
+	Start       int64   `json:"start"`
+	Impressions int64   `json:"impressions"`
+	Clicks      int64   `json:"clicks"`
+	CTR         float64 `json:"ctr"`
+}
+
+// AdStatsResponse is the performance report of an ad
+type AdStatsResponse struct {
+	AdID    string          `json:"ad_id"`
+	From    int64           `json:"from"`
+	To      int64           `json:"to"`
+	Bucket  string          `json:"bucket"`
+	Totals  AdStatsBucket   `json:"totals"`
+	Buckets []AdStatsBucket `json:"buckets"`
+}
+
+func GetAdsStatsHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	// Get ID from path parameters
+	id := c.Param("id")
+	if id == "" {
+		return gin.H{
+			"error": "ID parameter is required",
+		}, http.StatusBadRequest, nil
+	}
+
+	// Get report parameters, defaults to the last 24 hours by hour
+	bucketName := c.DefaultQuery("bucket", "hour")
+	bucket, ok := statsBuckets[bucketName]
+	if !ok {
+		return gin.H{
+			"error":   "Invalid bucket",
+			"details": "bucket must be one of minute, hour or day",
+		}, http.StatusBadRequest, nil
+	}
+
+	// The range is [from, to), by default it ends with the current bucket
+	bucketSeconds := int64(bucket.width.Seconds())
+	currentBucketEnd := (time.Now().Unix()/bucketSeconds + 1) * bucketSeconds
+	to, err := parseUnixParam(c, "to", currentBucketEnd)
+	if err != nil {
+		return gin.H{"error": "Invalid to", "details": err.Error()}, http.StatusBadRequest, nil
+	}
+	from, err := parseUnixParam(c, "from", to-int64(bucket.defaultWindow.Seconds()))
+	if err != nil {
+		return gin.H{"error": "Invalid from", "details": err.Error()}, http.StatusBadRequest, nil
+	}
+	if from >= to {
+		return gin.H{
+			"error":   "Invalid range",
+			"details": "from must be before to",
+		}, http.StatusBadRequest, nil
+	}
+
+	if (to-from)/bucketSeconds > maxStatsBuckets {
+		return gin.H{
+			"error":   "Invalid range",
+			"details": "range spans more than " + strconv.Itoa(maxStatsBuckets) + " buckets",
+		}, http.StatusBadRequest, nil
+	}
+
+	accountID := middleware.CurrentAccountID(c)
+
+	// Check the ad exists within the account
+	records, err := query.SelectAds(c.Request.Context(), ctx.Db, &query.SelectAdsArgs{AccountID: accountID, ID: id})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: GetAdsStatsHandler query error")
+	}
+	if len(records) == 0 {
+		return gin.H{
+			"error": "Ad not found",
+		}, http.StatusNotFound, nil
+	}
+
+	buckets, err := query.SelectAdEventStats(c.Request.Context(), ctx.Db, &query.SelectAdEventStatsArgs{
+		AccountID:     accountID,
+		AdID:          id,
+		From:          from,
+		To:            to,
+		BucketSeconds: bucketSeconds,
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: GetAdsStatsHandler stats error")
+	}
+
+	res := AdStatsResponse{
+		AdID:    id,
+		From:    from,
+		To:      to,
+		Bucket:  bucketName,
+		Buckets: make([]AdStatsBucket, 0, len(buckets)),
+	}
+	for _, b := range buckets {
+		res.Buckets = append(res.Buckets, newAdStatsBucket(b))
+		res.Totals.Impressions += b.Impressions
+		res.Totals.Clicks += b.Clicks
+	}
+	res.Totals = newAdStatsBucket(&store.AdEventBucket{
+		Start:       from,
+		Impressions: res.Totals.Impressions,
+		Clicks:      res.Totals.Clicks,
+	})
+
+	return res, http.StatusOK, nil
+}
+
+func newAdStatsBucket(b *store.AdEventBucket) AdStatsBucket {
+	out := AdStatsBucket{
+		Start:       b.Start,
+		Impressions: b.Impressions,
+		Clicks:      b.Clicks,
+	}
+	if b.Impressions > 0 {
+		out.CTR = float64(b.Clicks) / float64(b.Impressions)
+	}
+	return out
+}
+
+// parseUnixParam reads an optional unix timestamp query parameter
+func parseUnixParam(c *gin.Context, name string, fallback int64) (int64, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || value < 0 {
+		return 0, errors.Errorf("%s must be a unix timestamp", name)
+	}
+	return value, nil
+}
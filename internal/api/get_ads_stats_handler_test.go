@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackingAndStats(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		var withLanding, withoutLanding store.AdvertiseRecord
+		srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title:     "Landing",
+			ImageURL:  "https://example.com/landing.jpg",
+			ClickURL:  "https://example.com/shop",
+			Placement: "homepage",
+		}, &withLanding)
+		srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title:     "No landing",
+			ImageURL:  "https://example.com/plain.jpg",
+			Placement: "homepage",
+		}, &withoutLanding)
+		require.NoError(t, srv.ctx.Serving.Refresh(context.Background()))
+
+		for i := 0; i < 4; i++ {
+			status := srv.do(http.MethodGet, "/v1/track/impression?ad_id="+withLanding.ID, nil, nil)
+			require.Equal(t, http.StatusNoContent, status)
+		}
+		status := srv.do(http.MethodPost, "/v1/track/impression?ad_id="+withoutLanding.ID, nil, nil)
+		require.Equal(t, http.StatusNoContent, status)
+
+		// Clicks redirect to the landing page when the ad has one
+		req := httptest.NewRequest(http.MethodGet, "/v1/track/click?ad_id="+withLanding.ID, nil)
+		w := httptest.NewRecorder()
+		srv.engine.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, "https://example.com/shop", w.Header().Get("Location"))
+
+		status = srv.do(http.MethodGet, "/v1/track/click?ad_id="+withoutLanding.ID, nil, nil)
+		assert.Equal(t, http.StatusNoContent, status)
+
+		assert.Equal(t, http.StatusNotFound, srv.do(http.MethodGet, "/v1/track/impression?ad_id=missing", nil, nil))
+		assert.Equal(t, http.StatusBadRequest, srv.do(http.MethodGet, "/v1/track/click", nil, nil))
+
+		srv.flushTracking()
+
+		var stats AdStatsResponse
+		status = srv.do(http.MethodGet, "/v1/ads/"+withLanding.ID+"/stats?bucket=minute", nil, &stats)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "minute", stats.Bucket)
+		assert.Equal(t, int64(4), stats.Totals.Impressions)
+		assert.Equal(t, int64(1), stats.Totals.Clicks)
+		assert.InDelta(t, 0.25, stats.Totals.CTR, 1e-9)
+		require.NotEmpty(t, stats.Buckets)
+		for _, b := range stats.Buckets {
+			assert.Zero(t, b.Start%60)
+			assert.LessOrEqual(t, b.Start, time.Now().Unix())
+		}
+
+		status = srv.do(http.MethodGet, "/v1/ads/"+withoutLanding.ID+"/stats", nil, &stats)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, int64(1), stats.Totals.Impressions)
+		assert.Equal(t, int64(1), stats.Totals.Clicks)
+		assert.Equal(t, "hour", stats.Bucket)
+
+		for _, path := range []string{
+			"/v1/ads/" + withLanding.ID + "/stats?bucket=week",
+			"/v1/ads/" + withLanding.ID + "/stats?from=200&to=100",
+			"/v1/ads/" + withLanding.ID + "/stats?bucket=minute&from=0",
+		} {
+			assert.Equal(t, http.StatusBadRequest, srv.do(http.MethodGet, path, nil, nil), path)
+		}
+		assert.Equal(t, http.StatusNotFound, srv.do(http.MethodGet, "/v1/ads/missing/stats", nil, nil))
+	})
+}
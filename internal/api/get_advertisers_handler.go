@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+func GetAdvertisersHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	records, err := query.SelectAdvertisers(c.Request.Context(), ctx.Db, &query.SelectAdvertisersArgs{
+		AccountID: middleware.CurrentAccountID(c),
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: GetAdvertisersHandler query error")
+	}
+
+	return map[string]any{
+		"advertisers": records,
+	}, http.StatusOK, nil
+}
+
+func GetAdvertisersByIDHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	id := c.Param("id")
+	if id == "" {
+		return map[string]any{
+			"error": "ID parameter is required",
+		}, http.StatusBadRequest, nil
+	}
+
+	records, err := query.SelectAdvertisers(c.Request.Context(), ctx.Db, &query.SelectAdvertisersArgs{
+		AccountID: middleware.CurrentAccountID(c),
+		ID:        id,
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: GetAdvertisersByIDHandler query error")
+	}
+	if len(records) == 0 {
+		return map[string]any{
+			"error": "Advertiser not found",
+		}, http.StatusNotFound, nil
+	}
+
+	return records[0], http.StatusOK, nil
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+func GetAPIKeysHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	// Revoked keys are listed unless only the active ones are requested
+	args := &query.SelectAPIKeysArgs{
+		AccountID:  middleware.CurrentAccountID(c),
+		ActiveOnly: c.Query("active") == "true",
+	}
+
+	records, err := query.SelectAPIKeys(c.Request.Context(), ctx.Db, args)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: GetAPIKeysHandler query error")
+	}
+
+	return map[string]any{
+		"api_keys": records,
+	}, http.StatusOK, nil
+}
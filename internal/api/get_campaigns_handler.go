@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+func GetCampaignsHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	records, err := query.SelectCampaigns(c.Request.Context(), ctx.Db, &query.SelectCampaignsArgs{
+		AccountID:    middleware.CurrentAccountID(c),
+		AdvertiserID: c.Query("advertiser_id"),
+		Status:       c.Query("status"),
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: GetCampaignsHandler query error")
+	}
+
+	return map[string]any{
+		"campaigns": records,
+	}, http.StatusOK, nil
+}
+
+func GetCampaignsByIDHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	id := c.Param("id")
+	if id == "" {
+		return map[string]any{
+			"error": "ID parameter is required",
+		}, http.StatusBadRequest, nil
+	}
+
+	records, err := query.SelectCampaigns(c.Request.Context(), ctx.Db, &query.SelectCampaignsArgs{
+		AccountID: middleware.CurrentAccountID(c),
+		ID:        id,
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: GetCampaignsByIDHandler query error")
+	}
+	if len(records) == 0 {
+		return map[string]any{
+			"error": "Campaign not found",
+		}, http.StatusNotFound, nil
+	}
+
+	return records[0], http.StatusOK, nil
+}
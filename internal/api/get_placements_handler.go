@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+func GetPlacementsHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	records, err := query.SelectPlacements(c.Request.Context(), ctx.Db, &query.SelectPlacementsArgs{})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: GetPlacementsHandler query error")
+	}
+
+	return map[string]any{
+		"placements": records,
+	}, http.StatusOK, nil
+}
+
+func GetPlacementsByNameHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	name := c.Param("name")
+	if name == "" {
+		return map[string]any{
+			"error": "Name parameter is required",
+		}, http.StatusBadRequest, nil
+	}
+
+	records, err := query.SelectPlacements(c.Request.Context(), ctx.Db, &query.SelectPlacementsArgs{Name: name})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: GetPlacementsByNameHandler query error")
+	}
+	if len(records) == 0 {
+		return map[string]any{
+			"error": "Placement not found",
+		}, http.StatusNotFound, nil
+	}
+
+	return records[0], http.StatusOK, nil
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/logging"
+	"github.com/mtavano/admoai-takehome/internal/metrics"
+)
+
+// ServeAdResponse is the compact creative returned to front-ends
+type ServeAdResponse struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	ImageURL  string `json:"image_url"`
+	Placement string `json:"placement"`
+}
+
+func GetServeAdHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	// Get placement from path parameters
+	placement := c.Param("placement")
+	if placement == "" {
+		return gin.H{
+			"error": "Placement parameter is required",
+		}, http.StatusBadRequest, nil
+	}
+
+	if ctx.Serving == nil {
+		return gin.H{
+			"error": "Ad serving is not available",
+		}, http.StatusServiceUnavailable, nil
+	}
+
+	// Pick an ad from the in-memory index
+	rec := ctx.Serving.Pick(placement)
+
+	// Record the decision. The route is public, so placements that are
+	// not known share one label instead of adding a series each.
+	collector := metrics.GetCollector()
+	if collector != nil {
+		label := placement
+		if !ctx.Serving.Known(placement) {
+			label = metrics.UnknownPlacement
+		}
+		collector.RecordAdServed(label, rec != nil)
+	}
+
+	logger := logging.FromContext(c.Request.Context())
+	if rec == nil {
+		logger.Info("ad decision", slog.String("placement", placement), slog.Bool("filled", false))
+		return nil, http.StatusNoContent, nil
+	}
+	logger.Info("ad decision", slog.String("placement", placement), slog.Bool("filled", true), slog.String("ad_id", rec.ID))
+
+	return ServeAdResponse{
+		ID:        rec.ID,
+		Title:     rec.Title,
+		ImageURL:  rec.ImageURL,
+		Placement: rec.Placement,
+	}, http.StatusOK, nil
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/mtavano/admoai-takehome/internal/metrics"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetServeAdHandler(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		var live, inactive store.AdvertiseRecord
+		srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title:     "Live",
+			ImageURL:  "https://example.com/live.jpg",
+			Placement: "homepage",
+		}, &live)
+		srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title:      "Scheduled",
+			ImageURL:   "https://example.com/scheduled.jpg",
+			Placement:  "homepage",
+			StartDelay: 60,
+		}, nil)
+		srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title:     "Inactive",
+			ImageURL:  "https://example.com/inactive.jpg",
+			Placement: "sidebar",
+		}, &inactive)
+		srv.do(http.MethodPost, "/v1/ads/"+inactive.ID+"/deactivate", nil, nil)
+
+		// Serving reads the in-memory index only
+		status := srv.do(http.MethodGet, "/v1/serve/homepage", nil, nil)
+		assert.Equal(t, http.StatusNoContent, status)
+
+		require.NoError(t, srv.ctx.Serving.Refresh(context.Background()))
+
+		for i := 0; i < 5; i++ {
+			var served ServeAdResponse
+			status = srv.do(http.MethodGet, "/v1/serve/homepage", nil, &served)
+			require.Equal(t, http.StatusOK, status)
+			assert.Equal(t, ServeAdResponse{
+				ID:        live.ID,
+				Title:     "Live",
+				ImageURL:  "https://example.com/live.jpg",
+				Placement: "homepage",
+			}, served)
+		}
+
+		status = srv.do(http.MethodGet, "/v1/serve/sidebar", nil, nil)
+		assert.Equal(t, http.StatusNoContent, status)
+	})
+}
+
+func TestServeAdMetricsUnknownPlacements(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		require.NoError(t, srv.ctx.Serving.Refresh(context.Background()))
+
+		before := servedSeries(t)
+		for i := 0; i < 50; i++ {
+			status := srv.do(http.MethodGet, fmt.Sprintf("/v1/serve/random-%d", i), nil, nil)
+			require.Equal(t, http.StatusNoContent, status)
+		}
+		// Registered placements keep their own label
+		srv.do(http.MethodGet, "/v1/serve/sidebar", nil, nil)
+
+		after := servedSeries(t)
+		assert.Contains(t, after, metrics.UnknownPlacement+"/empty")
+		assert.Contains(t, after, "sidebar/empty")
+		for series := range after {
+			assert.NotContains(t, series, "random-")
+		}
+		assert.LessOrEqual(t, len(after)-len(before), 2)
+	})
+}
+
+// servedSeries returns the placement/outcome series of the ad decisions
+func servedSeries(t *testing.T) map[string]bool {
+	t.Helper()
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	series := map[string]bool{}
+	for _, family := range families {
+		if family.GetName() != "admoai_ad_serves_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			series[labels["placement"]+"/"+labels["outcome"]] = true
+		}
+	}
+	return series
+}
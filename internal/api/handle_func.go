@@ -1,28 +1,40 @@
 package api
 
 import (
-	"log"
+	"log/slog"
+	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
+	"github.com/mtavano/admoai-takehome/internal/logging"
 	"github.com/mtavano/admoai-takehome/internal/metrics"
+	"github.com/mtavano/admoai-takehome/internal/store"
 )
 
 type handler func(*gin.Context, *Context) (any, int, error)
 
 func HandleFunc(fn handler, ctx *Context) func(*gin.Context) {
+	// Requests not matching the OpenAPI document never reach the handler
+	fn = ValidateRequest(fn)
+
 	return func(c *gin.Context) {
 		start := time.Now()
-		requestID := uuid.NewString()
-		
-		log.Printf("request received %s", requestID)
-		
+
+		span := startServerSpan(c)
+		defer endServerSpan(c, span)
+
 		payload, statusCode, err := fn(c, ctx)
-		
+
+		// Statements stopped by their deadline or by the client going away
+		// are reported as such instead of as internal errors
+		message := ""
+		if err != nil {
+			statusCode, message = errorStatus(err, statusCode)
+		}
+
 		elapsed := time.Since(start)
-		
+
 		// Record metrics using Prometheus collector
 		collector := metrics.GetCollector()
 		if collector != nil {
@@ -34,19 +46,34 @@ func HandleFunc(fn handler, ctx *Context) func(*gin.Context) {
 			collector.RecordHTTPRequest(c.Request.Method, endpoint, status, elapsed)
 		}
 
+		// The request itself is logged by the access log middleware
 		if err != nil {
-			log.Printf("request error %s %v", requestID, elapsed)
+			span.RecordError(err)
+			logging.FromContext(c.Request.Context()).Error("request failed",
+				slog.Int("status", statusCode),
+				slog.String("error", err.Error()),
+			)
 			c.JSON(statusCode, map[string]any{
-				"error": err.Error(),
+				"error": message,
 			})
 			return
 		}
 
-		log.Printf("request responded %s %v", requestID, elapsed)
-		
 		// Don't send response if it's already been sent (like in metrics handler)
 		if !c.Writer.Written() {
 			c.JSON(statusCode, payload)
 		}
 	}
 }
+
+// errorStatus returns the status and message responded for a handler error
+func errorStatus(err error, statusCode int) (int, string) {
+	switch {
+	case store.IsTimeout(err):
+		return http.StatusGatewayTimeout, "Database query timed out"
+	case store.IsCanceled(err):
+		return http.StatusServiceUnavailable, "Request cancelled before completion"
+	default:
+		return statusCode, err.Error()
+	}
+}
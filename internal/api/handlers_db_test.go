@@ -0,0 +1,200 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/metrics"
+	"github.com/mtavano/admoai-takehome/internal/serving"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/mtavano/admoai-takehome/internal/store/storetest"
+	"github.com/mtavano/admoai-takehome/internal/tracking"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// The collector registers on the default registry, so it is set up once
+	// for every test
+	metrics.Init()
+	os.Exit(storetest.Main(m))
+}
+
+// dbTestServer wires the real routes on top of a migrated database
+type dbTestServer struct {
+	t      *testing.T
+	ctx    *Context
+	engine *gin.Engine
+	// apiKey is sent on every request, an admin key unless replaced
+	apiKey string
+}
+
+func newDBTestServer(t *testing.T, driver string) *dbTestServer {
+	gin.SetMode(gin.TestMode)
+
+	db := storetest.Open(t, driver)
+	ctx := &Context{
+		Db:       db,
+		Serving:  serving.NewIndex(db, 0),
+		Tracking: tracking.NewRecorder(db, tracking.Options{}),
+	}
+	engine := gin.New()
+	RegisterRoutes(ctx, engine)
+
+	// Placements used across the tests, without constraints
+	for _, name := range []string{"homepage", "sidebar"} {
+		require.NoError(t, query.InsertPlacements(context.Background(), db, &store.PlacementRecord{
+			Name:      name,
+			CreatedAt: time.Now().Unix(),
+		}))
+	}
+
+	srv := &dbTestServer{t: t, ctx: ctx, engine: engine}
+	srv.apiKey = srv.insertAPIKey("test-admin", store.AccountDefaultID, store.APIKeyRoleAdmin)
+
+	return srv
+}
+
+// insertAPIKey stores a key of the account and returns its plain value
+func (s *dbTestServer) insertAPIKey(id, accountID, role string) string {
+	s.t.Helper()
+
+	plain, hash, prefix, err := middleware.GenerateAPIKey()
+	require.NoError(s.t, err)
+	require.NoError(s.t, query.InsertAPIKeys(context.Background(), s.ctx.Db, &store.APIKeyRecord{
+		ID:        id,
+		AccountID: accountID,
+		Name:      id,
+		KeyHash:   hash,
+		KeyPrefix: prefix,
+		Role:      role,
+		CreatedAt: time.Now().Unix(),
+	}))
+
+	return plain
+}
+
+// authenticateTestContext marks a handler test context as authenticated by
+// an admin key of the account
+func authenticateTestContext(c *gin.Context, accountID string) {
+	c.Set(middleware.APIKeyContextKey, &store.APIKeyRecord{
+		ID:        "test-" + accountID,
+		AccountID: accountID,
+		Role:      store.APIKeyRoleAdmin,
+	})
+}
+
+// do performs a request and decodes the JSON response into out when given
+func (s *dbTestServer) do(method, path string, body any, out any) int {
+	s.t.Helper()
+
+	status, _ := s.doWithHeaders(method, path, nil, body, out)
+	return status
+}
+
+// doWithHeaders performs a request with extra headers and returns the status
+// and headers of the response. A []byte body is sent as is and a *[]byte out
+// receives the raw response.
+func (s *dbTestServer) doWithHeaders(method, path string, headers map[string]string, body any, out any) (int, http.Header) {
+	s.t.Helper()
+
+	var reader *bytes.Reader
+	if raw, ok := body.([]byte); ok {
+		reader = bytes.NewReader(raw)
+	} else if body != nil {
+		raw, err := json.Marshal(body)
+		require.NoError(s.t, err)
+		reader = bytes.NewReader(raw)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+
+	if raw, ok := out.(*[]byte); ok {
+		*raw = w.Body.Bytes()
+	} else if out != nil {
+		require.NoError(s.t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+	}
+	return w.Code, w.Header()
+}
+
+// flushTracking writes every queued tracking event
+func (s *dbTestServer) flushTracking() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.ctx.Tracking.Run(ctx)
+}
+
+// forEachBackend runs fn once per database engine under test
+func forEachBackend(t *testing.T, fn func(t *testing.T, srv *dbTestServer)) {
+	for _, driver := range storetest.Backends() {
+		t.Run(driver, func(t *testing.T) {
+			fn(t, newDBTestServer(t, driver))
+		})
+	}
+}
+
+func TestAdsLifecycleAgainstDatabase(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		var created store.AdvertiseRecord
+		status := srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title:     "Summer sale",
+			ImageURL:  "https://example.com/summer.jpg",
+			Placement: "homepage",
+			Ttl:       30,
+		}, &created)
+		require.Equal(t, http.StatusCreated, status)
+		require.NotEmpty(t, created.ID)
+		require.NotNil(t, created.ExpiresAt)
+
+		srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title:     "Sidebar banner",
+			ImageURL:  "https://example.com/sidebar.jpg",
+			Placement: "sidebar",
+		}, nil)
+
+		var fetched store.AdvertiseRecord
+		status = srv.do(http.MethodGet, "/v1/ads/"+created.ID, nil, &fetched)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, created.Title, fetched.Title)
+		assert.Equal(t, *created.ExpiresAt, *fetched.ExpiresAt)
+
+		var filtered struct {
+			Ads []*store.AdvertiseRecord `json:"ads"`
+		}
+		status = srv.do(http.MethodGet, "/v1/ads?placement=homepage", nil, &filtered)
+		assert.Equal(t, http.StatusOK, status)
+		require.Len(t, filtered.Ads, 1)
+		assert.Equal(t, created.ID, filtered.Ads[0].ID)
+
+		status = srv.do(http.MethodPost, "/v1/ads/"+created.ID+"/deactivate", nil, nil)
+		assert.Equal(t, http.StatusOK, status)
+
+		status = srv.do(http.MethodGet, "/v1/ads?status=inactive", nil, &filtered)
+		assert.Equal(t, http.StatusOK, status)
+		require.Len(t, filtered.Ads, 1)
+		assert.Equal(t, store.AdvertiseStatusInactive, filtered.Ads[0].Status)
+
+		status = srv.do(http.MethodGet, "/v1/ads/does-not-exist", nil, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+}
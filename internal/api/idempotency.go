@@ -0,0 +1,132 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/idempotency"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+// IdempotencyKeyHeader carries the key a client reuses when retrying
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds the keys chosen by the clients
+const maxIdempotencyKeyLength = 255
+
+// Idempotent replays the stored response of a request retried with the same
+// Idempotency-Key header instead of running the handler again. Successful
+// responses are stored in the transaction of the handler, so it must run
+// inside Transactional. Requests without the header run as usual.
+func Idempotent(fn handler) handler {
+	return func(c *gin.Context, ctx *Context) (any, int, error) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			return fn(c, ctx)
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return map[string]any{
+				"error":   "Validation failed",
+				"details": "Idempotency-Key must be at most 255 characters",
+			}, http.StatusBadRequest, nil
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return map[string]any{
+				"error":   "Validation failed",
+				"details": "Unable to read request body",
+			}, http.StatusBadRequest, nil
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		accountID := middleware.CurrentAccountID(c)
+		requestHash := hashRequest(c.Request.Method, c.Request.URL.RequestURI(), body)
+		now := time.Now()
+
+		records, err := query.SelectIdempotencyKeys(c.Request.Context(), ctx.Db, &query.SelectIdempotencyKeysArgs{
+			AccountID: accountID,
+			Key:       key,
+			Now:       now.Unix(),
+		})
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.Wrap(err, "api: Idempotent query error")
+		}
+		if len(records) > 0 {
+			if records[0].RequestHash != requestHash {
+				return map[string]any{
+					"error":   "Idempotency key reused",
+					"details": "Idempotency-Key was already used with a different request",
+				}, http.StatusUnprocessableEntity, nil
+			}
+
+			c.Header("Idempotent-Replayed", "true")
+			return json.RawMessage(records[0].Response), records[0].StatusCode, nil
+		}
+
+		// Forget the expired use of the key, if any, before storing this one
+		_, err = query.DeleteIdempotencyKeys(c.Request.Context(), ctx.Db, &query.DeleteIdempotencyKeysArgs{
+			AccountID: accountID,
+			Key:       key,
+			ExpiredAt: now.Unix(),
+		})
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.Wrap(err, "api: Idempotent delete error")
+		}
+
+		payload, status, err := fn(c, ctx)
+		if err != nil || status >= http.StatusBadRequest {
+			// Failed requests are rolled back and can be retried with the key
+			return payload, status, err
+		}
+
+		response, err := json.Marshal(payload)
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.Wrap(err, "api: Idempotent marshal error")
+		}
+
+		ttl := ctx.IdempotencyTTL
+		if ttl <= 0 {
+			ttl = idempotency.DefaultTTL
+		}
+
+		err = query.InsertIdempotencyKeys(c.Request.Context(), ctx.Db, &store.IdempotencyKeyRecord{
+			AccountID:   accountID,
+			Key:         key,
+			RequestHash: requestHash,
+			StatusCode:  status,
+			Response:    response,
+			CreatedAt:   now.Unix(),
+			ExpiresAt:   now.Add(ttl).Unix(),
+		})
+		if store.IsUniqueViolation(err) {
+			// A concurrent request with the same key committed first
+			return map[string]any{
+				"error":   "Idempotency key in use",
+				"details": "A request with the same Idempotency-Key is being processed, retry later",
+			}, http.StatusConflict, nil
+		}
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.Wrap(err, "api: Idempotent insert error")
+		}
+
+		return payload, status, nil
+	}
+}
+
+// hashRequest identifies a request by its URI and body
+func hashRequest(method, uri string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + uri + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostAdsIdempotency(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		newAd := func(title string) PostAdsHandlerRequest {
+			return PostAdsHandlerRequest{
+				Title:     title,
+				ImageURL:  "https://example.com/ad.jpg",
+				Placement: "homepage",
+			}
+		}
+		withKey := func(key string) map[string]string {
+			return map[string]string{IdempotencyKeyHeader: key}
+		}
+		countAds := func() int {
+			var page struct {
+				Ads []*store.AdvertiseRecord `json:"ads"`
+			}
+			require.Equal(t, http.StatusOK, srv.do(http.MethodGet, "/v1/ads", nil, &page))
+			return len(page.Ads)
+		}
+
+		var created store.AdvertiseRecord
+		status, headers := srv.doWithHeaders(http.MethodPost, "/v1/ads", withKey("create-1"), newAd("First"), &created)
+		require.Equal(t, http.StatusCreated, status)
+		assert.Empty(t, headers.Get("Idempotent-Replayed"))
+
+		// A retry replays the original response without creating another ad
+		var replayed store.AdvertiseRecord
+		status, headers = srv.doWithHeaders(http.MethodPost, "/v1/ads", withKey("create-1"), newAd("First"), &replayed)
+		require.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, "true", headers.Get("Idempotent-Replayed"))
+		assert.Equal(t, created.ID, replayed.ID)
+		assert.Equal(t, 1, countAds())
+
+		// The key cannot be reused for another request
+		status, _ = srv.doWithHeaders(http.MethodPost, "/v1/ads", withKey("create-1"), newAd("Other"), nil)
+		assert.Equal(t, http.StatusUnprocessableEntity, status)
+
+		// Failed requests are not remembered, the fixed request can use the key
+		invalid := newAd("Second")
+		invalid.Placement = "unknown"
+		status, _ = srv.doWithHeaders(http.MethodPost, "/v1/ads", withKey("create-2"), invalid, nil)
+		require.Equal(t, http.StatusBadRequest, status)
+		status, _ = srv.doWithHeaders(http.MethodPost, "/v1/ads", withKey("create-2"), newAd("Second"), nil)
+		require.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, 2, countAds())
+
+		// Keys are scoped by account
+		srv.apiKey = srv.insertAPIKey("other-editor", "other", store.APIKeyRoleEditor)
+		var other store.AdvertiseRecord
+		status, _ = srv.doWithHeaders(http.MethodPost, "/v1/ads", withKey("create-1"), newAd("First"), &other)
+		require.Equal(t, http.StatusCreated, status)
+		assert.NotEqual(t, created.ID, other.ID)
+
+		// Expired keys are forgotten
+		now := time.Now()
+		require.NoError(t, query.InsertIdempotencyKeys(context.Background(), srv.ctx.Db, &store.IdempotencyKeyRecord{
+			AccountID:   "other",
+			Key:         "expired",
+			RequestHash: "stale",
+			StatusCode:  http.StatusCreated,
+			Response:    []byte(`{}`),
+			CreatedAt:   now.Add(-48 * time.Hour).Unix(),
+			ExpiresAt:   now.Add(-24 * time.Hour).Unix(),
+		}))
+		status, headers = srv.doWithHeaders(http.MethodPost, "/v1/ads", withKey("expired"), newAd("Fresh"), nil)
+		require.Equal(t, http.StatusCreated, status)
+		assert.Empty(t, headers.Get("Idempotent-Replayed"))
+	})
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/logging"
+)
+
+// AccessLog writes one line per request and carries a logger tagged with
+// the request ID in the request context, see logging.FromContext. It runs
+// after RequestID.
+type AccessLog struct {
+	logger *slog.Logger
+}
+
+// NewAccessLog returns the middleware logging to logger, the default logger
+// when nil
+func NewAccessLog(logger *slog.Logger) *AccessLog {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &AccessLog{logger: logger}
+}
+
+func (mw *AccessLog) Setup(engine *gin.Engine) {
+	engine.Use(mw.handler())
+}
+
+func (mw *AccessLog) handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		logger := mw.logger.With(slog.String("request_id", c.GetString("request_id")))
+		c.Request = c.Request.WithContext(logging.WithLogger(c.Request.Context(), logger))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+
+		// The route is empty for requests not matching any
+		logger.LogAttrs(c.Request.Context(), level, "request",
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		)
+	}
+}
@@ -0,0 +1,300 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/logging"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+)
+
+// APIKeyContextKey is the gin context key holding the authenticated key
+const APIKeyContextKey = "api_key"
+
+// linkContextKey marks the requests authenticated by a link token
+const linkContextKey = "link_token"
+
+// apiKeyPrefix marks the keys issued by this service
+const apiKeyPrefix = "admoai_"
+
+// linkMaxRole caps the role of the requests authenticated by a link token, a
+// leaked link never grants more than the dashboard needs
+var linkMaxRole = store.APIKeyRoleEditor
+
+// roleRanks orders the roles, a key is granted every role up to its own
+var roleRanks = map[string]int{
+	store.APIKeyRoleReader: 1,
+	store.APIKeyRoleEditor: 2,
+	store.APIKeyRoleAdmin:  3,
+}
+
+// ValidRole reports whether role is one of the API key roles
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// Auth authenticates requests with hashed API keys stored in the database
+type Auth struct {
+	db    store.Transaction
+	links *LinkTokens
+}
+
+// NewAuth returns the authentication of the API keys and of the link tokens
+// signed by links
+func NewAuth(db store.Transaction, links *LinkTokens) *Auth {
+	return &Auth{db: db, links: links}
+}
+
+// Require returns a handler that aborts with 401 when the request carries no
+// valid API key and with 403 when the key role is below role. Link tokens are
+// rejected with 403, they only reach the routes of RequireLink and
+// RequireLinkPage.
+func (mw *Auth) Require(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if mw.require(c, role, extractAPIKey(c.Request), false) == nil {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireLink is Require for the routes called by the dashboard, it also
+// accepts a link token in the headers, with its role capped at editor
+func (mw *Auth) RequireLink(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if mw.require(c, role, extractAPIKey(c.Request), true) == nil {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireLinkPage is RequireLink for the pages opened from a browser link, it
+// also reads the link token from the token query parameter. API keys are
+// never read from the URL.
+func (mw *Auth) RequireLinkPage(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		credential := extractAPIKey(c.Request)
+		if token := c.Query(LinkTokenParam); token != "" {
+			if !isLinkToken(token) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]any{
+					"error": "Invalid or expired link",
+				})
+				return
+			}
+			credential = token
+		}
+		if mw.require(c, role, credential, true) == nil {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAccount is Require restricted to the keys of one account, it guards
+// the resources shared by every account that only the operator changes
+func (mw *Auth) RequireAccount(role, accountID string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := mw.require(c, role, extractAPIKey(c.Request), false)
+		if key == nil {
+			return
+		}
+		if key.AccountID != accountID {
+			c.AbortWithStatusJSON(http.StatusForbidden, map[string]any{
+				"error": "Only the keys of the operator account can access this resource",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// require authenticates the credential of the request and checks the role of
+// its key, it aborts and returns nil when the key is missing or not allowed.
+// Link tokens are only accepted when allowLink is set.
+func (mw *Auth) require(c *gin.Context, role, credential string, allowLink bool) *store.APIKeyRecord {
+	if !allowLink && isLinkToken(credential) {
+		abortLinkForbidden(c)
+		return nil
+	}
+
+	key, status, msg := mw.authenticate(c, credential)
+	if key == nil {
+		if status == http.StatusUnauthorized {
+			c.Header("WWW-Authenticate", `Bearer realm="admoai"`)
+		}
+		c.AbortWithStatusJSON(status, map[string]any{
+			"error": msg,
+		})
+		return nil
+	}
+
+	if roleRanks[key.Role] < roleRanks[role] {
+		c.AbortWithStatusJSON(http.StatusForbidden, map[string]any{
+			"error": "API key role " + key.Role + " cannot access this resource, " + role + " required",
+		})
+		return nil
+	}
+
+	// Expose the key to the handlers
+	c.Set(APIKeyContextKey, key)
+	return key
+}
+
+// Optional authenticates the request when it carries an API key and lets it
+// through anonymously otherwise. An invalid key is still rejected.
+func (mw *Auth) Optional() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		credential := extractAPIKey(c.Request)
+		if credential == "" {
+			c.Next()
+			return
+		}
+		if isLinkToken(credential) {
+			abortLinkForbidden(c)
+			return
+		}
+
+		key, status, msg := mw.authenticate(c, credential)
+		if key == nil {
+			c.AbortWithStatusJSON(status, map[string]any{
+				"error": msg,
+			})
+			return
+		}
+
+		c.Set(APIKeyContextKey, key)
+
+		c.Next()
+	}
+}
+
+// authenticate resolves the API key of a plain key or of a link token
+func (mw *Auth) authenticate(c *gin.Context, plain string) (*store.APIKeyRecord, int, string) {
+	if plain == "" {
+		return nil, http.StatusUnauthorized, "API key required"
+	}
+
+	// The key is looked up across tenants, it is what identifies the tenant
+	args := &query.SelectAPIKeysArgs{
+		AllAccounts: true,
+		KeyHash:     HashAPIKey(plain),
+		ActiveOnly:  true,
+	}
+	viaLink := isLinkToken(plain)
+	if viaLink {
+		keyID, ok := mw.links.Verify(plain, time.Now())
+		if !ok {
+			return nil, http.StatusUnauthorized, "Invalid or expired link"
+		}
+		args.ID, args.KeyHash = keyID, ""
+	}
+
+	records, err := query.SelectAPIKeys(c.Request.Context(), mw.db, args)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("api key lookup failed", slog.String("error", err.Error()))
+		switch {
+		case store.IsTimeout(err):
+			return nil, http.StatusGatewayTimeout, "Timed out verifying API key"
+		case store.IsCanceled(err):
+			return nil, http.StatusServiceUnavailable, "Request cancelled before completion"
+		}
+		return nil, http.StatusInternalServerError, "Unable to verify API key"
+	}
+	if len(records) == 0 {
+		return nil, http.StatusUnauthorized, "Invalid API key"
+	}
+
+	key := records[0]
+	if viaLink {
+		c.Set(linkContextKey, true)
+		if roleRanks[key.Role] > roleRanks[linkMaxRole] {
+			capped := *key
+			capped.Role = linkMaxRole
+			key = &capped
+		}
+	}
+	return key, http.StatusOK, ""
+}
+
+// abortLinkForbidden rejects a link token on a route outside the dashboard
+func abortLinkForbidden(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusForbidden, map[string]any{
+		"error": "Dashboard links cannot access this resource",
+	})
+}
+
+// extractAPIKey reads the key, or a link token, from
+// "Authorization: Bearer <key>" or X-API-Key
+func extractAPIKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		scheme, token, ok := strings.Cut(auth, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// AuthenticatedByLink reports whether the request carried a link token
+// instead of an API key
+func AuthenticatedByLink(c *gin.Context) bool {
+	return c.GetBool(linkContextKey)
+}
+
+// CurrentAPIKey returns the key authenticated by Require, nil on public routes
+func CurrentAPIKey(c *gin.Context) *store.APIKeyRecord {
+	if value, ok := c.Get(APIKeyContextKey); ok {
+		if key, ok := value.(*store.APIKeyRecord); ok {
+			return key
+		}
+	}
+	return nil
+}
+
+// CurrentAccountID returns the tenant of the authenticated key, empty on
+// public routes
+func CurrentAccountID(c *gin.Context) string {
+	if key := CurrentAPIKey(c); key != nil {
+		return key.AccountID
+	}
+	return ""
+}
+
+// HashAPIKey returns the hex encoded SHA-256 stored for a plain key. Keys are
+// random and long, so a fast hash is enough and keeps lookups cheap.
+func HashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateAPIKey returns a new random key together with its hash and the
+// prefix shown to identify it
+func GenerateAPIKey() (plain, hash, prefix string, err error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+
+	plain = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return plain, HashAPIKey(plain), KeyPrefix(plain), nil
+}
+
+// KeyPrefix returns the non secret beginning of a key
+func KeyPrefix(plain string) string {
+	const visible = len(apiKeyPrefix) + 6
+	if len(plain) <= visible {
+		return plain
+	}
+	return plain[:visible]
+}
@@ -7,19 +7,34 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+type CorsConfig struct {
+	// AllowOrigins lists the origins allowed to send credentialed requests,
+	// when empty every origin is allowed but without credentials
+	AllowOrigins []string
+}
+
 type Cors struct{}
 
 func NewCors() *Cors {
 	return &Cors{}
 }
 
-func (mw *Cors) Setup(engine *gin.Engine, conf any) {
+func (mw *Cors) Setup(engine *gin.Engine, conf *CorsConfig) {
+	config := cors.Config{
+		AllowMethods:  []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:  []string{"Origin", "Content-Type", "Authorization", "X-API-Key", "X-Request-ID", "Idempotency-Key"},
+		ExposeHeaders: []string{"X-Request-ID", "Idempotent-Replayed"},
+		MaxAge:        12 * time.Hour,
+	}
+
+	// Browsers reject a wildcard origin on credentialed requests, so
+	// credentials are only enabled for an explicit list of origins
+	if conf != nil && len(conf.AllowOrigins) > 0 {
+		config.AllowOrigins = conf.AllowOrigins
+		config.AllowCredentials = true
+	} else {
+		config.AllowAllOrigins = true
+	}
 
-	engine.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "OPTIONS"},
-		AllowHeaders:     []string{"*"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
+	engine.Use(cors.New(config))
 }
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultLinkTTL is how long a dashboard link is valid
+const DefaultLinkTTL = 15 * time.Minute
+
+// LinkTokenParam is the query parameter carrying the token of a dashboard link
+const LinkTokenParam = "token"
+
+// linkTokenPrefix tells link tokens apart from API keys
+const linkTokenPrefix = "link_"
+
+// LinkTokens signs the short-lived tokens of the dashboard links. A token
+// stands for the API key it was issued to, until it expires or the key is
+// revoked, so API keys themselves never travel in URLs.
+type LinkTokens struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewLinkTokens returns the signer of the link tokens. An empty secret is
+// replaced by a random one, the links then only work on this process. A zero
+// ttl is DefaultLinkTTL.
+func NewLinkTokens(secret []byte, ttl time.Duration) *LinkTokens {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic("middleware: failed to generate the link secret: " + err.Error())
+		}
+	}
+	if ttl <= 0 {
+		ttl = DefaultLinkTTL
+	}
+	return &LinkTokens{secret: secret, ttl: ttl}
+}
+
+// Issue returns a token of the key valid from now and when it expires
+func (l *LinkTokens) Issue(keyID string, now time.Time) (string, int64) {
+	expiresAt := now.Add(l.ttl).Unix()
+	payload := base64.RawURLEncoding.EncodeToString([]byte(keyID + "|" + strconv.FormatInt(expiresAt, 10)))
+	return linkTokenPrefix + payload + "." + l.sign(payload), expiresAt
+}
+
+// Verify returns the key of a token signed by Issue that has not expired
+func (l *LinkTokens) Verify(token string, now time.Time) (string, bool) {
+	payload, signature, ok := strings.Cut(strings.TrimPrefix(token, linkTokenPrefix), ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(l.sign(payload))) {
+		return "", false
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", false
+	}
+	keyID, expires, ok := strings.Cut(string(raw), "|")
+	if !ok || keyID == "" {
+		return "", false
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() >= expiresAt {
+		return "", false
+	}
+	return keyID, true
+}
+
+func (l *LinkTokens) sign(payload string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// isLinkToken reports whether a credential is a link token
+func isLinkToken(credential string) bool {
+	return strings.HasPrefix(credential, linkTokenPrefix)
+}
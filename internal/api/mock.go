@@ -13,28 +13,33 @@ type MockDatabase struct {
 	mock.Mock
 }
 
+// DriverName reports sqlite3 so queries are built with the SQLite dialect
+func (m *MockDatabase) DriverName() string {
+	return "sqlite3"
+}
+
 func (m *MockDatabase) BeginTx(ctx context.Context) (store.Transactioner, error) {
 	args := m.Called(ctx)
 	return args.Get(0).(store.Transactioner), args.Error(1)
 }
 
-func (m *MockDatabase) Exec(query string, params ...interface{}) (sql.Result, error) {
-	args := m.Called(query, params)
+func (m *MockDatabase) ExecContext(ctx context.Context, query string, params ...interface{}) (sql.Result, error) {
+	args := m.Called(ctx, query, params)
 	return args.Get(0).(sql.Result), args.Error(1)
 }
 
-func (m *MockDatabase) Query(query string, params ...interface{}) (*sql.Rows, error) {
-	args := m.Called(query, params)
+func (m *MockDatabase) QueryContext(ctx context.Context, query string, params ...interface{}) (*sql.Rows, error) {
+	args := m.Called(ctx, query, params)
 	return args.Get(0).(*sql.Rows), args.Error(1)
 }
 
-func (m *MockDatabase) Get(dest interface{}, query string, args ...interface{}) error {
-	mockArgs := m.Called(dest, query, args)
+func (m *MockDatabase) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	mockArgs := m.Called(ctx, dest, query, args)
 	return mockArgs.Error(0)
 }
 
-func (m *MockDatabase) Select(dest interface{}, query string, args ...interface{}) error {
-	mockArgs := m.Called(dest, query, args)
+func (m *MockDatabase) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	mockArgs := m.Called(ctx, dest, query, args)
 	return mockArgs.Error(0)
 }
 
@@ -43,23 +48,28 @@ type MockTransaction struct {
 	mock.Mock
 }
 
-func (m *MockTransaction) Exec(query string, params ...interface{}) (sql.Result, error) {
-	args := m.Called(query, params)
+// DriverName reports sqlite3 so queries are built with the SQLite dialect
+func (m *MockTransaction) DriverName() string {
+	return "sqlite3"
+}
+
+func (m *MockTransaction) ExecContext(ctx context.Context, query string, params ...interface{}) (sql.Result, error) {
+	args := m.Called(ctx, query, params)
 	return args.Get(0).(sql.Result), args.Error(1)
 }
 
-func (m *MockTransaction) Query(query string, params ...interface{}) (*sql.Rows, error) {
-	args := m.Called(query, params)
+func (m *MockTransaction) QueryContext(ctx context.Context, query string, params ...interface{}) (*sql.Rows, error) {
+	args := m.Called(ctx, query, params)
 	return args.Get(0).(*sql.Rows), args.Error(1)
 }
 
-func (m *MockTransaction) Get(dest interface{}, query string, args ...interface{}) error {
-	mockArgs := m.Called(dest, query, args)
+func (m *MockTransaction) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	mockArgs := m.Called(ctx, dest, query, args)
 	return mockArgs.Error(0)
 }
 
-func (m *MockTransaction) Select(dest interface{}, query string, args ...interface{}) error {
-	mockArgs := m.Called(dest, query, args)
+func (m *MockTransaction) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	mockArgs := m.Called(ctx, dest, query, args)
 	return mockArgs.Error(0)
 }
 
@@ -0,0 +1,592 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/openapi"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+// APIVersion is the version of the API reported by the OpenAPI document
+const APIVersion = "1.0.0"
+
+// openAPISecurityScheme is the name of the API key scheme in the document
+const openAPISecurityScheme = "apiKey"
+
+//go:embed templates/openapi_explorer.html
+var openAPIExplorerPage []byte
+
+// Payloads of the handlers responding a map, described for the document
+
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Error   string       `json:"error"`
+	Details string       `json:"details,omitempty"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// MessageResponse confirms a deactivation or a deletion
+type MessageResponse struct {
+	Message string `json:"message"`
+	ID      string `json:"id,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Status  string `json:"status,omitempty"`
+}
+
+// HealthResponse is the body of the health check
+type HealthResponse struct {
+	Status    string `json:"status"`
+	RequestID string `json:"request_id"`
+}
+
+// AdsPageResponse is a page of ads and the cursor of the next one
+type AdsPageResponse struct {
+	Ads        []*store.AdvertiseRecord `json:"ads"`
+	NextCursor *string                  `json:"next_cursor"`
+	Total      int64                    `json:"total"`
+}
+
+// AdHistoryResponse is the audit log of an ad, oldest entry first
+type AdHistoryResponse struct {
+	AdID    string                 `json:"adId"`
+	History []*store.AdAuditRecord `json:"history"`
+}
+
+// PlacementsResponse lists the placements
+type PlacementsResponse struct {
+	Placements []*store.PlacementRecord `json:"placements"`
+}
+
+// AdvertisersResponse lists the advertisers of the account
+type AdvertisersResponse struct {
+	Advertisers []*store.AdvertiserRecord `json:"advertisers"`
+}
+
+// CampaignsResponse lists the campaigns of the account
+type CampaignsResponse struct {
+	Campaigns []*store.CampaignRecord `json:"campaigns"`
+}
+
+// APIKeysResponse lists the API keys of the account
+type APIKeysResponse struct {
+	APIKeys []*store.APIKeyRecord `json:"api_keys"`
+}
+
+// apiOperation documents one route registered by RegisterRoutes
+type apiOperation struct {
+	method string
+	// path is the OpenAPI path, route the gin route serving it when it is
+	// not the same, like the custom methods sharing /v1/ads:action
+	path  string
+	route string
+
+	id          string
+	tag         string
+	summary     string
+	description string
+	// role is the API key role required, empty for public operations
+	role   string
+	params []*openapi.Parameter
+
+	// request is the JSON body, or the schema of the requestTypes bodies
+	request      any
+	requestTypes []string
+	responses    []apiResponse
+}
+
+// apiResponse documents one status of an operation
+type apiResponse struct {
+	status      int
+	description string
+	// body is nil for responses without body, contentTypes default to JSON
+	body         any
+	contentTypes []string
+}
+
+// ginRoute returns the gin route serving the operation
+func (op *apiOperation) ginRoute() string {
+	if op.route != "" {
+		return op.route
+	}
+	return strings.NewReplacer("{", ":", "}", "").Replace(op.path)
+}
+
+// textBody is the schema of the bodies that are not JSON
+var textBody = &openapi.Schema{Type: openapi.TypeString}
+
+var (
+	idempotencyKeyParam = &openapi.Parameter{
+		Name:        IdempotencyKeyHeader,
+		In:          openapi.InHeader,
+		Description: "Replays the stored response when a request is retried with the same key",
+		Schema:      &openapi.Schema{Type: openapi.TypeString, MaxLength: int64Ptr(maxIdempotencyKeyLength)},
+	}
+	adIDQueryParam = queryParam("ad_id", "Ad the event belongs to", stringSchema(), true)
+)
+
+// adFilterParams are the filters of the ads listing and export
+func adFilterParams() []*openapi.Parameter {
+	return []*openapi.Parameter{
+		queryParam("placement", "Only the ads of a placement", stringSchema(), false),
+		queryParam("status", "Only the ads with a status, inactive also leaves out the expired ones",
+			stringSchema(store.AdvertiseStatusActive, store.AdvertiseStatusInactive, store.AdvertiseStatusExpired), false),
+		queryParam("lifecycle", "Only the ads scheduled, live or expired now",
+			stringSchema(store.AdvertiseLifecycleScheduled, store.AdvertiseLifecycleLive, store.AdvertiseLifecycleExpired), false),
+		queryParam("campaign_id", "Only the ads of a campaign", stringSchema(), false),
+		queryParam("sort", "Sort field created_at, expires_at or title, optionally followed by :asc or :desc", stringSchema(), false),
+	}
+}
+
+// apiOperations documents every route of RegisterRoutes, TestOpenAPICoversRoutes
+// fails when one is missing
+func apiOperations() []*apiOperation {
+	batchResponses := func(success int, description string) []apiResponse {
+		return []apiResponse{
+			{status: success, description: description, body: BatchResponse{}},
+			{status: http.StatusMultiStatus, description: "Partial batch where some items failed", body: BatchResponse{}},
+			{status: http.StatusUnprocessableEntity, description: "Nothing applied, see the failed items", body: BatchResponse{}},
+		}
+	}
+	operatorOnly := "Placements are shared by every account, only the admin keys of the operator account change them."
+	deleted := []apiResponse{{status: http.StatusOK, description: "Deleted", body: MessageResponse{}}}
+
+	return []*apiOperation{
+		{
+			method: http.MethodGet, path: "/health", id: "getHealth", tag: "system",
+			summary:   "Health check",
+			responses: []apiResponse{{status: http.StatusOK, description: "The server is running", body: HealthResponse{}}},
+		},
+		{
+			method: http.MethodGet, path: "/metrics", id: "getMetrics", tag: "system",
+			summary:     "Prometheus metrics",
+			description: "Public, an API key adds the series of its account.",
+			responses:   []apiResponse{{status: http.StatusOK, description: "Metrics in the Prometheus text format", body: "", contentTypes: []string{"text/plain"}}},
+		},
+		{
+			method: http.MethodGet, path: "/dashboard", id: "getDashboard", tag: "system", role: store.APIKeyRoleReader,
+			summary:     "HTML dashboard of the ads",
+			description: "Opened with the API key in a header, or from a link of createDashboardLink.",
+			params: []*openapi.Parameter{
+				queryParam(middleware.LinkTokenParam, "Token of a dashboard link", stringSchema(), false),
+			},
+			responses: []apiResponse{{status: http.StatusOK, description: "Dashboard page", body: "", contentTypes: []string{"text/html"}}},
+		},
+		{
+			method: http.MethodGet, path: "/openapi.json", id: "getOpenAPI", tag: "system",
+			summary:   "This document",
+			responses: []apiResponse{{status: http.StatusOK, description: "OpenAPI 3 document", body: map[string]any{}}},
+		},
+		{
+			method: http.MethodGet, path: "/docs", id: "getDocs", tag: "system",
+			summary:   "Explorer of this document",
+			responses: []apiResponse{{status: http.StatusOK, description: "Explorer page", body: "", contentTypes: []string{"text/html"}}},
+		},
+
+		{
+			method: http.MethodPost, path: "/v1/ads", id: "createAd", tag: "ads", role: store.APIKeyRoleEditor,
+			summary: "Create an ad",
+			params:  []*openapi.Parameter{idempotencyKeyParam},
+			request: PostAdsHandlerRequest{},
+			responses: []apiResponse{
+				{status: http.StatusCreated, description: "Created ad", body: store.AdvertiseRecord{}},
+				{status: http.StatusConflict, description: "A request with the same Idempotency-Key is in progress", body: ErrorResponse{}},
+				{status: http.StatusUnprocessableEntity, description: "Idempotency-Key reused with a different request", body: ErrorResponse{}},
+			},
+		},
+		{
+			method: http.MethodPost, path: "/v1/ads:batch", route: "/v1/ads:action", id: "batchCreateAds", tag: "ads", role: store.APIKeyRoleEditor,
+			summary:     "Create many ads",
+			description: "Items are validated like createAd. Atomic batches apply nothing when an item fails, partial ones apply the valid items.",
+			params:      []*openapi.Parameter{idempotencyKeyParam},
+			request:     PostAdsBatchHandlerRequest{},
+			responses:   batchResponses(http.StatusCreated, "Every ad created"),
+		},
+		{
+			method: http.MethodPost, path: "/v1/ads:batchDeactivate", route: "/v1/ads:action", id: "batchDeactivateAds", tag: "ads", role: store.APIKeyRoleEditor,
+			summary:     "Deactivate many ads",
+			description: "Targets either a list of ids or the active ads matching a filter.",
+			request:     PostAdsBatchDeactivateHandlerRequest{},
+			responses: append(batchResponses(http.StatusOK, "Every ad deactivated"),
+				apiResponse{status: http.StatusConflict, description: "An ad of an atomic batch expired while it ran", body: ErrorResponse{}}),
+		},
+		{
+			method: http.MethodPost, path: "/v1/ads/import", id: "importAds", tag: "ads", role: store.APIKeyRoleEditor,
+			summary:     "Import ads from CSV or JSON Lines",
+			description: "CSV files need a header line with the title, image_url and placement columns, JSON Lines files hold a createAd body per line. The status and expires_at of an export are restored.",
+			params: []*openapi.Parameter{
+				idempotencyKeyParam,
+				queryParam("format", "Format of the file, taken from the Content-Type when omitted", stringSchema(AdsFormatCSV, AdsFormatJSONL), false),
+				queryParam("mode", "atomic (default) or partial", stringSchema(BatchModeAtomic, BatchModePartial), false),
+				queryParam("dry_run", "Only validate the rows", &openapi.Schema{Type: openapi.TypeBoolean}, false),
+			},
+			request:      "",
+			requestTypes: []string{"text/csv", "application/x-ndjson"},
+			responses: append(batchResponses(http.StatusCreated, "Every row created"),
+				apiResponse{status: http.StatusOK, description: "Report of a dry run", body: BatchResponse{}}),
+		},
+		{
+			method: http.MethodGet, path: "/v1/ads/export", id: "exportAds", tag: "ads", role: store.APIKeyRoleReader,
+			summary: "Export ads as CSV or JSON Lines",
+			params: append(adFilterParams(),
+				queryParam("format", "csv (default) or jsonl", stringSchema(AdsFormatCSV, AdsFormatJSONL), false)),
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "Every ad matching the filters", body: "", contentTypes: []string{"text/csv", "application/x-ndjson"}},
+			},
+		},
+		{
+			method: http.MethodGet, path: "/v1/ads/{id}", id: "getAd", tag: "ads", role: store.APIKeyRoleReader,
+			summary:   "Get an ad",
+			responses: []apiResponse{{status: http.StatusOK, description: "The ad", body: store.AdvertiseRecord{}}},
+		},
+		{
+			method: http.MethodGet, path: "/v1/ads", id: "listAds", tag: "ads", role: store.APIKeyRoleReader,
+			summary: "List ads",
+			params: append(adFilterParams(),
+				queryParam("limit", "Page size", &openapi.Schema{Type: openapi.TypeInteger, Minimum: int64Ptr(1), Maximum: int64Ptr(maxAdsPageSize)}, false),
+				queryParam("cursor", "next_cursor of the previous page", stringSchema(), false),
+			),
+			responses: []apiResponse{{status: http.StatusOK, description: "A page of ads", body: AdsPageResponse{}}},
+		},
+		{
+			method: http.MethodPatch, path: "/v1/ads/{id}", id: "updateAd", tag: "ads", role: store.APIKeyRoleEditor,
+			summary: "Update an ad",
+			request: PatchAdsHandlerRequest{},
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "Updated ad", body: store.AdvertiseRecord{}},
+				{status: http.StatusConflict, description: "The status of an expired ad cannot change", body: ErrorResponse{}},
+			},
+		},
+		{
+			method: http.MethodPost, path: "/v1/ads/{id}/deactivate", id: "deactivateAd", tag: "ads", role: store.APIKeyRoleEditor,
+			summary:     "Deactivate an ad",
+			description: "An ad already inactive is left untouched.",
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "Deactivated", body: MessageResponse{}},
+				{status: http.StatusConflict, description: "The ad is expired", body: ErrorResponse{}},
+			},
+		},
+		{
+			method: http.MethodGet, path: "/v1/ads/{id}/stats", id: "getAdStats", tag: "ads", role: store.APIKeyRoleReader,
+			summary: "Impressions, clicks and CTR of an ad",
+			params: []*openapi.Parameter{
+				queryParam("from", "Start of the range, unix timestamp", &openapi.Schema{Type: openapi.TypeInteger, Minimum: int64Ptr(0)}, false),
+				queryParam("to", "End of the range, unix timestamp", &openapi.Schema{Type: openapi.TypeInteger, Minimum: int64Ptr(0)}, false),
+				queryParam("bucket", "Width of the buckets, hour by default", stringSchema("minute", "hour", "day"), false),
+			},
+			responses: []apiResponse{{status: http.StatusOK, description: "Report of the range", body: AdStatsResponse{}}},
+		},
+		{
+			method: http.MethodGet, path: "/v1/ads/{id}/history", id: "getAdHistory", tag: "ads", role: store.APIKeyRoleReader,
+			summary:   "Audit log of an ad",
+			responses: []apiResponse{{status: http.StatusOK, description: "Every change of the ad", body: AdHistoryResponse{}}},
+		},
+
+		{
+			method: http.MethodPost, path: "/v1/placements", id: "createPlacement", tag: "placements", role: store.APIKeyRoleAdmin,
+			summary:     "Register a placement",
+			description: operatorOnly,
+			request:     PostPlacementsHandlerRequest{},
+			responses:   []apiResponse{{status: http.StatusCreated, description: "Created placement", body: store.PlacementRecord{}}},
+		},
+		{
+			method: http.MethodGet, path: "/v1/placements", id: "listPlacements", tag: "placements", role: store.APIKeyRoleReader,
+			summary:   "List placements",
+			responses: []apiResponse{{status: http.StatusOK, description: "Every placement", body: PlacementsResponse{}}},
+		},
+		{
+			method: http.MethodGet, path: "/v1/placements/{name}", id: "getPlacement", tag: "placements", role: store.APIKeyRoleReader,
+			summary:   "Get a placement",
+			responses: []apiResponse{{status: http.StatusOK, description: "The placement", body: store.PlacementRecord{}}},
+		},
+		{
+			method: http.MethodPatch, path: "/v1/placements/{name}", id: "updatePlacement", tag: "placements", role: store.APIKeyRoleAdmin,
+			summary:     "Update a placement",
+			description: operatorOnly,
+			request:     PatchPlacementsHandlerRequest{},
+			responses:   []apiResponse{{status: http.StatusOK, description: "Updated placement", body: store.PlacementRecord{}}},
+		},
+		{
+			method: http.MethodDelete, path: "/v1/placements/{name}", id: "deletePlacement", tag: "placements", role: store.APIKeyRoleAdmin,
+			summary:     "Delete a placement without ads",
+			description: operatorOnly,
+			responses:   deleted,
+		},
+
+		{
+			method: http.MethodPost, path: "/v1/advertisers", id: "createAdvertiser", tag: "advertisers", role: store.APIKeyRoleEditor,
+			summary:   "Create an advertiser",
+			request:   PostAdvertisersHandlerRequest{},
+			responses: []apiResponse{{status: http.StatusCreated, description: "Created advertiser", body: store.AdvertiserRecord{}}},
+		},
+		{
+			method: http.MethodGet, path: "/v1/advertisers", id: "listAdvertisers", tag: "advertisers", role: store.APIKeyRoleReader,
+			summary:   "List advertisers",
+			responses: []apiResponse{{status: http.StatusOK, description: "Every advertiser of the account", body: AdvertisersResponse{}}},
+		},
+		{
+			method: http.MethodGet, path: "/v1/advertisers/{id}", id: "getAdvertiser", tag: "advertisers", role: store.APIKeyRoleReader,
+			summary:   "Get an advertiser",
+			responses: []apiResponse{{status: http.StatusOK, description: "The advertiser", body: store.AdvertiserRecord{}}},
+		},
+		{
+			method: http.MethodPatch, path: "/v1/advertisers/{id}", id: "updateAdvertiser", tag: "advertisers", role: store.APIKeyRoleEditor,
+			summary:   "Rename an advertiser",
+			request:   PatchAdvertisersHandlerRequest{},
+			responses: []apiResponse{{status: http.StatusOK, description: "Updated advertiser", body: store.AdvertiserRecord{}}},
+		},
+		{
+			method: http.MethodDelete, path: "/v1/advertisers/{id}", id: "deleteAdvertiser", tag: "advertisers", role: store.APIKeyRoleEditor,
+			summary:   "Delete an advertiser without campaigns",
+			responses: deleted,
+		},
+
+		{
+			method: http.MethodPost, path: "/v1/campaigns", id: "createCampaign", tag: "campaigns", role: store.APIKeyRoleEditor,
+			summary:   "Create a campaign",
+			request:   PostCampaignsHandlerRequest{},
+			responses: []apiResponse{{status: http.StatusCreated, description: "Created campaign", body: store.CampaignRecord{}}},
+		},
+		{
+			method: http.MethodGet, path: "/v1/campaigns", id: "listCampaigns", tag: "campaigns", role: store.APIKeyRoleReader,
+			summary: "List campaigns",
+			params: []*openapi.Parameter{
+				queryParam("advertiser_id", "Only the campaigns of an advertiser", stringSchema(), false),
+				queryParam("status", "Only the campaigns with a status", stringSchema(store.CampaignStatusActive, store.CampaignStatusPaused), false),
+			},
+			responses: []apiResponse{{status: http.StatusOK, description: "The campaigns of the account", body: CampaignsResponse{}}},
+		},
+		{
+			method: http.MethodGet, path: "/v1/campaigns/{id}", id: "getCampaign", tag: "campaigns", role: store.APIKeyRoleReader,
+			summary:   "Get a campaign",
+			responses: []apiResponse{{status: http.StatusOK, description: "The campaign", body: store.CampaignRecord{}}},
+		},
+		{
+			method: http.MethodPatch, path: "/v1/campaigns/{id}", id: "updateCampaign", tag: "campaigns", role: store.APIKeyRoleEditor,
+			summary:   "Rename, pause or resume a campaign",
+			request:   PatchCampaignsHandlerRequest{},
+			responses: []apiResponse{{status: http.StatusOK, description: "Updated campaign", body: store.CampaignRecord{}}},
+		},
+		{
+			method: http.MethodDelete, path: "/v1/campaigns/{id}", id: "deleteCampaign", tag: "campaigns", role: store.APIKeyRoleEditor,
+			summary:   "Delete a campaign without ads",
+			responses: deleted,
+		},
+
+		{
+			method: http.MethodPost, path: "/v1/dashboard/links", id: "createDashboardLink", tag: "system", role: store.APIKeyRoleReader,
+			summary:     "Issue a dashboard link",
+			description: "The link opens the dashboard with the role of the API key, capped at editor, until it expires. Its token is only accepted by the dashboard, ad creation and ad deactivation.",
+			responses:   []apiResponse{{status: http.StatusCreated, description: "Short-lived link", body: DashboardLinkResponse{}}},
+		},
+		{
+			method: http.MethodPost, path: "/v1/accounts", id: "createAccount", tag: "accounts", role: store.APIKeyRoleAdmin,
+			summary:     "Provision an account",
+			description: "Only the admin keys of the operator account provision accounts. The response carries the first admin key of the account, only shown once.",
+			request:     PostAccountsHandlerRequest{},
+			responses:   []apiResponse{{status: http.StatusCreated, description: "The account and its admin key", body: PostAccountsHandlerResponse{}}},
+		},
+		{
+			method: http.MethodPost, path: "/v1/api-keys", id: "createAPIKey", tag: "api-keys", role: store.APIKeyRoleAdmin,
+			summary:   "Issue an API key",
+			request:   PostAPIKeysHandlerRequest{},
+			responses: []apiResponse{{status: http.StatusCreated, description: "The key, only shown once", body: PostAPIKeysHandlerResponse{}}},
+		},
+		{
+			method: http.MethodGet, path: "/v1/api-keys", id: "listAPIKeys", tag: "api-keys", role: store.APIKeyRoleAdmin,
+			summary: "List API keys",
+			params: []*openapi.Parameter{
+				queryParam("active", "true leaves out the revoked keys", &openapi.Schema{Type: openapi.TypeBoolean}, false),
+			},
+			responses: []apiResponse{{status: http.StatusOK, description: "The keys of the account", body: APIKeysResponse{}}},
+		},
+		{
+			method: http.MethodDelete, path: "/v1/api-keys/{id}", id: "revokeAPIKey", tag: "api-keys", role: store.APIKeyRoleAdmin,
+			summary:   "Revoke an API key",
+			responses: []apiResponse{{status: http.StatusOK, description: "Revoked", body: MessageResponse{}}},
+		},
+
+		{
+			method: http.MethodGet, path: "/v1/serve/{placement}", id: "serveAd", tag: "delivery",
+			summary: "Pick the ad to show in a placement",
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "The ad to show", body: ServeAdResponse{}},
+				{status: http.StatusNoContent, description: "No ad is live in the placement"},
+			},
+		},
+		{
+			method: http.MethodGet, path: "/v1/track/impression", id: "trackImpression", tag: "delivery",
+			summary:   "Track an impression, as a pixel",
+			params:    []*openapi.Parameter{adIDQueryParam},
+			responses: []apiResponse{{status: http.StatusNoContent, description: "Tracked"}},
+		},
+		{
+			method: http.MethodPost, path: "/v1/track/impression", id: "trackImpressionBeacon", tag: "delivery",
+			summary:   "Track an impression, as a beacon",
+			params:    []*openapi.Parameter{adIDQueryParam},
+			responses: []apiResponse{{status: http.StatusNoContent, description: "Tracked"}},
+		},
+		{
+			method: http.MethodGet, path: "/v1/track/click", id: "trackClick", tag: "delivery",
+			summary: "Track a click and redirect to the landing page",
+			params:  []*openapi.Parameter{adIDQueryParam},
+			responses: []apiResponse{
+				{status: http.StatusFound, description: "Redirect to the click_url of the ad"},
+				{status: http.StatusNoContent, description: "Tracked, the ad has no click_url"},
+			},
+		},
+	}
+}
+
+// buildOpenAPIDocument derives the document from apiOperations
+func buildOpenAPIDocument() *openapi.Document {
+	gen := openapi.NewGenerator()
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       "AdMoai Ads API",
+			Description: "Manage, serve and track ads. Authenticate with an API key as a bearer token.",
+			Version:     APIVersion,
+		},
+		Security: []openapi.SecurityRequirement{{openAPISecurityScheme: {}}},
+		Tags: []openapi.Tag{
+			{Name: "ads"}, {Name: "placements"}, {Name: "advertisers"}, {Name: "campaigns"},
+			{Name: "accounts"}, {Name: "api-keys"}, {Name: "delivery", Description: "Public endpoints called by the pages showing the ads"},
+			{Name: "system"},
+		},
+	}
+
+	errorSchema := gen.Schema(ErrorResponse{})
+	for _, op := range apiOperations() {
+		operation := &openapi.Operation{
+			OperationID: op.id,
+			Summary:     op.summary,
+			Description: op.description,
+			Tags:        []string{op.tag},
+			Responses:   map[string]*openapi.Response{},
+			Security:    []openapi.SecurityRequirement{},
+		}
+
+		for _, name := range openapi.PathParams(op.path) {
+			operation.Parameters = append(operation.Parameters, &openapi.Parameter{
+				Name:     name,
+				In:       openapi.InPath,
+				Required: true,
+				Schema:   stringSchema(),
+			})
+		}
+		operation.Parameters = append(operation.Parameters, op.params...)
+
+		if op.request != nil {
+			types := op.requestTypes
+			if len(types) == 0 {
+				types = []string{"application/json"}
+			}
+			operation.RequestBody = &openapi.RequestBody{Required: true, Content: content(gen, op.request, types)}
+		}
+
+		for _, resp := range op.responses {
+			response := &openapi.Response{Description: resp.description}
+			if resp.body != nil {
+				types := resp.contentTypes
+				if len(types) == 0 {
+					types = []string{"application/json"}
+				}
+				response.Content = content(gen, resp.body, types)
+			}
+			operation.Responses[strconv.Itoa(resp.status)] = response
+		}
+
+		// Errors every operation may respond
+		errorResponse := func(description string) *openapi.Response {
+			return &openapi.Response{
+				Description: description,
+				Content:     map[string]*openapi.MediaType{"application/json": {Schema: errorSchema}},
+			}
+		}
+		if op.request != nil || len(operation.Parameters) > 0 {
+			operation.Responses["400"] = errorResponse("Invalid request")
+		}
+		if len(openapi.PathParams(op.path)) > 0 {
+			operation.Responses["404"] = errorResponse("Not found")
+		}
+		if op.role != "" {
+			operation.Description = strings.TrimSpace(operation.Description + " Requires the " + op.role + " role.")
+			operation.Security = doc.Security
+			operation.Responses["401"] = errorResponse("Missing or invalid API key")
+			operation.Responses["403"] = errorResponse("The API key lacks the " + op.role + " role")
+		}
+		operation.Responses["default"] = errorResponse("Unexpected error")
+
+		doc.AddOperation(op.method, op.path, operation)
+	}
+
+	doc.Components = openapi.Components{
+		Schemas: gen.Schemas(),
+		SecuritySchemes: map[string]*openapi.SecurityScheme{
+			openAPISecurityScheme: {
+				Type:        "http",
+				Scheme:      "bearer",
+				Description: "API key issued by POST /v1/api-keys, or the token of a dashboard link",
+			},
+		},
+	}
+	return doc
+}
+
+// content returns the schema of a body in each content type, strings stand
+// for the bodies that are not JSON
+func content(gen *openapi.Generator, body any, types []string) map[string]*openapi.MediaType {
+	schema := gen.Schema(body)
+	if _, ok := body.(string); ok {
+		schema = textBody
+	}
+
+	out := make(map[string]*openapi.MediaType, len(types))
+	for _, contentType := range types {
+		out[contentType] = &openapi.MediaType{Schema: schema}
+	}
+	return out
+}
+
+// OpenAPIDocument returns the document of the API, built once
+var OpenAPIDocument = sync.OnceValue(buildOpenAPIDocument)
+
+// GetOpenAPIHandler serves the OpenAPI document
+func GetOpenAPIHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	return OpenAPIDocument(), http.StatusOK, nil
+}
+
+// GetOpenAPIExplorerHandler serves the page exploring the OpenAPI document
+func GetOpenAPIExplorerHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", openAPIExplorerPage)
+	return nil, http.StatusOK, nil
+}
+
+func queryParam(name, description string, schema *openapi.Schema, required bool) *openapi.Parameter {
+	return &openapi.Parameter{
+		Name:        name,
+		In:          openapi.InQuery,
+		Description: description,
+		Required:    required,
+		Schema:      schema,
+	}
+}
+
+// stringSchema returns a string schema, restricted to the values when given
+func stringSchema(values ...string) *openapi.Schema {
+	schema := &openapi.Schema{Type: openapi.TypeString}
+	for _, value := range values {
+		schema.Enum = append(schema.Enum, value)
+	}
+	return schema
+}
+
+func int64Ptr(value int64) *int64 {
+	return &value
+}
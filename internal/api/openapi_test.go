@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	engine := gin.New()
+	RegisterRoutes(&Context{}, engine)
+
+	documented := map[string]bool{}
+	for _, op := range apiOperations() {
+		documented[op.method+" "+op.ginRoute()] = true
+	}
+
+	registered := map[string]bool{}
+	for _, route := range engine.Routes() {
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		assert.True(t, documented[key], "route %s is missing from apiOperations", key)
+	}
+	for key := range documented {
+		assert.True(t, registered[key], "documented route %s is not registered", key)
+	}
+
+	doc := OpenAPIDocument()
+	ids := map[string]bool{}
+	for _, op := range apiOperations() {
+		operation := doc.Operation(op.method, op.path)
+		require.NotNil(t, operation, "%s %s", op.method, op.path)
+		assert.False(t, ids[operation.OperationID], "duplicated operationId %s", operation.OperationID)
+		ids[operation.OperationID] = true
+	}
+}
+
+func TestOpenAPIReferencesResolve(t *testing.T) {
+	raw, err := json.Marshal(OpenAPIDocument())
+	require.NoError(t, err)
+
+	// Every $ref of the document names a component schema
+	var walk func(node any)
+	walk = func(node any) {
+		switch v := node.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				name := strings.TrimPrefix(ref, "#/components/schemas/")
+				assert.Contains(t, OpenAPIDocument().Components.Schemas, name, ref)
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	var decoded any
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	walk(decoded)
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	doc := OpenAPIDocument()
+
+	// Binding tags become constraints
+	create := doc.Resolve(doc.Operation(http.MethodPost, "/v1/ads").RequestBody.Content["application/json"].Schema)
+	require.NotNil(t, create)
+	assert.ElementsMatch(t, []string{"title", "image_url", "placement"}, create.Required)
+	assert.Equal(t, "uri", create.Properties["image_url"].Format)
+	assert.Equal(t, int64(1), *create.Properties["weight"].Minimum)
+	assert.Equal(t, int64(1000), *create.Properties["weight"].Maximum)
+
+	patch := doc.Resolve(doc.Operation(http.MethodPatch, "/v1/ads/{id}").RequestBody.Content["application/json"].Schema)
+	assert.Equal(t, []any{"active", "inactive"}, patch.Properties["status"].Enum)
+	assert.True(t, patch.Properties["title"].Nullable)
+
+	placement := doc.Resolve(doc.Operation(http.MethodPost, "/v1/placements").RequestBody.Content["application/json"].Schema)
+	assert.Contains(t, placement.Properties["image_formats"].Items.Enum, "png")
+
+	// Responses use the json names of the records
+	ad := doc.Components.Schemas["AdvertiseRecord"]
+	require.NotNil(t, ad)
+	assert.Contains(t, ad.Properties, "imageUrl")
+	assert.NotContains(t, ad.Properties, "ImageURL")
+	assert.True(t, ad.Properties["clickUrl"].Nullable)
+
+	// Embedded structs are flattened
+	key := doc.Resolve(doc.Operation(http.MethodPost, "/v1/api-keys").Responses["201"].Content["application/json"].Schema)
+	assert.Contains(t, key.Properties, "key")
+	assert.Contains(t, key.Properties, "role")
+	assert.NotContains(t, key.Properties, "key_hash")
+
+	// Authentication follows the role of the route
+	assert.Empty(t, doc.Operation(http.MethodGet, "/v1/serve/{placement}").Security)
+	assert.NotEmpty(t, doc.Operation(http.MethodGet, "/v1/ads").Security)
+	assert.Contains(t, doc.Operation(http.MethodGet, "/v1/ads").Responses, "401")
+
+	assert.Equal(t, "/v1/ads/{id}/stats", openapi.PathFromRoute("/v1/ads/:id/stats"))
+}
+
+func TestGetOpenAPIHandler(t *testing.T) {
+	engine := gin.New()
+	RegisterRoutes(&Context{}, engine)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, openapi.Version, doc["openapi"])
+	assert.Contains(t, doc["paths"], "/v1/ads")
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "/openapi.json")
+}
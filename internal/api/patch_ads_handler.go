@@ -0,0 +1,247 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/metrics"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+// PatchAdsHandlerRequest holds the mutable fields of an ad, nil fields are kept
+type PatchAdsHandlerRequest struct {
+	Title    *string `json:"title" binding:"omitempty,min=1"`
+	ImageURL *string `json:"image_url" binding:"omitempty,url"`
+	ClickURL *string `json:"click_url" binding:"omitempty,url"`
+	// CampaignID moves the ad to a campaign, "" takes it out of its campaign
+	CampaignID *string `json:"campaign_id"`
+	Placement  *string `json:"placement" binding:"omitempty,min=1"`
+	Status     *string `json:"status" binding:"omitempty,oneof=active inactive"`
+	Weight     *int64  `json:"weight" binding:"omitempty,min=1,max=1000"`
+	Width      *int64  `json:"width" binding:"omitempty,min=1"`
+	Height     *int64  `json:"height" binding:"omitempty,min=1"`
+	// StartsAt reschedules the ad to a unix timestamp, 0 makes it live now
+	StartsAt *int64 `json:"starts_at" binding:"omitempty,min=0"`
+	// Ttl resets the expiration to Ttl minutes after the ad goes live, like
+	// on creation, 0 removes it
+	Ttl *int64 `json:"ttl" binding:"omitempty,min=0"`
+	// ExpiresAt sets the expiration to an absolute unix timestamp
+	ExpiresAt *int64 `json:"expires_at" binding:"omitempty,min=1"`
+}
+
+func (req *PatchAdsHandlerRequest) empty() bool {
+	return req.Title == nil && req.ImageURL == nil && req.ClickURL == nil && req.CampaignID == nil && req.Placement == nil &&
+		req.Status == nil && req.Weight == nil && req.Width == nil && req.Height == nil &&
+		req.StartsAt == nil && req.Ttl == nil && req.ExpiresAt == nil
+}
+
+// changesCreative reports whether the update touches a field constrained by
+// the placement of the ad
+func (req *PatchAdsHandlerRequest) changesCreative() bool {
+	return req.Title != nil || req.ImageURL != nil || req.Placement != nil || req.Width != nil || req.Height != nil
+}
+
+func PatchAdsHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	// Get ID from path parameters
+	id := c.Param("id")
+	if id == "" {
+		return gin.H{
+			"error": "ID parameter is required",
+		}, http.StatusBadRequest, nil
+	}
+
+	var req PatchAdsHandlerRequest
+
+	// Bind JSON with validation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": err.Error(),
+		}, http.StatusBadRequest, nil
+	}
+
+	if req.empty() {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": "At least one field must be provided",
+		}, http.StatusBadRequest, nil
+	}
+
+	accountID := middleware.CurrentAccountID(c)
+
+	args := &query.UpdateAdsArgs{
+		AccountID: accountID,
+		ID:        id,
+		Title:     req.Title,
+		ImageURL:  req.ImageURL,
+		ClickURL:  req.ClickURL,
+		Placement: req.Placement,
+		Status:    req.Status,
+		Weight:    req.Weight,
+		Width:     req.Width,
+		Height:    req.Height,
+	}
+
+	// Keep the ad as it was for the audit log
+	records, err := query.SelectAds(c.Request.Context(), ctx.Db, &query.SelectAdsArgs{AccountID: accountID, ID: id})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PatchAdsHandler query error")
+	}
+	if len(records) == 0 {
+		return map[string]any{
+			"error": "Ad not found",
+		}, http.StatusNotFound, nil
+	}
+	before := records[0]
+
+	if req.Status != nil && before.Status == store.AdvertiseStatusExpired {
+		return expiredAdConflict(), http.StatusConflict, nil
+	}
+
+	// Check the resulting ad against the constraints of its placement, ads
+	// are not checked again when only other fields change
+	if req.changesCreative() {
+		updated := *before
+		if req.Title != nil {
+			updated.Title = *req.Title
+		}
+		if req.ImageURL != nil {
+			updated.ImageURL = *req.ImageURL
+		}
+		if req.Placement != nil {
+			updated.Placement = *req.Placement
+		}
+		if req.Width != nil {
+			updated.Width = req.Width
+		}
+		if req.Height != nil {
+			updated.Height = req.Height
+		}
+
+		fields, err := validateCreative(c, ctx, &updated)
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PatchAdsHandler placement error")
+		}
+		if len(fields) > 0 {
+			return validationFailed(fields), http.StatusBadRequest, nil
+		}
+	}
+
+	// Resolve the new campaign
+	if req.CampaignID != nil {
+		if *req.CampaignID == "" {
+			args.ClearCampaignID = true
+		} else {
+			payload, err := validateCampaign(c, ctx, accountID, *req.CampaignID)
+			if err != nil {
+				return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PatchAdsHandler campaign error")
+			}
+			if payload != nil {
+				return payload, http.StatusBadRequest, nil
+			}
+			args.CampaignID = req.CampaignID
+		}
+	}
+
+	now := time.Now()
+
+	// Resolve the new start time, and the moment the ad goes live the ttl
+	// counts from: its start time, or its creation when it has none
+	liveAt := before.CreatedAt
+	if before.StartsAt != nil {
+		liveAt = *before.StartsAt
+	}
+	if req.StartsAt != nil {
+		if *req.StartsAt <= now.Unix() {
+			args.ClearStartsAt = true
+			liveAt = now.Unix()
+		} else {
+			args.StartsAt = req.StartsAt
+			liveAt = *req.StartsAt
+		}
+	}
+
+	// Resolve the new expiration from either ttl or expires_at
+	switch {
+	case req.Ttl != nil && req.ExpiresAt != nil:
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": "Only one of ttl and expires_at can be provided",
+		}, http.StatusBadRequest, nil
+	case req.Ttl != nil && *req.Ttl == 0:
+		args.ClearExpiresAt = true
+	case req.Ttl != nil:
+		expAt := time.Unix(liveAt, 0).Add(time.Duration(*req.Ttl) * time.Minute).Unix()
+		if expAt <= now.Unix() {
+			return map[string]any{
+				"error":   "Validation failed",
+				"details": "ttl must end in the future, it counts from the moment the ad goes live",
+			}, http.StatusBadRequest, nil
+		}
+		args.ExpiresAt = &expAt
+	case req.ExpiresAt != nil:
+		if *req.ExpiresAt <= now.Unix() {
+			return map[string]any{
+				"error":   "Validation failed",
+				"details": "expires_at must be in the future",
+			}, http.StatusBadRequest, nil
+		}
+		args.ExpiresAt = req.ExpiresAt
+	}
+
+	// An ad cannot expire before it goes live, the expiration kept when only
+	// the start time moves included
+	if req.StartsAt != nil || req.ExpiresAt != nil {
+		expiresAt := before.ExpiresAt
+		if args.ExpiresAt != nil {
+			expiresAt = args.ExpiresAt
+		}
+		if expiresAt != nil && !args.ClearExpiresAt && *expiresAt <= liveAt {
+			return map[string]any{
+				"error":   "Validation failed",
+				"details": "expires_at must be after starts_at",
+			}, http.StatusBadRequest, nil
+		}
+	}
+
+	// Update the ad, an ad found above that is not updated has just been
+	// expired by the reaper
+	err = query.UpdateAds(c.Request.Context(), ctx.Db, args)
+	if errors.Is(err, query.ErrAdNotFound) {
+		return expiredAdConflict(), http.StatusConflict, nil
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PatchAdsHandler update error")
+	}
+
+	// Read back the updated record
+	records, err = query.SelectAds(c.Request.Context(), ctx.Db, &query.SelectAdsArgs{AccountID: accountID, ID: id})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PatchAdsHandler query error")
+	}
+	if len(records) == 0 {
+		return map[string]any{
+			"error": "Ad not found",
+		}, http.StatusNotFound, nil
+	}
+
+	err = recordAdAudit(c, ctx.Db, store.AdAuditActionUpdate, before, records[0])
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PatchAdsHandler audit error")
+	}
+
+	// Increment metrics for ad update, once committed
+	afterCommit(c, func() {
+		if collector := metrics.GetCollector(); collector != nil {
+			collector.IncrementAdUpdated(accountID)
+		}
+	})
+
+	records[0].CalculateAndSetExpired()
+
+	return records[0], http.StatusOK, nil
+}
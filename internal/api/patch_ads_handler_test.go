@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchAdsHandler(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		var created store.AdvertiseRecord
+		status := srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title:     "Original",
+			ImageURL:  "https://example.com/original.jpg",
+			Placement: "homepage",
+			Ttl:       10,
+		}, &created)
+		require.Equal(t, http.StatusCreated, status)
+
+		future := time.Now().Add(2 * time.Hour).Unix()
+
+		testCases := []struct {
+			name           string
+			id             string
+			body           map[string]any
+			expectedStatus int
+			check          func(t *testing.T, rec *store.AdvertiseRecord)
+		}{
+			{
+				name:           "updates title and placement",
+				id:             created.ID,
+				body:           map[string]any{"title": "Renamed", "placement": "sidebar"},
+				expectedStatus: http.StatusOK,
+				check: func(t *testing.T, rec *store.AdvertiseRecord) {
+					assert.Equal(t, "Renamed", rec.Title)
+					assert.Equal(t, "sidebar", rec.Placement)
+					assert.Equal(t, created.ImageURL, rec.ImageURL)
+					assert.Equal(t, *created.ExpiresAt, *rec.ExpiresAt)
+				},
+			},
+			{
+				name:           "sets absolute expiration",
+				id:             created.ID,
+				body:           map[string]any{"expires_at": future},
+				expectedStatus: http.StatusOK,
+				check: func(t *testing.T, rec *store.AdvertiseRecord) {
+					require.NotNil(t, rec.ExpiresAt)
+					assert.Equal(t, future, *rec.ExpiresAt)
+				},
+			},
+			{
+				name:           "ttl zero removes expiration",
+				id:             created.ID,
+				body:           map[string]any{"ttl": 0, "status": "inactive"},
+				expectedStatus: http.StatusOK,
+				check: func(t *testing.T, rec *store.AdvertiseRecord) {
+					assert.Nil(t, rec.ExpiresAt)
+					assert.Equal(t, store.AdvertiseStatusInactive, rec.Status)
+					assert.Equal(t, "Renamed", rec.Title)
+				},
+			},
+			{
+				name:           "rejects invalid image url",
+				id:             created.ID,
+				body:           map[string]any{"image_url": "not-a-url"},
+				expectedStatus: http.StatusBadRequest,
+			},
+			{
+				name:           "rejects unknown status",
+				id:             created.ID,
+				body:           map[string]any{"status": "paused"},
+				expectedStatus: http.StatusBadRequest,
+			},
+			{
+				name:           "rejects ttl and expires_at together",
+				id:             created.ID,
+				body:           map[string]any{"ttl": 5, "expires_at": future},
+				expectedStatus: http.StatusBadRequest,
+			},
+			{
+				name:           "rejects empty body",
+				id:             created.ID,
+				body:           map[string]any{},
+				expectedStatus: http.StatusBadRequest,
+			},
+			{
+				name:           "unknown ad returns not found",
+				id:             "missing",
+				body:           map[string]any{"title": "Nope"},
+				expectedStatus: http.StatusNotFound,
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				var rec store.AdvertiseRecord
+				status := srv.do(http.MethodPatch, "/v1/ads/"+tc.id, tc.body, &rec)
+				assert.Equal(t, tc.expectedStatus, status)
+				if tc.check != nil {
+					tc.check(t, &rec)
+				}
+			})
+		}
+	})
+}
+
+func TestPatchAdsTtl(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		startsAt := time.Now().Add(2 * time.Hour).Unix()
+		var live, scheduled store.AdvertiseRecord
+		require.Equal(t, http.StatusCreated, srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title: "Live", ImageURL: "https://example.com/live.jpg", Placement: "homepage", Ttl: 10,
+		}, &live))
+		require.Equal(t, http.StatusCreated, srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title: "Scheduled", ImageURL: "https://example.com/scheduled.jpg", Placement: "homepage", StartsAt: startsAt, Ttl: 10,
+		}, &scheduled))
+		require.Equal(t, startsAt+10*60, *scheduled.ExpiresAt)
+
+		// Live for two hours long before the ttl was changed
+		old := &store.AdvertiseRecord{
+			ID:        "old",
+			AccountID: store.AccountDefaultID,
+			Title:     "Old",
+			ImageURL:  "https://example.com/old.jpg",
+			Placement: "homepage",
+			Status:    store.AdvertiseStatusActive,
+			Weight:    store.AdvertiseDefaultWeight,
+			CreatedAt: time.Now().Add(-2 * time.Hour).Unix(),
+		}
+		require.NoError(t, query.InsertAds(context.Background(), srv.ctx.Db, old))
+
+		patch := func(id string, body map[string]any) (*store.AdvertiseRecord, int) {
+			var rec store.AdvertiseRecord
+			status := srv.do(http.MethodPatch, "/v1/ads/"+id, body, &rec)
+			return &rec, status
+		}
+
+		// The ttl counts from the moment the ad goes live, like on creation
+		rec, status := patch(live.ID, map[string]any{"ttl": 60})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, live.CreatedAt+60*60, *rec.ExpiresAt)
+
+		rec, status = patch(scheduled.ID, map[string]any{"ttl": 60})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, startsAt+60*60, *rec.ExpiresAt)
+
+		rec, status = patch(scheduled.ID, map[string]any{"starts_at": startsAt + 3600, "ttl": 30})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, startsAt+3600+30*60, *rec.ExpiresAt)
+
+		rec, status = patch(old.ID, map[string]any{"ttl": 180})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, old.CreatedAt+180*60, *rec.ExpiresAt)
+
+		// A ttl ending before now is rejected rather than expiring the ad
+		_, status = patch(old.ID, map[string]any{"ttl": 60})
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		// Moved live now, the ttl counts from now
+		rec, status = patch(scheduled.ID, map[string]any{"starts_at": 0, "ttl": 60})
+		require.Equal(t, http.StatusOK, status)
+		assert.Nil(t, rec.StartsAt)
+		assert.InDelta(t, time.Now().Add(time.Hour).Unix(), *rec.ExpiresAt, 2)
+
+		// An ad cannot expire before it goes live, it expires in an hour and
+		// startsAt is two hours away
+		for name, body := range map[string]map[string]any{
+			"starts_at after the expiry":  {"starts_at": startsAt},
+			"expires_at before starts_at": {"starts_at": startsAt, "expires_at": startsAt - 60},
+			"expires_at at starts_at":     {"starts_at": startsAt, "expires_at": startsAt},
+		} {
+			_, status = patch(scheduled.ID, body)
+			assert.Equal(t, http.StatusBadRequest, status, name)
+		}
+		rec, status = patch(scheduled.ID, map[string]any{"starts_at": startsAt, "expires_at": startsAt + 60})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, startsAt+60, *rec.ExpiresAt)
+
+		_, status = patch(scheduled.ID, map[string]any{"expires_at": startsAt - 1})
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+// PatchAdvertisersHandlerRequest holds the mutable fields of an advertiser
+type PatchAdvertisersHandlerRequest struct {
+	Name *string `json:"name" binding:"required,min=1,max=200"`
+}
+
+func PatchAdvertisersHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	id := c.Param("id")
+	if id == "" {
+		return map[string]any{
+			"error": "ID parameter is required",
+		}, http.StatusBadRequest, nil
+	}
+
+	var req PatchAdvertisersHandlerRequest
+
+	// Bind JSON with validation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": err.Error(),
+		}, http.StatusBadRequest, nil
+	}
+
+	accountID := middleware.CurrentAccountID(c)
+
+	err := query.UpdateAdvertisers(c.Request.Context(), ctx.Db, &query.UpdateAdvertisersArgs{
+		AccountID: accountID,
+		ID:        id,
+		Name:      req.Name,
+	})
+	if errors.Is(err, query.ErrAdvertiserNotFound) {
+		return map[string]any{
+			"error": "Advertiser not found",
+		}, http.StatusNotFound, nil
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PatchAdvertisersHandler update error")
+	}
+
+	// Read back the updated record
+	records, err := query.SelectAdvertisers(c.Request.Context(), ctx.Db, &query.SelectAdvertisersArgs{AccountID: accountID, ID: id})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PatchAdvertisersHandler query error")
+	}
+	if len(records) == 0 {
+		return map[string]any{
+			"error": "Advertiser not found",
+		}, http.StatusNotFound, nil
+	}
+
+	return records[0], http.StatusOK, nil
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+// PatchCampaignsHandlerRequest holds the mutable fields of a campaign, nil
+// fields are kept
+type PatchCampaignsHandlerRequest struct {
+	Name *string `json:"name" binding:"omitempty,min=1,max=200"`
+	// Status pauses or resumes all the ads of the campaign
+	Status *string `json:"status" binding:"omitempty,oneof=active paused"`
+}
+
+func PatchCampaignsHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	id := c.Param("id")
+	if id == "" {
+		return map[string]any{
+			"error": "ID parameter is required",
+		}, http.StatusBadRequest, nil
+	}
+
+	var req PatchCampaignsHandlerRequest
+
+	// Bind JSON with validation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": err.Error(),
+		}, http.StatusBadRequest, nil
+	}
+
+	if req.Name == nil && req.Status == nil {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": "At least one field must be provided",
+		}, http.StatusBadRequest, nil
+	}
+
+	accountID := middleware.CurrentAccountID(c)
+
+	err := query.UpdateCampaigns(c.Request.Context(), ctx.Db, &query.UpdateCampaignsArgs{
+		AccountID: accountID,
+		ID:        id,
+		Name:      req.Name,
+		Status:    req.Status,
+	})
+	if errors.Is(err, query.ErrCampaignNotFound) {
+		return map[string]any{
+			"error": "Campaign not found",
+		}, http.StatusNotFound, nil
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PatchCampaignsHandler update error")
+	}
+
+	// Read back the updated record
+	records, err := query.SelectCampaigns(c.Request.Context(), ctx.Db, &query.SelectCampaignsArgs{AccountID: accountID, ID: id})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PatchCampaignsHandler query error")
+	}
+	if len(records) == 0 {
+		return map[string]any{
+			"error": "Campaign not found",
+		}, http.StatusNotFound, nil
+	}
+
+	return records[0], http.StatusOK, nil
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+// PatchPlacementsHandlerRequest replaces the constraints that are provided,
+// an empty list or a zero length removes the constraint. Existing ads are
+// not checked again.
+type PatchPlacementsHandlerRequest struct {
+	Sizes          *[]string `json:"sizes"`
+	MaxTitleLength *int64    `json:"max_title_length" binding:"omitempty,min=0"`
+	ImageFormats   *[]string `json:"image_formats" binding:"omitempty,dive,oneof=jpg jpeg png gif webp svg"`
+}
+
+func PatchPlacementsHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	name := c.Param("name")
+	if name == "" {
+		return map[string]any{
+			"error": "Name parameter is required",
+		}, http.StatusBadRequest, nil
+	}
+
+	var req PatchPlacementsHandlerRequest
+
+	// Bind JSON with validation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": err.Error(),
+		}, http.StatusBadRequest, nil
+	}
+
+	if req.Sizes == nil && req.MaxTitleLength == nil && req.ImageFormats == nil {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": "At least one field must be provided",
+		}, http.StatusBadRequest, nil
+	}
+
+	args := &query.UpdatePlacementsArgs{
+		Name:           name,
+		MaxTitleLength: req.MaxTitleLength,
+	}
+	if req.Sizes != nil {
+		sizes, fields := normalizeSizes(*req.Sizes)
+		if len(fields) > 0 {
+			return validationFailed(fields), http.StatusBadRequest, nil
+		}
+		args.Sizes = &sizes
+	}
+	if req.ImageFormats != nil {
+		formats := normalizeImageFormats(*req.ImageFormats)
+		args.ImageFormats = &formats
+	}
+
+	err := query.UpdatePlacements(c.Request.Context(), ctx.Db, args)
+	if errors.Is(err, query.ErrPlacementNotFound) {
+		return map[string]any{
+			"error": "Placement not found",
+		}, http.StatusNotFound, nil
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PatchPlacementsHandler update error")
+	}
+
+	// Read back the updated record
+	records, err := query.SelectPlacements(c.Request.Context(), ctx.Db, &query.SelectPlacementsArgs{Name: name})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PatchPlacementsHandler query error")
+	}
+	if len(records) == 0 {
+		return map[string]any{
+			"error": "Placement not found",
+		}, http.StatusNotFound, nil
+	}
+
+	return records[0], http.StatusOK, nil
+}
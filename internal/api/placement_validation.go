@@ -0,0 +1,136 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+)
+
+// FieldError describes why one field of a request was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// validationFailed builds the 400 payload of a request rejected field by
+// field, details keeps the flat message of the other validation errors
+func validationFailed(fields []FieldError) map[string]any {
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, field.Message)
+	}
+
+	return map[string]any{
+		"error":   "Validation failed",
+		"details": strings.Join(messages, "; "),
+		"fields":  fields,
+	}
+}
+
+// validateCreative checks an ad against the placement it references
+func validateCreative(c *gin.Context, ctx *Context, ad *store.AdvertiseRecord) ([]FieldError, error) {
+	placements, err := query.SelectPlacements(c.Request.Context(), ctx.Db, &query.SelectPlacementsArgs{Name: ad.Placement})
+	if err != nil {
+		return nil, err
+	}
+	if len(placements) == 0 {
+		return []FieldError{{
+			Field:   "placement",
+			Code:    "unknown_placement",
+			Message: fmt.Sprintf("placement %q is not registered", ad.Placement),
+		}}, nil
+	}
+
+	return checkPlacement(placements[0], ad), nil
+}
+
+// checkPlacement returns the constraints of the placement the ad breaks
+func checkPlacement(placement *store.PlacementRecord, ad *store.AdvertiseRecord) []FieldError {
+	var fields []FieldError
+
+	if placement.MaxTitleLength > 0 && int64(utf8.RuneCountInString(ad.Title)) > placement.MaxTitleLength {
+		fields = append(fields, FieldError{
+			Field:   "title",
+			Code:    "too_long",
+			Message: fmt.Sprintf("title must be at most %d characters for placement %s", placement.MaxTitleLength, placement.Name),
+		})
+	}
+
+	if len(placement.Sizes) > 0 {
+		allowed := strings.Join(placement.Sizes, ", ")
+		switch {
+		case ad.Width == nil || ad.Height == nil:
+			fields = append(fields, FieldError{
+				Field:   "width",
+				Code:    "size_required",
+				Message: fmt.Sprintf("width and height are required for placement %s, allowed sizes: %s", placement.Name, allowed),
+			})
+		case !placement.Sizes.Contains(formatSize(*ad.Width, *ad.Height)):
+			fields = append(fields, FieldError{
+				Field:   "width",
+				Code:    "size_not_allowed",
+				Message: fmt.Sprintf("size %s is not allowed for placement %s, allowed sizes: %s", formatSize(*ad.Width, *ad.Height), placement.Name, allowed),
+			})
+		}
+	}
+
+	if len(placement.ImageFormats) > 0 {
+		if format := imageFormat(ad.ImageURL); !placement.ImageFormats.Contains(format) {
+			fields = append(fields, FieldError{
+				Field:   "image_url",
+				Code:    "format_not_allowed",
+				Message: fmt.Sprintf("image format %q is not allowed for placement %s, allowed formats: %s", format, placement.Name, strings.Join(placement.ImageFormats, ", ")),
+			})
+		}
+	}
+
+	return fields
+}
+
+// formatSize renders a size the way placements list them
+func formatSize(width, height int64) string {
+	return fmt.Sprintf("%dx%d", width, height)
+}
+
+// parseSize parses "<width>x<height>" with positive dimensions
+func parseSize(size string) (int64, int64, bool) {
+	rawWidth, rawHeight, ok := strings.Cut(strings.ToLower(strings.TrimSpace(size)), "x")
+	if !ok {
+		return 0, 0, false
+	}
+	width, err := strconv.ParseInt(rawWidth, 10, 64)
+	if err != nil || width <= 0 {
+		return 0, 0, false
+	}
+	height, err := strconv.ParseInt(rawHeight, 10, 64)
+	if err != nil || height <= 0 {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// imageFormat returns the normalized file extension of an image URL
+func imageFormat(imageURL string) string {
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return ""
+	}
+	return normalizeImageFormat(path.Ext(u.Path))
+}
+
+// normalizeImageFormat lowercases a format and folds its aliases
+func normalizeImageFormat(format string) string {
+	format = strings.ToLower(strings.TrimPrefix(format, "."))
+	if format == "jpeg" {
+		return "jpg"
+	}
+	return format
+}
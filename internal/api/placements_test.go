@@ -0,0 +1,153 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlacementConstraints(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		var placement store.PlacementRecord
+		status := srv.do(http.MethodPost, "/v1/placements", PostPlacementsHandlerRequest{
+			Name:           "banner",
+			Sizes:          []string{"300x250", "728X90"},
+			MaxTitleLength: 10,
+			ImageFormats:   []string{"png", "jpeg"},
+		}, &placement)
+		require.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, store.StringList{"300x250", "728x90"}, placement.Sizes)
+		assert.Equal(t, store.StringList{"png", "jpg"}, placement.ImageFormats)
+
+		var fetched store.PlacementRecord
+		status = srv.do(http.MethodGet, "/v1/placements/banner", nil, &fetched)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, placement, fetched)
+
+		status = srv.do(http.MethodPost, "/v1/placements", PostPlacementsHandlerRequest{Name: "banner"}, nil)
+		assert.Equal(t, http.StatusConflict, status)
+
+		status = srv.do(http.MethodPost, "/v1/placements", PostPlacementsHandlerRequest{
+			Name:  "broken",
+			Sizes: []string{"300by250"},
+		}, nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		type validationResponse struct {
+			Error  string       `json:"error"`
+			Fields []FieldError `json:"fields"`
+		}
+		codes := func(res validationResponse) []string {
+			out := make([]string, 0, len(res.Fields))
+			for _, field := range res.Fields {
+				out = append(out, field.Field+":"+field.Code)
+			}
+			return out
+		}
+
+		testCases := []struct {
+			name           string
+			req            PostAdsHandlerRequest
+			expectedStatus int
+			expectedCodes  []string
+		}{
+			{
+				name:           "unknown placement",
+				req:            PostAdsHandlerRequest{Title: "Sale", ImageURL: "https://example.com/a.png", Placement: "homepge"},
+				expectedStatus: http.StatusBadRequest,
+				expectedCodes:  []string{"placement:unknown_placement"},
+			},
+			{
+				name:           "every constraint broken",
+				req:            PostAdsHandlerRequest{Title: "Summer mega sale", ImageURL: "https://example.com/a.gif", Placement: "banner"},
+				expectedStatus: http.StatusBadRequest,
+				expectedCodes:  []string{"title:too_long", "width:size_required", "image_url:format_not_allowed"},
+			},
+			{
+				name:           "size not allowed",
+				req:            PostAdsHandlerRequest{Title: "Sale", ImageURL: "https://example.com/a.png", Placement: "banner", Width: 100, Height: 100},
+				expectedStatus: http.StatusBadRequest,
+				expectedCodes:  []string{"width:size_not_allowed"},
+			},
+			{
+				name:           "valid creative",
+				req:            PostAdsHandlerRequest{Title: "Sale", ImageURL: "https://example.com/a.JPEG", Placement: "banner", Width: 728, Height: 90},
+				expectedStatus: http.StatusCreated,
+			},
+		}
+
+		var created store.AdvertiseRecord
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				if tc.expectedStatus == http.StatusCreated {
+					require.Equal(t, tc.expectedStatus, srv.do(http.MethodPost, "/v1/ads", tc.req, &created))
+					return
+				}
+				var res validationResponse
+				require.Equal(t, tc.expectedStatus, srv.do(http.MethodPost, "/v1/ads", tc.req, &res))
+				assert.Equal(t, "Validation failed", res.Error)
+				assert.Equal(t, tc.expectedCodes, codes(res))
+			})
+		}
+		require.NotEmpty(t, created.ID)
+
+		// Updates touching the creative are checked against the placement
+		var res validationResponse
+		longTitle := "Far too long title"
+		status = srv.do(http.MethodPatch, "/v1/ads/"+created.ID, PatchAdsHandlerRequest{Title: &longTitle}, &res)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, []string{"title:too_long"}, codes(res))
+
+		// Tightening a placement does not block unrelated updates of its ads
+		maxTitle := int64(2)
+		status = srv.do(http.MethodPatch, "/v1/placements/banner", PatchPlacementsHandlerRequest{MaxTitleLength: &maxTitle}, nil)
+		require.Equal(t, http.StatusOK, status)
+		weight := int64(5)
+		status = srv.do(http.MethodPatch, "/v1/ads/"+created.ID, PatchAdsHandlerRequest{Weight: &weight}, nil)
+		assert.Equal(t, http.StatusOK, status)
+
+		// Placements in use cannot be removed
+		assert.Equal(t, http.StatusConflict, srv.do(http.MethodDelete, "/v1/placements/banner", nil, nil))
+		assert.Equal(t, http.StatusOK, srv.do(http.MethodDelete, "/v1/placements/sidebar", nil, nil))
+		assert.Equal(t, http.StatusNotFound, srv.do(http.MethodGet, "/v1/placements/sidebar", nil, nil))
+	})
+}
+
+func TestPlacementsOperatorOnly(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		operatorKey := srv.apiKey
+		otherKey := srv.insertAPIKey("acme-admin", "acme", store.APIKeyRoleAdmin)
+
+		// The admins of other accounts read the shared registry but cannot
+		// change what every account's ads are checked against
+		srv.apiKey = otherKey
+		testCases := []struct {
+			name   string
+			method string
+			path   string
+			body   any
+		}{
+			{"create", http.MethodPost, "/v1/placements", PostPlacementsHandlerRequest{Name: "banner"}},
+			{"update", http.MethodPatch, "/v1/placements/homepage", map[string]any{"max_title_length": 3}},
+			{"delete", http.MethodDelete, "/v1/placements/sidebar", nil},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				assert.Equal(t, http.StatusForbidden, srv.do(tc.method, tc.path, tc.body, nil))
+			})
+		}
+		assert.Equal(t, http.StatusOK, srv.do(http.MethodGet, "/v1/placements/homepage", nil, nil))
+
+		var placement store.PlacementRecord
+		srv.apiKey = operatorKey
+		require.Equal(t, http.StatusOK, srv.do(http.MethodGet, "/v1/placements/homepage", nil, &placement))
+		assert.Zero(t, placement.MaxTitleLength)
+		assert.Equal(t, http.StatusOK, srv.do(http.MethodGet, "/v1/placements/sidebar", nil, nil))
+
+		assert.Equal(t, http.StatusCreated, srv.do(http.MethodPost, "/v1/placements", PostPlacementsHandlerRequest{Name: "banner"}, nil))
+		assert.Equal(t, http.StatusOK, srv.do(http.MethodDelete, "/v1/placements/banner", nil, nil))
+	})
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+type PostAccountsHandlerRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// PostAccountsHandlerResponse is the new account with its first admin key,
+// the only response carrying the plain key
+type PostAccountsHandlerResponse struct {
+	Account *store.AccountRecord        `json:"account"`
+	APIKey  *PostAPIKeysHandlerResponse `json:"api_key"`
+}
+
+// PostAccountsHandler provisions a tenant, its admin then issues the other
+// keys of the account with POST /v1/api-keys
+func PostAccountsHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	var req PostAccountsHandlerRequest
+
+	// Bind JSON with validation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": err.Error(),
+		}, http.StatusBadRequest, nil
+	}
+
+	record := &store.AccountRecord{
+		ID:        uuid.NewString(),
+		Name:      req.Name,
+		CreatedAt: time.Now().Unix(),
+	}
+
+	err := query.InsertAccounts(c.Request.Context(), ctx.Db, record)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostAccountsHandler insert error")
+	}
+
+	key, err := issueAPIKey(c, ctx, record.ID, req.Name+" admin", store.APIKeyRoleAdmin)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostAccountsHandler")
+	}
+
+	return &PostAccountsHandlerResponse{
+		Account: record,
+		APIKey:  key,
+	}, http.StatusCreated, nil
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/metrics"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+// PostAdsBatchDeactivateHandlerRequest deactivates the ads listed in IDs or
+// the active ads matching Filter, exactly one of them is required
+type PostAdsBatchDeactivateHandlerRequest struct {
+	// Mode is atomic (default) or partial
+	Mode   string          `json:"mode" binding:"omitempty,oneof=atomic partial"`
+	IDs    []string        `json:"ids" binding:"omitempty,max=1000,dive,min=1"`
+	Filter *AdsBatchFilter `json:"filter"`
+}
+
+// AdsBatchFilter selects the active ads of the account to deactivate
+type AdsBatchFilter struct {
+	Placement  string `json:"placement"`
+	CampaignID string `json:"campaign_id"`
+}
+
+func (f *AdsBatchFilter) empty() bool {
+	return f.Placement == "" && f.CampaignID == ""
+}
+
+// batchDeactivateTarget is an ad to deactivate with the result of its item
+type batchDeactivateTarget struct {
+	ad     *store.AdvertiseRecord
+	result *BatchItemResult
+}
+
+func PostAdsBatchDeactivateHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	var req PostAdsBatchDeactivateHandlerRequest
+
+	// Bind JSON with validation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": err.Error(),
+		}, http.StatusBadRequest, nil
+	}
+
+	switch {
+	case len(req.IDs) > 0 && req.Filter != nil:
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": "Only one of ids and filter can be provided",
+		}, http.StatusBadRequest, nil
+	case len(req.IDs) == 0 && req.Filter == nil:
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": "One of ids and filter must be provided",
+		}, http.StatusBadRequest, nil
+	case req.Filter != nil && req.Filter.empty():
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": "filter must set at least one of placement and campaign_id",
+		}, http.StatusBadRequest, nil
+	}
+
+	accountID := middleware.CurrentAccountID(c)
+
+	resp := &BatchResponse{Mode: req.Mode}
+	if resp.Mode == "" {
+		resp.Mode = BatchModeAtomic
+	}
+
+	// Resolve the ads of the batch
+	args := &query.SelectAdsArgs{AccountID: accountID, IDs: req.IDs}
+	if req.Filter != nil {
+		args = &query.SelectAdsArgs{
+			AccountID:  accountID,
+			Status:     store.AdvertiseStatusActive,
+			Placement:  req.Filter.Placement,
+			CampaignID: req.Filter.CampaignID,
+			Order:      &query.SortOrder{Field: "created_at"},
+			// One more than allowed tells an oversized filter apart
+			Limit: maxBatchSize + 1,
+		}
+	}
+	records, err := query.SelectAds(c.Request.Context(), ctx.Db, args)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostAdsBatchDeactivateHandler query error")
+	}
+	if len(records) > maxBatchSize {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": "filter matches more than 1000 ads, narrow it down",
+		}, http.StatusBadRequest, nil
+	}
+
+	// Match the ads to the items of the request, a filter lists its matches
+	ids := req.IDs
+	byID := make(map[string]*store.AdvertiseRecord, len(records))
+	for _, rec := range records {
+		byID[rec.ID] = rec
+	}
+	if req.Filter != nil {
+		ids = make([]string, 0, len(records))
+		for _, rec := range records {
+			ids = append(ids, rec.ID)
+		}
+	}
+
+	resp.Results = make([]*BatchItemResult, 0, len(ids))
+	targets := make([]batchDeactivateTarget, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for i, id := range ids {
+		rec, ok := byID[id]
+		switch {
+		case !ok:
+			resp.Results = append(resp.Results, failedItem(i, id, map[string]any{"error": "Ad not found"}))
+			resp.Failed++
+		case seen[id]:
+			resp.Results = append(resp.Results, failedItem(i, id, map[string]any{"error": "Duplicate ad id in batch"}))
+			resp.Failed++
+		case rec.Status == store.AdvertiseStatusExpired:
+			resp.Results = append(resp.Results, failedItem(i, id, expiredAdConflict()))
+			resp.Failed++
+		case rec.Status == store.AdvertiseStatusInactive:
+			// Nothing changes, so nothing is audited nor counted
+			seen[id] = true
+			resp.Results = append(resp.Results, &BatchItemResult{Index: i, ID: id, Status: BatchItemUnchanged})
+			resp.Succeeded++
+		default:
+			seen[id] = true
+			result := &BatchItemResult{Index: i, ID: id, Status: BatchItemDeactivated}
+			targets = append(targets, batchDeactivateTarget{ad: rec, result: result})
+			resp.Results = append(resp.Results, result)
+			resp.Succeeded++
+		}
+	}
+
+	if resp.Failed > 0 && resp.Mode == BatchModeAtomic {
+		skipValidItems(resp)
+		recordBatch(c, "deactivate", resp)
+		return resp, batchStatus(resp, http.StatusOK), nil
+	}
+
+	// Deactivate and audit every ad in the transaction of the request
+	status := store.AdvertiseStatusInactive
+	deactivated := 0
+	for _, target := range targets {
+		before := target.ad
+		err := query.UpdateAds(c.Request.Context(), ctx.Db, &query.UpdateAdsArgs{
+			AccountID: accountID,
+			ID:        before.ID,
+			Status:    &status,
+		})
+		if errors.Is(err, query.ErrAdNotFound) {
+			// Expired by the reaper or deleted since it was read, an atomic
+			// batch applies nothing while a partial one reports the item
+			if resp.Mode == BatchModeAtomic {
+				return expiredAdConflict(), http.StatusConflict, nil
+			}
+			*target.result = *failedItem(target.result.Index, before.ID, map[string]any{
+				"error":   "Ad not found or expired",
+				"details": "The ad changed since the batch read it",
+			})
+			resp.Succeeded--
+			resp.Failed++
+			continue
+		}
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostAdsBatchDeactivateHandler update error")
+		}
+
+		after := *before
+		after.Status = status
+		if err := recordAdAudit(c, ctx.Db, store.AdAuditActionDeactivate, before, &after); err != nil {
+			return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostAdsBatchDeactivateHandler audit error")
+		}
+		deactivated++
+	}
+
+	// Increment metrics for ad deactivation, once committed
+	afterCommit(c, func() {
+		if collector := metrics.GetCollector(); collector != nil {
+			for range deactivated {
+				collector.IncrementAdDeactivated(accountID)
+			}
+		}
+	})
+	recordBatch(c, "deactivate", resp)
+
+	return resp, batchStatus(resp, http.StatusOK), nil
+}
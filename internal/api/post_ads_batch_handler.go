@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/pkg/errors"
+)
+
+// PostAdsBatchHandlerRequest creates many ads at once, every item is
+// validated like a POST /v1/ads body
+type PostAdsBatchHandlerRequest struct {
+	// Mode is atomic (default) or partial
+	Mode string                  `json:"mode" binding:"omitempty,oneof=atomic partial"`
+	Ads  []PostAdsHandlerRequest `json:"ads" binding:"required,min=1,max=1000"`
+}
+
+func PostAdsBatchHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	var req PostAdsBatchHandlerRequest
+
+	// Bind JSON with validation, the items are validated one by one below
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": err.Error(),
+		}, http.StatusBadRequest, nil
+	}
+
+	resp := &BatchResponse{
+		Mode:    req.Mode,
+		Results: make([]*BatchItemResult, 0, len(req.Ads)),
+	}
+	if resp.Mode == "" {
+		resp.Mode = BatchModeAtomic
+	}
+
+	// Validate every item before writing any of them
+	createdAt := time.Now()
+	records := make([]*store.AdvertiseRecord, 0, len(req.Ads))
+	for i := range req.Ads {
+		rec, failure, err := validateAdItem(c, ctx, i, &req.Ads[i], createdAt)
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostAdsBatchHandler validation error")
+		}
+		if failure != nil {
+			resp.Results = append(resp.Results, failure)
+			resp.Failed++
+			continue
+		}
+
+		records = append(records, rec)
+		resp.Results = append(resp.Results, &BatchItemResult{
+			Index:  i,
+			ID:     rec.ID,
+			Status: BatchItemCreated,
+			Ad:     rec,
+		})
+		resp.Succeeded++
+	}
+
+	if resp.Failed > 0 && resp.Mode == BatchModeAtomic {
+		skipValidItems(resp)
+		recordBatch(c, "create", resp)
+		return resp, batchStatus(resp, http.StatusCreated), nil
+	}
+
+	if err := insertAdBatch(c, ctx, records); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostAdsBatchHandler insert error")
+	}
+	recordBatch(c, "create", resp)
+
+	return resp, batchStatus(resp, http.StatusCreated), nil
+}
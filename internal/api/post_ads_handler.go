@@ -2,11 +2,11 @@ package api
 
 import (
 	"net/http"
-	"net/url"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
 	"github.com/mtavano/admoai-takehome/internal/metrics"
 	"github.com/mtavano/admoai-takehome/internal/store"
 	"github.com/mtavano/admoai-takehome/internal/store/query"
@@ -14,10 +14,23 @@ import (
 )
 
 type PostAdsHandlerRequest struct {
-	Title     string `json:"title" binding:"required"`
-	ImageURL  string `json:"image_url" binding:"required,url"`
-	Placement string `json:"placement" binding:"required"`
-	Ttl       int64  `json:"ttl"`
+	Title    string `json:"title" binding:"required"`
+	ImageURL string `json:"image_url" binding:"required,url"`
+	ClickURL string `json:"click_url" binding:"omitempty,url"`
+	// CampaignID groups the ad under a campaign of the account
+	CampaignID string `json:"campaign_id"`
+	Placement  string `json:"placement" binding:"required"`
+	Ttl        int64  `json:"ttl"`
+	// Weight is the relative share of the ad in the serving rotation
+	Weight int64 `json:"weight" binding:"omitempty,min=1,max=1000"`
+	// Width and Height declare the creative size, required by placements
+	// restricting the sizes
+	Width  int64 `json:"width" binding:"omitempty,min=1,required_with=Height"`
+	Height int64 `json:"height" binding:"omitempty,min=1,required_with=Width"`
+	// StartsAt schedules the ad to go live at a unix timestamp
+	StartsAt int64 `json:"starts_at" binding:"omitempty,min=0"`
+	// StartDelay schedules the ad to go live after a number of minutes
+	StartDelay int64 `json:"start_delay" binding:"omitempty,min=0"`
 }
 
 func PostAdsHandler(c *gin.Context, ctx *Context) (any, int, error) {
@@ -31,43 +44,145 @@ func PostAdsHandler(c *gin.Context, ctx *Context) (any, int, error) {
 		}, http.StatusBadRequest, nil
 	}
 
-	// Additional custom validation for image_url
-	if _, err := url.ParseRequestURI(req.ImageURL); err != nil {
-		return map[string]any{
-			"error":   "Invalid image URL format",
-			"details": "The image_url must be a valid URL",
-		}, http.StatusBadRequest, nil
+	rec, payload, err := newAdFromRequest(c, ctx, &req, time.Now())
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostAdsHandler validation error")
+	}
+	if payload != nil {
+		return payload, http.StatusBadRequest, nil
+	}
+
+	err = query.InsertAds(c.Request.Context(), ctx.Db, rec)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostAdsHandlerRequest error")
+	}
+
+	// Audited in the transaction of the request, see Transactional
+	err = recordAdAudit(c, ctx.Db, store.AdAuditActionCreate, nil, rec)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostAdsHandler audit error")
+	}
+
+	// Increment metrics for ad creation, once committed
+	afterCommit(c, func() {
+		if collector := metrics.GetCollector(); collector != nil {
+			collector.IncrementAdCreated(rec.AccountID)
+		}
+	})
+
+	return rec, http.StatusCreated, nil
+}
+
+// newAdFromRequest validates a bound creation request and builds the ad it
+// describes. It returns the error payload when the request is invalid.
+func newAdFromRequest(c *gin.Context, ctx *Context, req *PostAdsHandlerRequest, createdAt time.Time) (*store.AdvertiseRecord, map[string]any, error) {
+	accountID := middleware.CurrentAccountID(c)
+
+	var campaignID *string
+	if req.CampaignID != "" {
+		payload, err := validateCampaign(c, ctx, accountID, req.CampaignID)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "campaign error")
+		}
+		if payload != nil {
+			return nil, payload, nil
+		}
+		campaignID = &req.CampaignID
+	}
+
+	// Resolve the moment the ad goes live, either absolute or delayed
+	startAt := createdAt
+	var startsAt *int64
+	switch {
+	case req.StartsAt > 0 && req.StartDelay > 0:
+		return nil, map[string]any{
+			"error":   "Validation failed",
+			"details": "Only one of starts_at and start_delay can be provided",
+		}, nil
+	case req.StartsAt > 0:
+		startAt = time.Unix(req.StartsAt, 0)
+	case req.StartDelay > 0:
+		startAt = createdAt.Add(time.Duration(req.StartDelay) * time.Minute)
+	}
+	if startAt.After(createdAt) {
+		startAtUnix := startAt.Unix()
+		startsAt = &startAtUnix
+	} else {
+		// A start in the past means the ad is live right away
+		startAt = createdAt
 	}
 
-	createdAt := time.Now()
 	var expiresAt *int64
 	if req.Ttl > 0 {
-		// Calculate expiration time by adding TTL minutes to creation time
-		expAt := createdAt.Add(time.Duration(req.Ttl) * time.Minute)
+		// Calculate expiration time by adding TTL minutes to the start time
+		expAt := startAt.Add(time.Duration(req.Ttl) * time.Minute)
 		expAtUnix := int64(expAt.Unix())
 		expiresAt = &expAtUnix
 	}
 
+	var clickURL *string
+	if req.ClickURL != "" {
+		clickURL = &req.ClickURL
+	}
+
+	weight := req.Weight
+	if weight == 0 {
+		weight = store.AdvertiseDefaultWeight
+	}
+
 	rec := &store.AdvertiseRecord{
-		ID:        uuid.NewString(),
-		Title:     req.Title,
-		ImageURL:  req.ImageURL,
-		Placement: req.Placement,
-		Status:    store.AdvertiseStatusActive,
-		CreatedAt: int64(createdAt.Unix()),
-		ExpiresAt: expiresAt,
+		ID:         uuid.NewString(),
+		AccountID:  accountID,
+		CampaignID: campaignID,
+		Title:      req.Title,
+		ImageURL:   req.ImageURL,
+		ClickURL:   clickURL,
+		Placement:  req.Placement,
+		Status:     store.AdvertiseStatusActive,
+		Weight:     weight,
+		Width:      optionalInt(req.Width),
+		Height:     optionalInt(req.Height),
+		CreatedAt:  int64(createdAt.Unix()),
+		StartsAt:   startsAt,
+		ExpiresAt:  expiresAt,
 	}
+	rec.CalculateAndSetExpired()
 
-	err := query.InsertAds(ctx.Db, rec)
+	// Check the ad against the constraints of its placement
+	fields, err := validateCreative(c, ctx, rec)
 	if err != nil {
-		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostAdsHandlerRequest error")
+		return nil, nil, errors.Wrap(err, "placement error")
+	}
+	if len(fields) > 0 {
+		return nil, validationFailed(fields), nil
 	}
 
-	// Increment metrics for ad creation
-	collector := metrics.GetCollector()
-	if collector != nil {
-		collector.IncrementAdCreated()
+	return rec, nil, nil
+}
+
+// optionalInt returns nil for the zero value of an omitted field
+func optionalInt(value int64) *int64 {
+	if value == 0 {
+		return nil
 	}
+	return &value
+}
 
-	return rec, http.StatusCreated, nil
+// validateCampaign returns the error payload when the campaign is not one of
+// the account
+func validateCampaign(c *gin.Context, ctx *Context, accountID, campaignID string) (map[string]any, error) {
+	records, err := query.SelectCampaigns(c.Request.Context(), ctx.Db, &query.SelectCampaignsArgs{
+		AccountID: accountID,
+		ID:        campaignID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": "campaign_id does not match any campaign",
+		}, nil
+	}
+	return nil, nil
 }
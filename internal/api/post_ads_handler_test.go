@@ -0,0 +1,99 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostAdsHandlerScheduling(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		startsAt := time.Now().Add(2 * time.Hour).Unix()
+
+		testCases := []struct {
+			name              string
+			req               PostAdsHandlerRequest
+			expectedStatus    int
+			expectedLifecycle string
+			check             func(t *testing.T, rec *store.AdvertiseRecord)
+		}{
+			{
+				name:              "live right away",
+				req:               PostAdsHandlerRequest{Title: "now", Ttl: 30},
+				expectedStatus:    http.StatusCreated,
+				expectedLifecycle: store.AdvertiseLifecycleLive,
+				check: func(t *testing.T, rec *store.AdvertiseRecord) {
+					assert.Nil(t, rec.StartsAt)
+				},
+			},
+			{
+				name:              "scheduled with absolute start",
+				req:               PostAdsHandlerRequest{Title: "later", StartsAt: startsAt, Ttl: 30},
+				expectedStatus:    http.StatusCreated,
+				expectedLifecycle: store.AdvertiseLifecycleScheduled,
+				check: func(t *testing.T, rec *store.AdvertiseRecord) {
+					require.NotNil(t, rec.StartsAt)
+					assert.Equal(t, startsAt, *rec.StartsAt)
+					// TTL counts from the moment the ad goes live
+					assert.Equal(t, startsAt+30*60, *rec.ExpiresAt)
+				},
+			},
+			{
+				name:              "scheduled with delay",
+				req:               PostAdsHandlerRequest{Title: "delayed", StartDelay: 15},
+				expectedStatus:    http.StatusCreated,
+				expectedLifecycle: store.AdvertiseLifecycleScheduled,
+				check: func(t *testing.T, rec *store.AdvertiseRecord) {
+					require.NotNil(t, rec.StartsAt)
+					assert.Equal(t, rec.CreatedAt+15*60, *rec.StartsAt)
+				},
+			},
+			{
+				name:           "rejects start and delay together",
+				req:            PostAdsHandlerRequest{Title: "both", StartsAt: startsAt, StartDelay: 15},
+				expectedStatus: http.StatusBadRequest,
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				tc.req.ImageURL = "https://example.com/ad.jpg"
+				tc.req.Placement = "homepage"
+
+				var rec store.AdvertiseRecord
+				status := srv.do(http.MethodPost, "/v1/ads", tc.req, &rec)
+				require.Equal(t, tc.expectedStatus, status)
+				if tc.expectedStatus != http.StatusCreated {
+					return
+				}
+				assert.Equal(t, tc.expectedLifecycle, rec.Lifecycle)
+				if tc.check != nil {
+					tc.check(t, &rec)
+				}
+
+				var fetched store.AdvertiseRecord
+				srv.do(http.MethodGet, "/v1/ads/"+rec.ID, nil, &fetched)
+				assert.Equal(t, tc.expectedLifecycle, fetched.Lifecycle)
+			})
+		}
+
+		var page struct {
+			Ads []*store.AdvertiseRecord `json:"ads"`
+		}
+		srv.do(http.MethodGet, "/v1/ads?lifecycle=live", nil, &page)
+		require.Len(t, page.Ads, 1)
+		assert.Equal(t, "now", page.Ads[0].Title)
+
+		srv.do(http.MethodGet, "/v1/ads?lifecycle=scheduled&sort=title", nil, &page)
+		require.Len(t, page.Ads, 2)
+		assert.Equal(t, "delayed", page.Ads[0].Title)
+		assert.Equal(t, "later", page.Ads[1].Title)
+
+		status := srv.do(http.MethodGet, "/v1/ads?lifecycle=paused", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+}
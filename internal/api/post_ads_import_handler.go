@@ -0,0 +1,364 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/pkg/errors"
+)
+
+// maxImportLineSize bounds a JSONL line, far above any valid ad
+const maxImportLineSize = 1 << 20
+
+// importColumns are the CSV columns every import must have
+var importColumns = []string{"title", "image_url", "placement"}
+
+// Text and integer columns of an imported CSV, the other columns, like the
+// id and created_at of an export, are ignored
+var (
+	importTextColumns = map[string]func(*importRow) *string{
+		"title":       func(row *importRow) *string { return &row.req.Title },
+		"image_url":   func(row *importRow) *string { return &row.req.ImageURL },
+		"click_url":   func(row *importRow) *string { return &row.req.ClickURL },
+		"campaign_id": func(row *importRow) *string { return &row.req.CampaignID },
+		"placement":   func(row *importRow) *string { return &row.req.Placement },
+		"status":      func(row *importRow) *string { return &row.state.Status },
+	}
+	importIntColumns = map[string]func(*importRow) *int64{
+		"ttl":         func(row *importRow) *int64 { return &row.req.Ttl },
+		"weight":      func(row *importRow) *int64 { return &row.req.Weight },
+		"width":       func(row *importRow) *int64 { return &row.req.Width },
+		"height":      func(row *importRow) *int64 { return &row.req.Height },
+		"starts_at":   func(row *importRow) *int64 { return &row.req.StartsAt },
+		"start_delay": func(row *importRow) *int64 { return &row.req.StartDelay },
+		"expires_at":  func(row *importRow) *int64 { return &row.state.ExpiresAt },
+	}
+)
+
+// importRow is one row of an imported file, either the request it describes
+// or the error payload of a row that could not be read
+type importRow struct {
+	line    int
+	req     *PostAdsHandlerRequest
+	state   importState
+	payload map[string]any
+}
+
+// importState holds the columns of an export that a creation request lacks,
+// so an export imports back with its deactivations and absolute expiries
+type importState struct {
+	// Status is active (default), inactive or expired
+	Status string `json:"status"`
+	// ExpiresAt is an absolute unix expiration, instead of a ttl
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+// apply sets the state of the row on the ad built from its request. It
+// returns the errors of a state that does not fit the ad.
+func (state *importState) apply(rec *store.AdvertiseRecord, ttl int64, now time.Time) []FieldError {
+	var fields []FieldError
+	switch {
+	case state.ExpiresAt == 0:
+	case state.ExpiresAt < 0:
+		fields = append(fields, FieldError{
+			Field:   "expires_at",
+			Code:    "invalid_timestamp",
+			Message: "expires_at must be a positive unix timestamp",
+		})
+	case ttl > 0:
+		fields = append(fields, FieldError{
+			Field:   "expires_at",
+			Code:    "exclusive_fields",
+			Message: "Only one of ttl and expires_at can be provided",
+		})
+	case rec.StartsAt != nil && state.ExpiresAt <= *rec.StartsAt:
+		fields = append(fields, FieldError{
+			Field:   "expires_at",
+			Code:    "before_start",
+			Message: "expires_at must be after starts_at",
+		})
+	default:
+		expiresAt := state.ExpiresAt
+		rec.ExpiresAt = &expiresAt
+		rec.CalculateAndSetExpiredAt(now)
+	}
+
+	switch state.Status {
+	case "", store.AdvertiseStatusActive:
+	case store.AdvertiseStatusInactive:
+		rec.Status = store.AdvertiseStatusInactive
+	case store.AdvertiseStatusExpired:
+		// Like the reaper, only an ad past its expiration is expired
+		if !rec.Expired {
+			fields = append(fields, FieldError{
+				Field:   "status",
+				Code:    "not_expired",
+				Message: "status expired requires an expires_at in the past",
+			})
+		}
+		rec.Status = store.AdvertiseStatusExpired
+	default:
+		fields = append(fields, FieldError{
+			Field:   "status",
+			Code:    "invalid_status",
+			Message: "status must be one of active, inactive or expired",
+		})
+	}
+	return fields
+}
+
+// PostAdsImportHandler creates the ads of a CSV or JSON Lines file, every row
+// validated like a POST /v1/ads body. Rows are applied like a batch, atomic
+// unless mode=partial, and dry_run=true only reports what would be created.
+func PostAdsImportHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	format := c.Query("format")
+	if format == "" {
+		switch c.ContentType() {
+		case "text/csv":
+			format = AdsFormatCSV
+		case "application/x-ndjson", "application/jsonl":
+			format = AdsFormatJSONL
+		}
+	}
+
+	mode := c.DefaultQuery("mode", BatchModeAtomic)
+	if mode != BatchModeAtomic && mode != BatchModePartial {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": "mode must be one of atomic or partial",
+		}, http.StatusBadRequest, nil
+	}
+
+	dryRun := false
+	if raw := c.Query("dry_run"); raw != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(raw)
+		if err != nil {
+			return map[string]any{
+				"error":   "Validation failed",
+				"details": "dry_run must be a boolean",
+			}, http.StatusBadRequest, nil
+		}
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": "Unable to read request body",
+		}, http.StatusBadRequest, nil
+	}
+
+	var rows []*importRow
+	var payload map[string]any
+	switch format {
+	case AdsFormatCSV:
+		rows, payload = readImportCSV(body)
+	case AdsFormatJSONL:
+		rows, payload = readImportJSONL(body)
+	default:
+		return map[string]any{
+			"error":   "Invalid format",
+			"details": "format must be one of csv or jsonl, or given by a text/csv or application/x-ndjson Content-Type",
+		}, http.StatusBadRequest, nil
+	}
+	if payload != nil {
+		return payload, http.StatusBadRequest, nil
+	}
+	if len(rows) == 0 || len(rows) > maxBatchSize {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": "The file must have between 1 and " + strconv.Itoa(maxBatchSize) + " rows",
+		}, http.StatusBadRequest, nil
+	}
+
+	resp := &BatchResponse{
+		Mode:    mode,
+		DryRun:  dryRun,
+		Results: make([]*BatchItemResult, 0, len(rows)),
+	}
+
+	// Validate every row before writing any of them
+	createdAt := time.Now()
+	records := make([]*store.AdvertiseRecord, 0, len(rows))
+	for i, row := range rows {
+		var rec *store.AdvertiseRecord
+		var failure *BatchItemResult
+		if row.payload != nil {
+			failure = failedItem(i, "", row.payload)
+		} else {
+			rec, failure, err = validateAdItem(c, ctx, i, row.req, createdAt)
+			if err != nil {
+				return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostAdsImportHandler validation error")
+			}
+			if failure == nil {
+				if fields := row.state.apply(rec, row.req.Ttl, createdAt); len(fields) > 0 {
+					failure = failedItem(i, "", validationFailed(fields))
+				}
+			}
+		}
+		if failure != nil {
+			failure.Line = row.line
+			resp.Results = append(resp.Results, failure)
+			resp.Failed++
+			continue
+		}
+
+		result := &BatchItemResult{
+			Index:  i,
+			Line:   row.line,
+			Status: BatchItemValid,
+		}
+		if !dryRun {
+			result.ID = rec.ID
+			result.Status = BatchItemCreated
+			result.Ad = rec
+		}
+		records = append(records, rec)
+		resp.Results = append(resp.Results, result)
+		resp.Succeeded++
+	}
+
+	// A dry run is a report, whatever the rows hold
+	if dryRun {
+		return resp, http.StatusOK, nil
+	}
+
+	if resp.Failed > 0 && resp.Mode == BatchModeAtomic {
+		skipValidItems(resp)
+		recordBatch(c, "import", resp)
+		return resp, batchStatus(resp, http.StatusCreated), nil
+	}
+
+	if err := insertAdBatch(c, ctx, records); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostAdsImportHandler insert error")
+	}
+	recordBatch(c, "import", resp)
+
+	return resp, batchStatus(resp, http.StatusCreated), nil
+}
+
+// readImportCSV reads the rows of a CSV file with a header line. It returns
+// the error payload of a file that cannot be read at all.
+func readImportCSV(body []byte) ([]*importRow, map[string]any) {
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, map[string]any{
+			"error":   "Invalid CSV",
+			"details": "Unable to read the header line",
+		}
+	}
+	for i, name := range header {
+		// Spreadsheets often start the file with a byte order mark
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		header[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+	for _, column := range importColumns {
+		if !slices.Contains(header, column) {
+			return nil, map[string]any{
+				"error":   "Invalid CSV",
+				"details": "The header is missing the " + column + " column",
+			}
+		}
+	}
+
+	rows := make([]*importRow, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			// A broken quote leaves the rest of the file unreadable
+			return nil, map[string]any{
+				"error":   "Invalid CSV",
+				"details": err.Error(),
+			}
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := &importRow{line: line, req: &PostAdsHandlerRequest{}}
+
+		var fields []FieldError
+		for i, value := range record {
+			if i >= len(header) {
+				break
+			}
+			if field, ok := importTextColumns[header[i]]; ok {
+				*field(row) = csvUnescapeFormula(value)
+				continue
+			}
+			field, ok := importIntColumns[header[i]]
+			if !ok || strings.TrimSpace(value) == "" {
+				continue
+			}
+			parsed, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				fields = append(fields, FieldError{
+					Field:   header[i],
+					Code:    "invalid_integer",
+					Message: header[i] + " must be an integer",
+				})
+				continue
+			}
+			*field(row) = parsed
+		}
+		if len(fields) > 0 {
+			row.payload = validationFailed(fields)
+		}
+
+		rows = append(rows, row)
+	}
+}
+
+// readImportJSONL reads the rows of a JSON Lines file, one creation request
+// per line with the state of an export. Blank lines are skipped.
+func readImportJSONL(body []byte) ([]*importRow, map[string]any) {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+
+	rows := make([]*importRow, 0)
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		row := &importRow{line: line, req: &PostAdsHandlerRequest{}}
+		err := json.Unmarshal(raw, row.req)
+		if err == nil {
+			err = json.Unmarshal(raw, &row.state)
+		}
+		if err != nil {
+			row.payload = map[string]any{
+				"error":   "Invalid JSON",
+				"details": err.Error(),
+			}
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, map[string]any{
+			"error":   "Invalid JSONL",
+			"details": err.Error(),
+		}
+	}
+
+	return rows, nil
+}
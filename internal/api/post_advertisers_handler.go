@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+type PostAdvertisersHandlerRequest struct {
+	Name string `json:"name" binding:"required,max=200"`
+}
+
+func PostAdvertisersHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	var req PostAdvertisersHandlerRequest
+
+	// Bind JSON with validation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": err.Error(),
+		}, http.StatusBadRequest, nil
+	}
+
+	record := &store.AdvertiserRecord{
+		ID:        uuid.NewString(),
+		AccountID: middleware.CurrentAccountID(c),
+		Name:      req.Name,
+		CreatedAt: time.Now().Unix(),
+	}
+
+	err := query.InsertAdvertisers(c.Request.Context(), ctx.Db, record)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostAdvertisersHandler insert error")
+	}
+
+	return record, http.StatusCreated, nil
+}
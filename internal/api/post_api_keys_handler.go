@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+type PostAPIKeysHandlerRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	Role string `json:"role" binding:"required"`
+}
+
+// PostAPIKeysHandlerResponse is the only response carrying the plain key,
+// it cannot be recovered afterwards
+type PostAPIKeysHandlerResponse struct {
+	*store.APIKeyRecord
+	Key string `json:"key"`
+}
+
+func PostAPIKeysHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	var req PostAPIKeysHandlerRequest
+
+	// Bind JSON with validation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": err.Error(),
+		}, http.StatusBadRequest, nil
+	}
+
+	if !middleware.ValidRole(req.Role) {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": "Role must be one of reader, editor or admin",
+		}, http.StatusBadRequest, nil
+	}
+
+	// Keys are always issued for the account of the admin creating them
+	res, err := issueAPIKey(c, ctx, middleware.CurrentAccountID(c), req.Name, req.Role)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostAPIKeysHandler")
+	}
+
+	return res, http.StatusCreated, nil
+}
+
+// issueAPIKey generates and stores a new key of the account
+func issueAPIKey(c *gin.Context, ctx *Context, accountID, name, role string) (*PostAPIKeysHandlerResponse, error) {
+	plain, hash, prefix, err := middleware.GenerateAPIKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "generate error")
+	}
+
+	record := &store.APIKeyRecord{
+		ID:        uuid.NewString(),
+		AccountID: accountID,
+		Name:      name,
+		KeyHash:   hash,
+		KeyPrefix: prefix,
+		Role:      role,
+		CreatedAt: time.Now().Unix(),
+	}
+
+	err = query.InsertAPIKeys(c.Request.Context(), ctx.Db, record)
+	if err != nil {
+		return nil, errors.Wrap(err, "insert error")
+	}
+
+	return &PostAPIKeysHandlerResponse{
+		APIKeyRecord: record,
+		Key:          plain,
+	}, nil
+}
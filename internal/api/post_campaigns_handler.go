@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+type PostCampaignsHandlerRequest struct {
+	AdvertiserID string `json:"advertiser_id" binding:"required"`
+	Name         string `json:"name" binding:"required,max=200"`
+	// Status defaults to active, a paused campaign does not serve its ads
+	Status string `json:"status" binding:"omitempty,oneof=active paused"`
+}
+
+func PostCampaignsHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	var req PostCampaignsHandlerRequest
+
+	// Bind JSON with validation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": err.Error(),
+		}, http.StatusBadRequest, nil
+	}
+
+	accountID := middleware.CurrentAccountID(c)
+
+	advertisers, err := query.SelectAdvertisers(c.Request.Context(), ctx.Db, &query.SelectAdvertisersArgs{
+		AccountID: accountID,
+		ID:        req.AdvertiserID,
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostCampaignsHandler advertiser error")
+	}
+	if len(advertisers) == 0 {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": "advertiser_id does not match any advertiser",
+		}, http.StatusBadRequest, nil
+	}
+
+	status := req.Status
+	if status == "" {
+		status = store.CampaignStatusActive
+	}
+
+	record := &store.CampaignRecord{
+		ID:           uuid.NewString(),
+		AccountID:    accountID,
+		AdvertiserID: req.AdvertiserID,
+		Name:         req.Name,
+		Status:       status,
+		CreatedAt:    time.Now().Unix(),
+	}
+
+	err = query.InsertCampaigns(c.Request.Context(), ctx.Db, record)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostCampaignsHandler insert error")
+	}
+
+	return record, http.StatusCreated, nil
+}
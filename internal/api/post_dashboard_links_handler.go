@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+)
+
+// DashboardLinkResponse is a link opening the dashboard without sending the
+// API key in the URL
+type DashboardLinkResponse struct {
+	URL       string `json:"url"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// PostDashboardLinksHandler issues a short-lived dashboard link for the key
+// of the request, with the role of that key
+func PostDashboardLinksHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	// A link cannot renew itself past its expiry
+	if middleware.AuthenticatedByLink(c) {
+		return map[string]any{
+			"error": "Dashboard links are issued with an API key",
+		}, http.StatusForbidden, nil
+	}
+
+	token, expiresAt := ctx.LinkTokens.Issue(middleware.CurrentAPIKey(c).ID, time.Now())
+
+	return &DashboardLinkResponse{
+		URL:       "/dashboard?" + url.Values{middleware.LinkTokenParam: {token}}.Encode(),
+		ExpiresAt: expiresAt,
+	}, http.StatusCreated, nil
+}
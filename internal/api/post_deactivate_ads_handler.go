@@ -4,12 +4,22 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
 	"github.com/mtavano/admoai-takehome/internal/metrics"
 	"github.com/mtavano/admoai-takehome/internal/store"
 	"github.com/mtavano/admoai-takehome/internal/store/query"
 	"github.com/pkg/errors"
 )
 
+// expiredAdConflict is the 409 payload of a status change of an expired ad,
+// expired is terminal
+func expiredAdConflict() map[string]any {
+	return map[string]any{
+		"error":   "Ad is expired",
+		"details": "The status of an expired ad cannot change, create a new ad instead",
+	}
+}
+
 func PostDeactivateAdsHandler(c *gin.Context, ctx *Context) (any, int, error) {
 	// Get ID from path parameters
 	id := c.Param("id")
@@ -22,24 +32,63 @@ func PostDeactivateAdsHandler(c *gin.Context, ctx *Context) (any, int, error) {
 	// Set status to inactive
 	status := store.AdvertiseStatusInactive
 
+	accountID := middleware.CurrentAccountID(c)
+
 	// Create arguments for UpdateAds
 	args := &query.UpdateAdsArgs{
-		ID:     id,
-		Status: &status,
+		AccountID: accountID,
+		ID:        id,
+		Status:    &status,
+	}
+
+	// Keep the ad as it was for the audit log
+	before, err := query.SelectAds(c.Request.Context(), ctx.Db, &query.SelectAdsArgs{AccountID: accountID, ID: id})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostDeactivateAdsHandler query error")
+	}
+	if len(before) == 0 {
+		return gin.H{
+			"error": "Ad not found",
+		}, http.StatusNotFound, nil
+	}
+
+	switch before[0].Status {
+	case store.AdvertiseStatusExpired:
+		return expiredAdConflict(), http.StatusConflict, nil
+	case store.AdvertiseStatusInactive:
+		// Nothing changes, so nothing is audited nor counted
+		return gin.H{
+			"message": "Ad already inactive",
+			"id":      id,
+			"status":  status,
+		}, http.StatusOK, nil
 	}
 
-	// Update the ad status
-	err := query.UpdateAds(ctx.Db, args)
+	// Update the ad status, an ad found above that is not updated has just
+	// been expired by the reaper
+	err = query.UpdateAds(c.Request.Context(), ctx.Db, args)
+	if errors.Is(err, query.ErrAdNotFound) {
+		return expiredAdConflict(), http.StatusConflict, nil
+	}
 	if err != nil {
 		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostDeactivateAdsHandler update error")
 	}
 
-	// Increment metrics for ad deactivation
-	collector := metrics.GetCollector()
-	if collector != nil {
-		collector.IncrementAdDeactivated()
+	after := *before[0]
+	after.Status = status
+
+	err = recordAdAudit(c, ctx.Db, store.AdAuditActionDeactivate, before[0], &after)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostDeactivateAdsHandler audit error")
 	}
 
+	// Increment metrics for ad deactivation, once committed
+	afterCommit(c, func() {
+		if collector := metrics.GetCollector(); collector != nil {
+			collector.IncrementAdDeactivated(accountID)
+		}
+	})
+
 	return gin.H{
 		"message": "Ad deactivated successfully",
 		"id":      id,
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeactivateAdsStatus(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		var created BatchResponse
+		status := srv.do(http.MethodPost, "/v1/ads:batch", PostAdsBatchHandlerRequest{
+			Ads: []PostAdsHandlerRequest{
+				{Title: "inactive", ImageURL: "https://example.com/1.jpg", Placement: "homepage"},
+				{Title: "expired", ImageURL: "https://example.com/2.jpg", Placement: "homepage"},
+				{Title: "active", ImageURL: "https://example.com/3.jpg", Placement: "homepage"},
+			},
+		}, &created)
+		require.Equal(t, http.StatusCreated, status)
+		inactive, expired, active := created.Results[0].ID, created.Results[1].ID, created.Results[2].ID
+
+		require.Equal(t, http.StatusOK, srv.do(http.MethodPost, "/v1/ads/"+inactive+"/deactivate", nil, nil))
+		// As the reaper does it
+		expiredStatus := store.AdvertiseStatusExpired
+		require.NoError(t, query.UpdateAds(context.Background(), srv.ctx.Db, &query.UpdateAdsArgs{
+			AccountID: store.AccountDefaultID,
+			ID:        expired,
+			Status:    &expiredStatus,
+		}))
+
+		deactivated := deactivatedCount(t)
+		historyLen := func(id string) int {
+			var history AdHistoryResponse
+			require.Equal(t, http.StatusOK, srv.do(http.MethodGet, "/v1/ads/"+id+"/history", nil, &history))
+			return len(history.History)
+		}
+		statusOf := func(id string) string {
+			var rec store.AdvertiseRecord
+			require.Equal(t, http.StatusOK, srv.do(http.MethodGet, "/v1/ads/"+id, nil, &rec))
+			return rec.Status
+		}
+
+		// Deactivating an inactive ad changes nothing
+		var msg MessageResponse
+		require.Equal(t, http.StatusOK, srv.do(http.MethodPost, "/v1/ads/"+inactive+"/deactivate", nil, &msg))
+		assert.Equal(t, "Ad already inactive", msg.Message)
+		assert.Equal(t, 2, historyLen(inactive))
+
+		// Expired is terminal
+		assert.Equal(t, http.StatusConflict, srv.do(http.MethodPost, "/v1/ads/"+expired+"/deactivate", nil, nil))
+		for _, target := range []string{store.AdvertiseStatusActive, store.AdvertiseStatusInactive} {
+			status = srv.do(http.MethodPatch, "/v1/ads/"+expired, map[string]any{"status": target, "ttl": 60}, nil)
+			assert.Equal(t, http.StatusConflict, status, target)
+		}
+		assert.Equal(t, store.AdvertiseStatusExpired, statusOf(expired))
+		// Other fields of an expired ad can still be edited
+		assert.Equal(t, http.StatusOK, srv.do(http.MethodPatch, "/v1/ads/"+expired, map[string]any{"title": "renamed"}, nil))
+
+		var resp BatchResponse
+		status = srv.do(http.MethodPost, "/v1/ads:batchDeactivate", PostAdsBatchDeactivateHandlerRequest{
+			Mode: BatchModePartial,
+			IDs:  []string{inactive, expired, active},
+		}, &resp)
+		require.Equal(t, http.StatusMultiStatus, status)
+		assert.Equal(t, BatchItemUnchanged, resp.Results[0].Status)
+		assert.Equal(t, BatchItemFailed, resp.Results[1].Status)
+		assert.Equal(t, "Ad is expired", resp.Results[1].Error)
+		assert.Equal(t, BatchItemDeactivated, resp.Results[2].Status)
+		assert.Equal(t, 2, resp.Succeeded)
+		assert.Equal(t, 1, resp.Failed)
+
+		assert.Equal(t, store.AdvertiseStatusExpired, statusOf(expired))
+		assert.Equal(t, store.AdvertiseStatusInactive, statusOf(active))
+		assert.Equal(t, 2, historyLen(inactive))
+		// Only the active ad was counted
+		assert.Equal(t, deactivated+1, deactivatedCount(t))
+	})
+}
+
+// deactivatedCount returns the ads deactivated by the default account
+func deactivatedCount(t *testing.T) float64 {
+	t.Helper()
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != "admoai_ads_deactivated_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "account" && label.GetValue() == store.AccountDefaultID {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+type PostPlacementsHandlerRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	// Sizes lists the allowed creative sizes as "<width>x<height>"
+	Sizes []string `json:"sizes"`
+	// MaxTitleLength caps the ad titles, 0 is unlimited
+	MaxTitleLength int64 `json:"max_title_length" binding:"omitempty,min=0"`
+	// ImageFormats lists the allowed image file extensions
+	ImageFormats []string `json:"image_formats" binding:"omitempty,dive,oneof=jpg jpeg png gif webp svg"`
+}
+
+func PostPlacementsHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	var req PostPlacementsHandlerRequest
+
+	// Bind JSON with validation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return map[string]any{
+			"error":   "Validation failed",
+			"details": err.Error(),
+		}, http.StatusBadRequest, nil
+	}
+
+	sizes, fields := normalizeSizes(req.Sizes)
+	if len(fields) > 0 {
+		return validationFailed(fields), http.StatusBadRequest, nil
+	}
+
+	existing, err := query.SelectPlacements(c.Request.Context(), ctx.Db, &query.SelectPlacementsArgs{Name: req.Name})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostPlacementsHandler query error")
+	}
+	if len(existing) > 0 {
+		return map[string]any{
+			"error": "Placement already exists",
+		}, http.StatusConflict, nil
+	}
+
+	record := &store.PlacementRecord{
+		Name:           req.Name,
+		Sizes:          sizes,
+		MaxTitleLength: req.MaxTitleLength,
+		ImageFormats:   normalizeImageFormats(req.ImageFormats),
+		CreatedAt:      time.Now().Unix(),
+	}
+
+	err = query.InsertPlacements(c.Request.Context(), ctx.Db, record)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: PostPlacementsHandler insert error")
+	}
+
+	return record, http.StatusCreated, nil
+}
+
+// normalizeSizes validates the sizes of a placement request
+func normalizeSizes(sizes []string) (store.StringList, []FieldError) {
+	normalized := store.StringList{}
+	var fields []FieldError
+	for _, size := range sizes {
+		width, height, ok := parseSize(size)
+		if !ok {
+			fields = append(fields, FieldError{
+				Field:   "sizes",
+				Code:    "invalid_size",
+				Message: "size " + size + " must be <width>x<height> with positive dimensions",
+			})
+			continue
+		}
+		if size = formatSize(width, height); !normalized.Contains(size) {
+			normalized = append(normalized, size)
+		}
+	}
+	return normalized, fields
+}
+
+// normalizeImageFormats folds the aliases of the formats of a placement request
+func normalizeImageFormats(formats []string) store.StringList {
+	normalized := store.StringList{}
+	for _, format := range formats {
+		if format = normalizeImageFormat(format); !normalized.Contains(format) {
+			normalized = append(normalized, format)
+		}
+	}
+	return normalized
+}
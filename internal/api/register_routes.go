@@ -1,25 +1,67 @@
 package api
 
 import (
+	"context"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/metrics"
+	"github.com/mtavano/admoai-takehome/internal/serving"
 	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/mtavano/admoai-takehome/internal/tracking"
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type Context struct {
-	Db store.Database
+	Db       store.Database
+	Serving  *serving.Index
+	Tracking *tracking.Recorder
+	// AllowedOrigins restricts the CORS origins, empty allows any origin
+	AllowedOrigins []string
+	// IdempotencyTTL is how long Idempotency-Key responses are replayed,
+	// idempotency.DefaultTTL when zero
+	IdempotencyTTL time.Duration
+	// AdCountsMaxAge is how long the ad counts of /metrics are reused,
+	// metrics.DefaultAdCountsMaxAge when zero
+	AdCountsMaxAge time.Duration
+	// OperatorAccountID is the account whose admins manage the resources
+	// shared by every account, like placements, store.AccountDefaultID when
+	// empty
+	OperatorAccountID string
+	// LinkTokens signs the dashboard links, one with a random secret and
+	// middleware.DefaultLinkTTL when nil
+	LinkTokens *middleware.LinkTokens
+	// Logger writes the access log and is carried in the context of the
+	// requests, slog.Default() when nil
+	Logger *slog.Logger
 }
 
 func RegisterRoutes(ctx *Context, engine *gin.Engine) {
 	corsMiddleware := middleware.NewCors()
 	requestIDMiddleware := middleware.NewRequestID()
+	accessLogMiddleware := middleware.NewAccessLog(ctx.Logger)
+	if ctx.LinkTokens == nil {
+		ctx.LinkTokens = middleware.NewLinkTokens(nil, 0)
+	}
+	auth := middleware.NewAuth(ctx.Db, ctx.LinkTokens)
 
-	// Setup middlewares
-	corsMiddleware.Setup(engine, nil)
+	// Setup middlewares, the access log covers the requests answered by the
+	// ones after it, like CORS preflights
 	requestIDMiddleware.Setup(engine)
+	accessLogMiddleware.Setup(engine)
+	corsMiddleware.Setup(engine, &middleware.CorsConfig{
+		AllowOrigins: ctx.AllowedOrigins,
+	})
+
+	// Ad count gauges are read from the database when /metrics is scraped
+	if collector := metrics.GetCollector(); collector != nil {
+		collector.WatchAdCounts(readAdCounts(ctx), ctx.AdCountsMaxAge)
+	}
 
 	engine.GET("/health", HandleFunc(func(c *gin.Context, ctx *Context) (any, int, error) {
 		return map[string]any{
@@ -28,34 +70,108 @@ func RegisterRoutes(ctx *Context, engine *gin.Engine) {
 		}, http.StatusOK, nil
 	}, ctx))
 
-	// Metrics endpoint for Prometheus
-	engine.GET("/metrics", HandleFunc(MetricsHandler, ctx))
+	// Metrics endpoint for Prometheus, an API key adds the series of its account
+	engine.GET("/metrics", auth.Optional(), HandleFunc(MetricsHandler, ctx))
+
+	// Dashboard HTML endpoint, opened from the links of /v1/dashboard/links
+	engine.GET("/dashboard", auth.RequireLinkPage(store.APIKeyRoleReader), HandleFunc(AdsDashboardHandler, ctx))
 
-	// Dashboard HTML endpoint
-	engine.GET("/dashboard", HandleFunc(AdsDashboardHandler, ctx))
+	// OpenAPI document of the routes below and its explorer, public like /health
+	engine.GET("/openapi.json", HandleFunc(GetOpenAPIHandler, ctx))
+	engine.GET("/docs", HandleFunc(GetOpenAPIExplorerHandler, ctx))
 
 	v1Router := engine.Group("/v1")
 
-	v1Router.POST("/ads", HandleFunc(PostAdsHandler, ctx))
-	v1Router.GET("/ads/:id", HandleFunc(GetAdsByIDHandler, ctx))
-	v1Router.GET("/ads", HandleFunc(GetAdsByFiltersHandler, ctx))
-	v1Router.POST("/ads/:id/deactivate", HandleFunc(PostDeactivateAdsHandler, ctx))
+	reader := auth.Require(store.APIKeyRoleReader)
+	editor := auth.Require(store.APIKeyRoleEditor)
+	admin := auth.Require(store.APIKeyRoleAdmin)
+	// The routes called by the dashboard also take its link tokens
+	dashboardEditor := auth.RequireLink(store.APIKeyRoleEditor)
+	operatorAccountID := ctx.OperatorAccountID
+	if operatorAccountID == "" {
+		operatorAccountID = store.AccountDefaultID
+	}
+	operator := auth.RequireAccount(store.APIKeyRoleAdmin, operatorAccountID)
+
+	// Mutations run in a transaction of their own, see Transactional
+	v1Router.POST("/ads", dashboardEditor, HandleFunc(Transactional(Idempotent(PostAdsHandler)), ctx))
+	// Custom methods of the collection, /ads:batch and /ads:batchDeactivate
+	v1Router.POST("/ads:action", editor, HandleFunc(Transactional(PostAdsActionHandler), ctx))
+	v1Router.POST("/ads/import", editor, HandleFunc(Transactional(Idempotent(PostAdsImportHandler)), ctx))
+	v1Router.GET("/ads/export", reader, HandleFunc(GetAdsExportHandler, ctx))
+	v1Router.GET("/ads/:id", reader, HandleFunc(GetAdsByIDHandler, ctx))
+	v1Router.GET("/ads", reader, HandleFunc(GetAdsByFiltersHandler, ctx))
+	v1Router.PATCH("/ads/:id", editor, HandleFunc(Transactional(PatchAdsHandler), ctx))
+	v1Router.POST("/ads/:id/deactivate", dashboardEditor, HandleFunc(Transactional(PostDeactivateAdsHandler), ctx))
+	v1Router.GET("/ads/:id/stats", reader, HandleFunc(GetAdsStatsHandler, ctx))
+	v1Router.GET("/ads/:id/history", reader, HandleFunc(GetAdsHistoryHandler, ctx))
+
+	// Placements are shared by every account, only the admins of the
+	// operator account change them
+	v1Router.POST("/placements", operator, HandleFunc(Transactional(PostPlacementsHandler), ctx))
+	v1Router.GET("/placements", reader, HandleFunc(GetPlacementsHandler, ctx))
+	v1Router.GET("/placements/:name", reader, HandleFunc(GetPlacementsByNameHandler, ctx))
+	v1Router.PATCH("/placements/:name", operator, HandleFunc(Transactional(PatchPlacementsHandler), ctx))
+	v1Router.DELETE("/placements/:name", operator, HandleFunc(Transactional(DeletePlacementsHandler), ctx))
+
+	v1Router.POST("/advertisers", editor, HandleFunc(Transactional(PostAdvertisersHandler), ctx))
+	v1Router.GET("/advertisers", reader, HandleFunc(GetAdvertisersHandler, ctx))
+	v1Router.GET("/advertisers/:id", reader, HandleFunc(GetAdvertisersByIDHandler, ctx))
+	v1Router.PATCH("/advertisers/:id", editor, HandleFunc(Transactional(PatchAdvertisersHandler), ctx))
+	v1Router.DELETE("/advertisers/:id", editor, HandleFunc(Transactional(DeleteAdvertisersHandler), ctx))
+
+	v1Router.POST("/campaigns", editor, HandleFunc(Transactional(PostCampaignsHandler), ctx))
+	v1Router.GET("/campaigns", reader, HandleFunc(GetCampaignsHandler, ctx))
+	v1Router.GET("/campaigns/:id", reader, HandleFunc(GetCampaignsByIDHandler, ctx))
+	v1Router.PATCH("/campaigns/:id", editor, HandleFunc(Transactional(PatchCampaignsHandler), ctx))
+	v1Router.DELETE("/campaigns/:id", editor, HandleFunc(Transactional(DeleteCampaignsHandler), ctx))
+
+	v1Router.POST("/dashboard/links", reader, HandleFunc(PostDashboardLinksHandler, ctx))
+
+	// Accounts are provisioned by the operator, each with its first admin key
+	v1Router.POST("/accounts", operator, HandleFunc(Transactional(PostAccountsHandler), ctx))
+
+	v1Router.POST("/api-keys", admin, HandleFunc(Transactional(PostAPIKeysHandler), ctx))
+	v1Router.GET("/api-keys", admin, HandleFunc(GetAPIKeysHandler, ctx))
+	v1Router.DELETE("/api-keys/:id", admin, HandleFunc(Transactional(DeleteAPIKeysHandler), ctx))
+
+	// Delivery endpoints are called from the pages showing the ads, so
+	// they stay public like /health and /metrics
+	v1Router.GET("/serve/:placement", HandleFunc(GetServeAdHandler, ctx))
+	v1Router.GET("/track/impression", HandleFunc(TrackImpressionHandler, ctx))
+	v1Router.POST("/track/impression", HandleFunc(TrackImpressionHandler, ctx))
+	v1Router.GET("/track/click", HandleFunc(TrackClickHandler, ctx))
 }
 
 // MetricsHandler handles the /metrics endpoint
 func MetricsHandler(c *gin.Context, ctx *Context) (any, int, error) {
-	// Update current ad counts from database
-	updateAdCounts(ctx)
-	
-	// Use Prometheus HTTP handler
-	promhttp.Handler().ServeHTTP(c.Writer, c.Request)
-	
+	// Use Prometheus HTTP handler, exposing only the series of the caller account
+	gatherer := metrics.TenantGatherer(middleware.CurrentAccountID(c))
+	promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}).ServeHTTP(c.Writer, c.Request)
+
 	// Return nil since promhttp.Handler handles the response
 	return nil, http.StatusOK, nil
 }
 
-// updateAdCounts fetches current ad counts from database
-func updateAdCounts(ctx *Context) {
-	// This will be implemented when we add the metrics package
-	// For now, we'll leave it empty since the metrics are handled by the Prometheus collector
+// readAdCounts returns the function reading the ad counts by account and
+// placement for the gauges of /metrics
+func readAdCounts(ctx *Context) metrics.AdCountsFunc {
+	return func(readCtx context.Context) ([]metrics.AdCounts, error) {
+		records, err := query.SelectAdCounts(readCtx, ctx.Db, time.Now().Unix())
+		if err != nil {
+			return nil, errors.Wrap(err, "api: readAdCounts error")
+		}
+
+		counts := make([]metrics.AdCounts, 0, len(records))
+		for _, rec := range records {
+			counts = append(counts, metrics.AdCounts{
+				Account:   rec.AccountID,
+				Placement: rec.Placement,
+				Active:    rec.Active,
+				Inactive:  rec.Inactive,
+				Expired:   rec.Expired,
+			})
+		}
+		return counts, nil
+	}
 }
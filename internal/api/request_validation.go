@@ -0,0 +1,151 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/openapi"
+)
+
+// ValidateRequest checks the parameters and the JSON body of a request
+// against its operation in the OpenAPI document before the handler runs.
+// Requests that do not match respond 400 with a FieldError per violation.
+// Routes missing from the document, and the items of batches, are left to
+// the handlers, which check them with validateBodyOf.
+func ValidateRequest(fn handler) handler {
+	return func(c *gin.Context, ctx *Context) (any, int, error) {
+		doc := OpenAPIDocument()
+		op := requestOperation(doc, c)
+		if op == nil {
+			return fn(c, ctx)
+		}
+
+		var violations []openapi.Violation
+		for _, param := range op.Parameters {
+			var raw string
+			var present bool
+			switch param.In {
+			case openapi.InPath:
+				raw = c.Param(param.Name)
+				present = true
+			case openapi.InQuery:
+				raw, present = c.GetQuery(param.Name)
+			case openapi.InHeader:
+				raw = c.GetHeader(param.Name)
+				present = raw != ""
+			}
+			violations = append(violations, doc.ValidateParam(param, raw, present)...)
+		}
+
+		if op.RequestBody != nil {
+			if media, ok := op.RequestBody.Content["application/json"]; ok {
+				bodyViolations, err := validateJSONBody(doc, c, op.RequestBody.Required, media.Schema)
+				if err != nil {
+					return map[string]any{
+						"error":   "Validation failed",
+						"details": "Unable to read request body",
+					}, http.StatusBadRequest, nil
+				}
+				violations = append(violations, bodyViolations...)
+			}
+		}
+
+		if len(violations) > 0 {
+			return validationFailed(fieldErrors(violations)), http.StatusBadRequest, nil
+		}
+
+		return fn(c, ctx)
+	}
+}
+
+// validateBodyOf checks a body against the JSON request schema of the
+// operation serving method and path, like ValidateRequest checks the requests.
+// The handlers check with it the items of batches and the rows of imports.
+func validateBodyOf(method, path string, body any) ([]FieldError, error) {
+	doc := OpenAPIDocument()
+	op := doc.Operation(method, path)
+	if op == nil || op.RequestBody == nil {
+		return nil, nil
+	}
+	media, ok := op.RequestBody.Content["application/json"]
+	if !ok {
+		return nil, nil
+	}
+
+	// Decoded like a request body, numbers are kept as written
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return fieldErrors(doc.ValidateValue("", media.Schema, value)), nil
+}
+
+// fieldErrors converts the violations of the document to the fields of a 400
+func fieldErrors(violations []openapi.Violation) []FieldError {
+	if len(violations) == 0 {
+		return nil
+	}
+	fields := make([]FieldError, 0, len(violations))
+	for _, violation := range violations {
+		fields = append(fields, FieldError{
+			Field:   violation.Field,
+			Code:    violation.Code,
+			Message: violation.Message,
+		})
+	}
+	return fields
+}
+
+// requestOperation returns the operation of the OpenAPI document serving a
+// request. The custom methods of a collection share a gin route and are
+// found by their path.
+func requestOperation(doc *openapi.Document, c *gin.Context) *openapi.Operation {
+	route := c.FullPath()
+	if route == "" {
+		return nil
+	}
+	if op := doc.Operation(c.Request.Method, openapi.PathFromRoute(route)); op != nil {
+		return op
+	}
+	return doc.Operation(c.Request.Method, c.Request.URL.Path)
+}
+
+// validateJSONBody checks the JSON body of a request against its schema and
+// puts the body back for the handler
+func validateJSONBody(doc *openapi.Document, c *gin.Context, required bool, schema *openapi.Schema) ([]openapi.Violation, error) {
+	if c.Request.Body == nil {
+		c.Request.Body = http.NoBody
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if required {
+			return []openapi.Violation{{Field: "body", Code: openapi.CodeRequired, Message: "body is required"}}, nil
+		}
+		return nil, nil
+	}
+
+	// Numbers are kept as written to tell integers apart
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return []openapi.Violation{{Field: "body", Code: "invalid_json", Message: "body must be valid JSON"}}, nil
+	}
+
+	return doc.ValidateValue("", schema, value), nil
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mtavano/admoai-takehome/internal/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRequest(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		testCases := []struct {
+			name   string
+			method string
+			path   string
+			body   any
+			// fields are the expected "field:code" of the violations
+			fields []string
+		}{
+			{
+				name:   "unknown status filter",
+				method: http.MethodGet, path: "/v1/ads?status=bogus",
+				fields: []string{"status:" + openapi.CodeInvalidValue},
+			},
+			{
+				name:   "non numeric limit",
+				method: http.MethodGet, path: "/v1/ads?limit=ten",
+				fields: []string{"limit:" + openapi.CodeInvalidType},
+			},
+			{
+				name:   "limit out of range",
+				method: http.MethodGet, path: "/v1/ads?limit=0&lifecycle=gone",
+				fields: []string{"lifecycle:" + openapi.CodeInvalidValue, "limit:" + openapi.CodeTooSmall},
+			},
+			{
+				name:   "stats range",
+				method: http.MethodGet, path: "/v1/ads/some-id/stats?bucket=week&from=-1",
+				fields: []string{"from:" + openapi.CodeTooSmall, "bucket:" + openapi.CodeInvalidValue},
+			},
+			{
+				name:   "invalid ad",
+				method: http.MethodPost, path: "/v1/ads",
+				body: map[string]any{
+					"title":     "",
+					"image_url": "not a url",
+					"weight":    5000,
+					"ttl":       "soon",
+				},
+				fields: []string{
+					"placement:" + openapi.CodeRequired,
+					"image_url:" + openapi.CodeInvalidFormat,
+					"title:" + openapi.CodeTooShort,
+					"ttl:" + openapi.CodeInvalidType,
+					"weight:" + openapi.CodeTooLarge,
+				},
+			},
+			{
+				name:   "missing body",
+				method: http.MethodPost, path: "/v1/ads",
+				fields: []string{"body:" + openapi.CodeRequired},
+			},
+			{
+				name:   "body of the wrong type",
+				method: http.MethodPost, path: "/v1/ads",
+				body:   []byte(`["title"]`),
+				fields: []string{":" + openapi.CodeInvalidType},
+			},
+			{
+				name:   "nested fields of a custom method",
+				method: http.MethodPost, path: "/v1/ads:batchDeactivate",
+				body:   map[string]any{"mode": "eventually", "ids": []any{"", 3}},
+				fields: []string{"ids[0]:" + openapi.CodeTooShort, "ids[1]:" + openapi.CodeInvalidType, "mode:" + openapi.CodeInvalidValue},
+			},
+			{
+				name:   "enum items",
+				method: http.MethodPatch, path: "/v1/placements/homepage",
+				body:   map[string]any{"image_formats": []string{"png", "bmp"}},
+				fields: []string{"image_formats[1]:" + openapi.CodeInvalidValue},
+			},
+			{
+				name:   "public route",
+				method: http.MethodGet, path: "/v1/track/impression",
+				fields: []string{"ad_id:" + openapi.CodeRequired},
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				var res ErrorResponse
+				status := srv.do(tc.method, tc.path, tc.body, &res)
+				require.Equal(t, http.StatusBadRequest, status)
+				assert.Equal(t, "Validation failed", res.Error)
+
+				fields := make([]string, 0, len(res.Fields))
+				for _, field := range res.Fields {
+					fields = append(fields, field.Field+":"+field.Code)
+					assert.NotEmpty(t, field.Message)
+				}
+				assert.ElementsMatch(t, tc.fields, fields)
+				assert.Equal(t, len(tc.fields), len(strings.Split(res.Details, "; ")))
+			})
+		}
+
+		// Zero values of omitempty fields stand for omitted ones
+		status := srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title:     "Defaults",
+			ImageURL:  "https://example.com/ad.jpg",
+			Placement: "homepage",
+		}, nil)
+		assert.Equal(t, http.StatusCreated, status)
+
+		// Batch items are reported one by one by the handler
+		var batch BatchResponse
+		status = srv.do(http.MethodPost, "/v1/ads:batch", PostAdsBatchHandlerRequest{
+			Mode: BatchModePartial,
+			Ads: []PostAdsHandlerRequest{
+				{Title: "ok", ImageURL: "https://example.com/ad.jpg", Placement: "homepage"},
+				{Title: "bad", ImageURL: "not a url", Placement: "homepage"},
+			},
+		}, &batch)
+		require.Equal(t, http.StatusMultiStatus, status)
+		assert.Equal(t, BatchItemFailed, batch.Results[1].Status)
+
+		// Authentication comes first
+		srv.apiKey = ""
+		status = srv.do(http.MethodGet, "/v1/ads?status=bogus", nil, nil)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+}
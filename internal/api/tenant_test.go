@@ -0,0 +1,125 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountIsolation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		ownerKey := srv.apiKey
+		otherKey := srv.insertAPIKey("acme-admin", "acme", store.APIKeyRoleAdmin)
+
+		var created store.AdvertiseRecord
+		status := srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+			Title:     "Summer sale",
+			ImageURL:  "https://example.com/summer.jpg",
+			Placement: "homepage",
+		}, &created)
+		require.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, store.AccountDefaultID, created.AccountID)
+
+		// The other account cannot read nor change the ad
+		srv.apiKey = otherKey
+		testCases := []struct {
+			name   string
+			method string
+			path   string
+			body   any
+		}{
+			{"get", http.MethodGet, "/v1/ads/" + created.ID, nil},
+			{"update", http.MethodPatch, "/v1/ads/" + created.ID, map[string]any{"title": "Hijacked"}},
+			{"deactivate", http.MethodPost, "/v1/ads/" + created.ID + "/deactivate", nil},
+			{"stats", http.MethodGet, "/v1/ads/" + created.ID + "/stats", nil},
+			{"revoke key", http.MethodDelete, "/v1/api-keys/test-admin", nil},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				assert.Equal(t, http.StatusNotFound, srv.do(tc.method, tc.path, tc.body, nil))
+			})
+		}
+
+		var listed struct {
+			Ads   []*store.AdvertiseRecord `json:"ads"`
+			Total int64                    `json:"total"`
+		}
+		status = srv.do(http.MethodGet, "/v1/ads", nil, &listed)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Empty(t, listed.Ads)
+		assert.Zero(t, listed.Total)
+
+		var keys struct {
+			APIKeys []*store.APIKeyRecord `json:"api_keys"`
+		}
+		status = srv.do(http.MethodGet, "/v1/api-keys", nil, &keys)
+		assert.Equal(t, http.StatusOK, status)
+		require.Len(t, keys.APIKeys, 1)
+		assert.Equal(t, "acme-admin", keys.APIKeys[0].ID)
+
+		// The owner still sees the ad untouched
+		srv.apiKey = ownerKey
+		var fetched store.AdvertiseRecord
+		status = srv.do(http.MethodGet, "/v1/ads/"+created.ID, nil, &fetched)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "Summer sale", fetched.Title)
+		assert.Equal(t, store.AdvertiseStatusActive, fetched.Status)
+	})
+}
+
+func TestAccountProvisioning(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		operatorKey := srv.apiKey
+
+		// Only the operator provisions accounts
+		srv.apiKey = srv.insertAPIKey("acme-admin", "acme", store.APIKeyRoleAdmin)
+		assert.Equal(t, http.StatusForbidden, srv.do(http.MethodPost, "/v1/accounts", PostAccountsHandlerRequest{Name: "Globex"}, nil))
+		srv.apiKey = srv.insertAPIKey("editor", store.AccountDefaultID, store.APIKeyRoleEditor)
+		assert.Equal(t, http.StatusForbidden, srv.do(http.MethodPost, "/v1/accounts", PostAccountsHandlerRequest{Name: "Globex"}, nil))
+
+		srv.apiKey = operatorKey
+		assert.Equal(t, http.StatusBadRequest, srv.do(http.MethodPost, "/v1/accounts", map[string]any{}, nil))
+
+		accounts := make([]PostAccountsHandlerResponse, 2)
+		for i, name := range []string{"Globex", "Initech"} {
+			require.Equal(t, http.StatusCreated, srv.do(http.MethodPost, "/v1/accounts", PostAccountsHandlerRequest{Name: name}, &accounts[i]))
+			assert.Equal(t, name, accounts[i].Account.Name)
+			assert.Equal(t, accounts[i].Account.ID, accounts[i].APIKey.AccountID)
+			assert.Equal(t, store.APIKeyRoleAdmin, accounts[i].APIKey.Role)
+			require.NotEmpty(t, accounts[i].APIKey.Key)
+		}
+		assert.NotEqual(t, accounts[0].Account.ID, accounts[1].Account.ID)
+
+		// Each admin creates ads in its own account and cannot see the other's
+		ads := make([]store.AdvertiseRecord, 2)
+		for i, account := range accounts {
+			srv.apiKey = account.APIKey.Key
+			require.Equal(t, http.StatusCreated, srv.do(http.MethodPost, "/v1/ads", PostAdsHandlerRequest{
+				Title:     account.Account.Name + " sale",
+				ImageURL:  "https://example.com/sale.jpg",
+				Placement: "homepage",
+			}, &ads[i]))
+			assert.Equal(t, account.Account.ID, ads[i].AccountID)
+		}
+
+		for i, account := range accounts {
+			srv.apiKey = account.APIKey.Key
+			other := ads[1-i]
+			assert.Equal(t, http.StatusNotFound, srv.do(http.MethodGet, "/v1/ads/"+other.ID, nil, nil))
+
+			var listed struct {
+				Ads []*store.AdvertiseRecord `json:"ads"`
+			}
+			require.Equal(t, http.StatusOK, srv.do(http.MethodGet, "/v1/ads", nil, &listed))
+			require.Len(t, listed.Ads, 1)
+			assert.Equal(t, ads[i].ID, listed.Ads[0].ID)
+		}
+
+		// The new admins are not operators
+		srv.apiKey = accounts[0].APIKey.Key
+		assert.Equal(t, http.StatusForbidden, srv.do(http.MethodPost, "/v1/accounts", PostAccountsHandlerRequest{Name: "Umbrella"}, nil))
+	})
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleFuncErrorStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "query timeout",
+			err:            errors.Wrap(context.DeadlineExceeded, "api: GetAdsByIDHandler query error"),
+			expectedStatus: http.StatusGatewayTimeout,
+		},
+		{
+			name:           "cancelled request",
+			err:            errors.Wrap(context.Canceled, "api: PostAdsHandler begin error"),
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "other errors keep their status",
+			err:            errors.New("boom"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+			HandleFunc(func(c *gin.Context, ctx *Context) (any, int, error) {
+				return nil, http.StatusInternalServerError, tc.err
+			}, &Context{})(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		db, ok := srv.ctx.Db.(*store.SqlStore)
+		require.True(t, ok)
+
+		status := srv.do(http.MethodGet, "/v1/ads", nil, nil)
+		require.Equal(t, http.StatusOK, status)
+
+		// No statement can complete within a nanosecond
+		db.QueryTimeout = time.Nanosecond
+		status = srv.do(http.MethodGet, "/v1/ads", nil, nil)
+		assert.Equal(t, http.StatusGatewayTimeout, status)
+	})
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/logging"
+	"github.com/mtavano/admoai-takehome/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startServerSpan starts the span of a request, continuing the trace of its
+// traceparent header, and carries it in the request context so the spans of
+// the queries are its children. The logger of the request gets its trace_id.
+func startServerSpan(c *gin.Context) trace.Span {
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+
+	ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+	ctx, span := tracing.Tracer().Start(ctx, c.Request.Method+" "+route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(c.Request.Method),
+			semconv.HTTPRoute(route),
+			semconv.URLPath(c.Request.URL.Path),
+			semconv.ClientAddress(c.ClientIP()),
+		),
+	)
+
+	if spanContext := span.SpanContext(); spanContext.IsValid() {
+		logger := logging.FromContext(ctx).With(slog.String("trace_id", spanContext.TraceID().String()))
+		ctx = logging.WithLogger(ctx, logger)
+	}
+	c.Request = c.Request.WithContext(ctx)
+
+	return span
+}
+
+// endServerSpan records the response of a request and ends its span, server
+// errors mark the span as failed
+func endServerSpan(c *gin.Context, span trace.Span) {
+	status := c.Writer.Status()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+	span.End()
+}
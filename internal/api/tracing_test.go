@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mtavano/admoai-takehome/internal/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	exporter, restore := tracing.SetupInMemory()
+	t.Cleanup(restore)
+
+	forEachBackend(t, func(t *testing.T, srv *dbTestServer) {
+		exporter.Reset()
+
+		// Continues the trace of the caller
+		status, _ := srv.doWithHeaders(http.MethodGet, "/v1/ads?placement=homepage", map[string]string{
+			"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		}, nil, nil)
+		require.Equal(t, http.StatusOK, status)
+
+		spans := exporter.GetSpans()
+		server := findSpan(t, spans, "GET /v1/ads")
+		assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+		assert.Contains(t, server.Attributes, attribute.String("http.route", "/v1/ads"))
+		assert.Contains(t, server.Attributes, attribute.Int("http.response.status_code", http.StatusOK))
+
+		// Queries are children of the request with their statement
+		selectAds := findSpan(t, spans, "query.SelectAds")
+		assert.Equal(t, trace.SpanKindClient, selectAds.SpanKind)
+		assert.Equal(t, server.SpanContext.SpanID(), selectAds.Parent.SpanID())
+		assert.Equal(t, server.SpanContext.TraceID(), selectAds.SpanContext.TraceID())
+		var statement string
+		for _, attr := range selectAds.Attributes {
+			if attr.Key == "db.query.text" {
+				statement = attr.Value.AsString()
+			}
+		}
+		assert.Contains(t, statement, "FROM ads")
+
+		// Without traceparent the request starts its own trace
+		exporter.Reset()
+		status = srv.do(http.MethodGet, "/v1/ads/missing", nil, nil)
+		require.Equal(t, http.StatusNotFound, status)
+		server = findSpan(t, exporter.GetSpans(), "GET /v1/ads/:id")
+		assert.False(t, server.Parent.IsValid())
+		assert.Contains(t, server.Attributes, attribute.Int("http.response.status_code", http.StatusNotFound))
+	})
+}
+
+// findSpan returns the span with a name, failing the test when missing
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	require.Failf(t, "span not found", "no span named %q", name)
+	return tracetest.SpanStub{}
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/metrics"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+func TrackClickHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	rec, payload, statusCode, err := trackAdEvent(c, ctx, store.AdEventTypeClick)
+	if rec == nil {
+		return payload, statusCode, err
+	}
+
+	// Increment metrics for the click
+	collector := metrics.GetCollector()
+	if collector != nil {
+		collector.RecordClick(rec.AccountID, rec.Placement)
+	}
+
+	// Ads without landing page only record the click
+	if rec.ClickURL == nil || *rec.ClickURL == "" {
+		return nil, http.StatusNoContent, nil
+	}
+
+	// Redirect to the landing page of the ad
+	c.Redirect(http.StatusFound, *rec.ClickURL)
+
+	return nil, http.StatusFound, nil
+}
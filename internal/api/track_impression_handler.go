@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mtavano/admoai-takehome/internal/metrics"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+func TrackImpressionHandler(c *gin.Context, ctx *Context) (any, int, error) {
+	rec, payload, statusCode, err := trackAdEvent(c, ctx, store.AdEventTypeImpression)
+	if rec == nil {
+		return payload, statusCode, err
+	}
+
+	// Increment metrics for the impression
+	collector := metrics.GetCollector()
+	if collector != nil {
+		collector.RecordImpression(rec.AccountID, rec.Placement)
+	}
+
+	return nil, http.StatusNoContent, nil
+}
+
+// trackAdEvent resolves the ad of the ad_id query parameter and queues an
+// event of the given type for it. When the returned record is nil the
+// remaining values are the response to send.
+func trackAdEvent(c *gin.Context, ctx *Context, eventType string) (*store.AdvertiseRecord, any, int, error) {
+	// Get ad ID from query parameters
+	adID := c.Query("ad_id")
+	if adID == "" {
+		return nil, gin.H{
+			"error": "ad_id parameter is required",
+		}, http.StatusBadRequest, nil
+	}
+
+	if ctx.Tracking == nil {
+		return nil, gin.H{
+			"error": "Tracking is not available",
+		}, http.StatusServiceUnavailable, nil
+	}
+
+	// Served ads are in the serving index, only fall back to the database
+	// for ads that are not indexed (yet). Tracking is public, the ad ID
+	// resolves the account the event belongs to.
+	var rec *store.AdvertiseRecord
+	if ctx.Serving != nil {
+		rec = ctx.Serving.Lookup(adID)
+	}
+	if rec == nil {
+		records, err := query.SelectAds(c.Request.Context(), ctx.Db, &query.SelectAdsArgs{AllAccounts: true, ID: adID})
+		if err != nil {
+			return nil, nil, http.StatusInternalServerError, errors.Wrap(err, "api: trackAdEvent query error")
+		}
+		if len(records) == 0 {
+			return nil, gin.H{
+				"error": "Ad not found",
+			}, http.StatusNotFound, nil
+		}
+		rec = records[0]
+	}
+
+	ctx.Tracking.Record(&store.AdEventRecord{
+		ID:        uuid.NewString(),
+		AccountID: rec.AccountID,
+		AdID:      rec.ID,
+		Type:      eventType,
+		CreatedAt: time.Now().Unix(),
+	})
+
+	return rec, nil, http.StatusOK, nil
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mtavano/admoai-takehome/internal/logging"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/pkg/errors"
+)
+
+// Retry policy of the transactions conflicting with a concurrent one
+const (
+	transactionMaxAttempts = 5
+	transactionBaseBackoff = 20 * time.Millisecond
+)
+
+// maxTransactionalBodySize bounds the bodies Transactional keeps in memory to
+// replay them, far above an import of maxBatchSize rows
+const maxTransactionalBodySize = 16 << 20
+
+// afterCommitKey is the gin context key holding the side effects of the
+// current attempt of a unit of work, see afterCommit
+const afterCommitKey = "after_commit"
+
+// Transactional wraps a handler in a unit of work. The handler runs with a
+// Context whose Db is a transaction bound to the request context, which is
+// committed when the handler succeeds and rolled back when it fails, responds
+// with an error status or panics. Transactions aborted by a concurrent one
+// (SQLite busy, PostgreSQL serialization failures) are run again from scratch,
+// the side effects registered with afterCommit only run once committed.
+func Transactional(fn handler) handler {
+	return func(c *gin.Context, ctx *Context) (any, int, error) {
+		// Keep the body so every attempt can bind it again, up to a size
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxTransactionalBodySize))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return map[string]any{
+					"error":   "Request body too large",
+					"details": "The body must not exceed " + strconv.Itoa(maxTransactionalBodySize>>20) + " MiB",
+				}, http.StatusRequestEntityTooLarge, nil
+			}
+			if err != nil {
+				return map[string]any{
+					"error":   "Validation failed",
+					"details": "Unable to read request body",
+				}, http.StatusBadRequest, nil
+			}
+		}
+
+		for attempt := 1; ; attempt++ {
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+			payload, status, err := runInTransaction(c, ctx, fn)
+			if err == nil || !store.IsRetryable(err) || attempt == transactionMaxAttempts {
+				return payload, status, err
+			}
+
+			logging.FromContext(c.Request.Context()).Warn("transaction conflict",
+				slog.Int("attempt", attempt),
+				slog.String("error", err.Error()),
+			)
+
+			if err := sleepBackoff(c.Request.Context(), attempt); err != nil {
+				return nil, http.StatusServiceUnavailable, errors.Wrap(err, "api: Transactional retry error")
+			}
+		}
+	}
+}
+
+// runInTransaction runs one attempt of the handler in its own transaction
+func runInTransaction(c *gin.Context, ctx *Context, fn handler) (payload any, status int, err error) {
+	tx, err := ctx.Db.BeginTx(c.Request.Context())
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: Transactional begin error")
+	}
+
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		if rbErr := tx.Rollback(); rbErr != nil {
+			logging.FromContext(c.Request.Context()).Error("transaction rollback failed", slog.String("error", rbErr.Error()))
+		}
+	}()
+
+	txCtx := *ctx
+	txCtx.Db = &txDatabase{Transactioner: tx}
+
+	// The side effects of an attempt are dropped with its transaction
+	var hooks []func()
+	c.Set(afterCommitKey, &hooks)
+	defer c.Set(afterCommitKey, (*[]func())(nil))
+
+	payload, status, err = fn(c, &txCtx)
+	if err != nil || status >= http.StatusBadRequest {
+		return payload, status, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "api: Transactional commit error")
+	}
+	committed = true
+
+	for _, hook := range hooks {
+		hook()
+	}
+
+	return payload, status, nil
+}
+
+// afterCommit runs fn once the transaction of the request has committed, so
+// the metrics of an attempt that is rolled back, and maybe retried, are not
+// counted. Outside of Transactional fn runs right away. Audit entries need no
+// hook, they are written in the transaction itself.
+func afterCommit(c *gin.Context, fn func()) {
+	if value, ok := c.Get(afterCommitKey); ok {
+		if hooks, ok := value.(*[]func()); ok && hooks != nil {
+			*hooks = append(*hooks, fn)
+			return
+		}
+	}
+	fn()
+}
+
+// sleepBackoff waits before the next attempt, with jitter so conflicting
+// requests do not collide again
+func sleepBackoff(ctx context.Context, attempt int) error {
+	backoff := transactionBaseBackoff << (attempt - 1)
+	backoff += time.Duration(rand.Int63n(int64(backoff)))
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// txDatabase exposes a transaction as the Database of a handler. Nested
+// transactions join the outer one, which alone commits or rolls back.
+type txDatabase struct {
+	store.Transactioner
+}
+
+func (db *txDatabase) BeginTx(context.Context) (store.Transactioner, error) {
+	return nestedTx{Transaction: db.Transactioner}, nil
+}
+
+// nestedTx is a transaction joined to an outer one
+type nestedTx struct {
+	store.Transaction
+}
+
+func (nestedTx) Commit() error   { return nil }
+func (nestedTx) Rollback() error { return nil }
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattn/go-sqlite3"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newUnitOfWorkTest(body string) (*gin.Context, *Context, *MockDatabase) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+
+	mockDB := new(MockDatabase)
+	return c, &Context{Db: mockDB}, mockDB
+}
+
+func TestTransactional(t *testing.T) {
+	busy := errors.Wrap(sqlite3.Error{Code: sqlite3.ErrBusy}, "query: InsertAds error")
+
+	testCases := []struct {
+		name            string
+		results         []error
+		status          int
+		expectedCommits int
+		expectedCalls   int
+		expectedErr     bool
+	}{
+		{
+			name:            "commits on success",
+			results:         []error{nil},
+			status:          http.StatusCreated,
+			expectedCommits: 1,
+			expectedCalls:   1,
+		},
+		{
+			name:          "rolls back on error",
+			results:       []error{errors.New("boom")},
+			status:        http.StatusInternalServerError,
+			expectedCalls: 1,
+			expectedErr:   true,
+		},
+		{
+			name:          "rolls back on error status",
+			results:       []error{nil},
+			status:        http.StatusConflict,
+			expectedCalls: 1,
+		},
+		{
+			name:            "retries busy transactions",
+			results:         []error{busy, busy, nil},
+			status:          http.StatusOK,
+			expectedCommits: 1,
+			expectedCalls:   3,
+		},
+		{
+			name:          "gives up after the last attempt",
+			results:       []error{busy, busy, busy, busy, busy},
+			status:        http.StatusInternalServerError,
+			expectedCalls: transactionMaxAttempts,
+			expectedErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, ctx, mockDB := newUnitOfWorkTest(`{"title":"ad"}`)
+
+			mockTx := new(MockTransaction)
+			mockDB.On("BeginTx", mock.Anything).Return(mockTx, nil)
+			mockTx.On("Commit").Return(nil)
+			mockTx.On("Rollback").Return(nil)
+
+			calls, committed := 0, 0
+			handler := Transactional(func(c *gin.Context, ctx *Context) (any, int, error) {
+				// Side effects only run for the attempt that commits
+				afterCommit(c, func() { committed++ })
+
+				// Every attempt reads the whole body again
+				body, err := io.ReadAll(c.Request.Body)
+				require.NoError(t, err)
+				assert.Equal(t, `{"title":"ad"}`, string(body))
+
+				// Handlers see the transaction, nested ones join it
+				nested, err := ctx.Db.BeginTx(c.Request.Context())
+				require.NoError(t, err)
+				assert.NoError(t, nested.Commit())
+
+				err = tc.results[calls]
+				calls++
+				if err != nil {
+					return nil, tc.status, err
+				}
+				return map[string]any{}, tc.status, nil
+			})
+
+			_, status, err := handler(c, ctx)
+			assert.Equal(t, tc.status, status)
+			assert.Equal(t, tc.expectedErr, err != nil)
+			assert.Equal(t, tc.expectedCalls, calls)
+			assert.Equal(t, tc.expectedCommits, committed)
+
+			mockDB.AssertNumberOfCalls(t, "BeginTx", tc.expectedCalls)
+			mockTx.AssertNumberOfCalls(t, "Commit", tc.expectedCommits)
+			mockTx.AssertNumberOfCalls(t, "Rollback", tc.expectedCalls-tc.expectedCommits)
+		})
+	}
+}
+
+func TestTransactionalRollsBackOnPanic(t *testing.T) {
+	c, ctx, mockDB := newUnitOfWorkTest("")
+
+	mockTx := new(MockTransaction)
+	mockDB.On("BeginTx", mock.Anything).Return(mockTx, nil)
+	mockTx.On("Rollback").Return(nil)
+
+	handler := Transactional(func(c *gin.Context, ctx *Context) (any, int, error) {
+		panic("boom")
+	})
+
+	assert.PanicsWithValue(t, "boom", func() {
+		handler(c, ctx)
+	})
+	mockTx.AssertNumberOfCalls(t, "Rollback", 1)
+	mockTx.AssertNotCalled(t, "Commit")
+}
+
+func TestTransactionalBodyLimit(t *testing.T) {
+	c, ctx, mockDB := newUnitOfWorkTest(strings.Repeat("a", maxTransactionalBodySize+1))
+
+	called := false
+	handler := Transactional(func(c *gin.Context, ctx *Context) (any, int, error) {
+		called = true
+		return nil, http.StatusOK, nil
+	})
+
+	_, status, err := handler(c, ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+	assert.False(t, called)
+	mockDB.AssertNotCalled(t, "BeginTx", mock.Anything)
+}
+
+func TestAfterCommitOutsideTransaction(t *testing.T) {
+	c, _, _ := newUnitOfWorkTest("")
+
+	ran := false
+	afterCommit(c, func() { ran = true })
+	assert.True(t, ran)
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, store.IsRetryable(errors.Wrap(sqlite3.Error{Code: sqlite3.ErrLocked}, "wrapped")))
+	assert.False(t, store.IsRetryable(sqlite3.Error{Code: sqlite3.ErrConstraint}))
+	assert.False(t, store.IsRetryable(errors.New("boom")))
+}
@@ -0,0 +1,134 @@
+// Package audit builds the entries of the ad audit log. Entries are written
+// by the callers in the same transaction as the mutation they describe, so
+// the log never records a change that was rolled back.
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/pkg/errors"
+)
+
+// ExpiryActor is the actor of the transitions made by the expiry worker
+const ExpiryActor = "system:expiry"
+
+// derivedFields are computed on read and never stored, they are left out of
+// snapshots so an entry only shows what was actually written
+var derivedFields = []string{"expired", "lifecycle"}
+
+// Change is the previous and new value of a field
+type Change struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// APIKeyActor returns the actor of a change made with an API key
+func APIKeyActor(keyID string) string {
+	return "api_key:" + keyID
+}
+
+// NewAdEntry builds the audit entry of a mutation of an ad. before is nil when
+// the ad is created.
+func NewAdEntry(action, actor, requestID string, before, after *store.AdvertiseRecord, at time.Time) (*store.AdAuditRecord, error) {
+	if after == nil {
+		return nil, errors.New("audit: NewAdEntry requires the ad after the change")
+	}
+
+	beforeFields, err := snapshot(before)
+	if err != nil {
+		return nil, errors.Wrap(err, "audit: NewAdEntry before snapshot error")
+	}
+	afterFields, err := snapshot(after)
+	if err != nil {
+		return nil, errors.Wrap(err, "audit: NewAdEntry after snapshot error")
+	}
+
+	changes, err := json.Marshal(Diff(beforeFields, afterFields))
+	if err != nil {
+		return nil, errors.Wrap(err, "audit: NewAdEntry changes error")
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, errors.Wrap(err, "audit: NewAdEntry id error")
+	}
+
+	entry := &store.AdAuditRecord{
+		ID:        id.String(),
+		AccountID: after.AccountID,
+		AdID:      after.ID,
+		Action:    action,
+		Actor:     actor,
+		RequestID: requestID,
+		Changes:   changes,
+		CreatedAt: at.Unix(),
+	}
+	if entry.Before, err = nullJSON(beforeFields); err != nil {
+		return nil, errors.Wrap(err, "audit: NewAdEntry before snapshot error")
+	}
+	if entry.After, err = nullJSON(afterFields); err != nil {
+		return nil, errors.Wrap(err, "audit: NewAdEntry after snapshot error")
+	}
+
+	return entry, nil
+}
+
+// Diff returns the fields whose value differs between two snapshots. A nil
+// snapshot has no fields, so every field of the other one is reported.
+func Diff(before, after map[string]any) map[string]Change {
+	changes := make(map[string]Change)
+	for field, to := range after {
+		from, ok := before[field]
+		if !ok || !reflect.DeepEqual(from, to) {
+			changes[field] = Change{From: from, To: to}
+		}
+	}
+	for field, from := range before {
+		if _, ok := after[field]; !ok {
+			changes[field] = Change{From: from, To: nil}
+		}
+	}
+	return changes
+}
+
+// snapshot returns the stored fields of an ad as they are serialized by the
+// API, nil for a missing ad
+func snapshot(ad *store.AdvertiseRecord) (map[string]any, error) {
+	if ad == nil {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(ad)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	for _, field := range derivedFields {
+		delete(fields, field)
+	}
+
+	return fields, nil
+}
+
+// nullJSON encodes a snapshot, nil for a missing ad
+func nullJSON(fields map[string]any) (*types.JSONText, error) {
+	if fields == nil {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	text := types.JSONText(raw)
+	return &text, nil
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mtavano/admoai-takehome/internal/alerting"
+)
+
+// Load returns the alert rules and notifiers of the rules file, the built-in
+// rule when none is set. The file is YAML or TOML like the configuration
+// file, or JSON.
+func (a Alerting) Load() (*alerting.Config, error) {
+	if a.Rules == "" {
+		return alerting.DefaultConfig(), nil
+	}
+
+	var raw []byte
+	switch {
+	case strings.ToLower(filepath.Ext(a.Rules)) == ".json":
+		var err error
+		if raw, err = os.ReadFile(a.Rules); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", a.Rules, err)
+		}
+	case isTreeFile(a.Rules):
+		// The tree is decoded like JSON, with the field names and the
+		// durations of alerting.Config
+		tree, err := decodeTreeFile(a.Rules)
+		if err != nil {
+			return nil, err
+		}
+		if raw, err = json.Marshal(tree); err != nil {
+			return nil, fmt.Errorf("failed to convert %s: %w", a.Rules, err)
+		}
+	default:
+		return nil, fmt.Errorf("rules file %s must be .yaml, .yml, .toml or .json", a.Rules)
+	}
+
+	cfg, err := alerting.ParseConfig(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", a.Rules, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,254 @@
+// Package config loads the configuration of the server from its defaults, a
+// YAML or TOML file, environment variables and command line flags, each
+// source overriding the previous ones, and validates it at startup.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mtavano/admoai-takehome/internal/api/middleware"
+	"github.com/mtavano/admoai-takehome/internal/expiry"
+	"github.com/mtavano/admoai-takehome/internal/idempotency"
+	"github.com/mtavano/admoai-takehome/internal/logging"
+	"github.com/mtavano/admoai-takehome/internal/metrics"
+	"github.com/mtavano/admoai-takehome/internal/serving"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/tracing"
+	"github.com/mtavano/admoai-takehome/internal/tracking"
+)
+
+// Each setting is named by the config tags of its section and field, like
+// database.dsn, in the file and the flags. The env tag names its environment
+// variable and redact hides secrets when the configuration is printed:
+// value hides the whole value, password the password of a URL.
+
+// Config is the configuration of the server
+type Config struct {
+	Server      Server      `config:"server"`
+	Database    Database    `config:"database"`
+	Logging     Logging     `config:"logging"`
+	Tracing     Tracing     `config:"tracing"`
+	Metrics     Metrics     `config:"metrics"`
+	Alerting    Alerting    `config:"alerting"`
+	Serving     Serving     `config:"serving"`
+	Expiry      Expiry      `config:"expiry"`
+	Tracking    Tracking    `config:"tracking"`
+	Idempotency Idempotency `config:"idempotency"`
+	Admin       Admin       `config:"admin"`
+	Dashboard   Dashboard   `config:"dashboard"`
+}
+
+// Server configures the http server
+type Server struct {
+	Port            int           `config:"port" env:"API_PORT" usage:"port the http server listens on"`
+	ShutdownTimeout time.Duration `config:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" usage:"how long in-flight requests are drained on shutdown"`
+	// CORSAllowedOrigins empty allows any origin without credentials
+	CORSAllowedOrigins []string `config:"cors_allowed_origins" env:"CORS_ALLOWED_ORIGINS" usage:"comma separated origins allowed by CORS, empty allows any origin without credentials"`
+}
+
+// Database configures the store and its connection pool
+type Database struct {
+	Driver string `config:"driver" env:"DB_DRIVER" usage:"database driver, sqlite3 or postgres"`
+	DSN    string `config:"dsn" env:"DB_DSN" redact:"password" usage:"database file path for sqlite3, connection URL for postgres"`
+	// Migrate runs the migrations of MigrationsDir at startup
+	Migrate       bool          `config:"migrate" env:"DB_MIGRATE" usage:"run the migrations at startup"`
+	MigrationsDir string        `config:"migrations_dir" env:"DB_MIGRATIONS_DIR" usage:"directory of the migrations"`
+	QueryTimeout  time.Duration `config:"query_timeout" env:"DB_QUERY_TIMEOUT" usage:"bound of each statement"`
+	// The pool settings follow store.PoolOptions
+	MaxOpenConns    int           `config:"max_open_conns" env:"DB_MAX_OPEN_CONNS" usage:"connections open at once, 0 is unlimited"`
+	MaxIdleConns    int           `config:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" usage:"connections kept open while idle"`
+	ConnMaxIdleTime time.Duration `config:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" usage:"idle time after which a connection is closed, 0 is unlimited"`
+	ConnMaxLifetime time.Duration `config:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" usage:"lifetime after which a connection is closed, 0 is unlimited"`
+}
+
+// Pool returns the connection pool options of the store
+func (d Database) Pool() store.PoolOptions {
+	return store.PoolOptions{
+		MaxOpenConns:    d.MaxOpenConns,
+		MaxIdleConns:    d.MaxIdleConns,
+		ConnMaxIdleTime: d.ConnMaxIdleTime,
+		ConnMaxLifetime: d.ConnMaxLifetime,
+	}
+}
+
+// Logging configures the structured logs
+type Logging struct {
+	Level  string `config:"level" env:"LOG_LEVEL" usage:"minimum level of the logs, debug, info, warn or error"`
+	Format string `config:"format" env:"LOG_FORMAT" usage:"format of the logs, json or text"`
+}
+
+// Tracing configures the export of the spans
+type Tracing struct {
+	Exporter string `config:"exporter" env:"TRACING_EXPORTER" usage:"exporter of the spans, none or otlp"`
+}
+
+// Metrics configures the Prometheus metrics
+type Metrics struct {
+	AdCountsMaxAge time.Duration `config:"ad_counts_max_age" env:"METRICS_AD_COUNTS_MAX_AGE" usage:"how long the ad counts are cached between scrapes"`
+}
+
+// Alerting configures the alerting engine
+type Alerting struct {
+	Enabled bool `config:"enabled" env:"ALERTING_ENABLED" usage:"evaluate the alert rules"`
+	// Rules is the YAML, TOML or JSON file of the rules and notifiers, read
+	// by Load and checked by Validate, the built-in rule when empty
+	Rules string `config:"rules" env:"ALERTING_CONFIG" usage:"YAML, TOML or JSON file of the alert rules and notifiers, the built-in rule when empty"`
+}
+
+// Serving configures the ad serving index
+type Serving struct {
+	RefreshInterval time.Duration `config:"refresh_interval" env:"SERVING_REFRESH_INTERVAL" usage:"how often the serving index is reloaded"`
+}
+
+// Expiry configures the expiry reaper
+type Expiry struct {
+	Enabled       bool          `config:"enabled" env:"EXPIRY_ENABLED" usage:"transition the expired ads in background"`
+	SweepInterval time.Duration `config:"sweep_interval" env:"EXPIRY_SWEEP_INTERVAL" usage:"how often the expired ads are swept"`
+}
+
+// Tracking configures the buffer of the tracking events
+type Tracking struct {
+	BufferSize    int           `config:"buffer_size" env:"TRACKING_BUFFER_SIZE" usage:"events kept in memory before dropping"`
+	BatchSize     int           `config:"batch_size" env:"TRACKING_BATCH_SIZE" usage:"events written per transaction"`
+	FlushInterval time.Duration `config:"flush_interval" env:"TRACKING_FLUSH_INTERVAL" usage:"longest an event waits before being written"`
+}
+
+// Options returns the options of the tracking recorder
+func (t Tracking) Options() tracking.Options {
+	return tracking.Options{
+		BufferSize:    t.BufferSize,
+		BatchSize:     t.BatchSize,
+		FlushInterval: t.FlushInterval,
+	}
+}
+
+// Idempotency configures the idempotency keys
+type Idempotency struct {
+	KeyTTL        time.Duration `config:"key_ttl" env:"IDEMPOTENCY_KEY_TTL" usage:"how long the responses of idempotency keys are kept"`
+	PurgeInterval time.Duration `config:"purge_interval" env:"IDEMPOTENCY_PURGE_INTERVAL" usage:"how often the expired idempotency keys are deleted"`
+}
+
+// Admin configures the admin key bootstrapped at startup
+type Admin struct {
+	// APIKey is stored as an admin key of AccountID when set
+	APIKey    string `config:"api_key" env:"ADMIN_API_KEY" redact:"value" usage:"admin api key created at startup"`
+	AccountID string `config:"account_id" env:"ADMIN_ACCOUNT_ID" usage:"account of the admin api key, whose admins manage the placements"`
+}
+
+// Dashboard configures the links opening the dashboard
+type Dashboard struct {
+	// LinkSigningKey signs the links, a random key when empty, so the links
+	// only work on the process that issued them
+	LinkSigningKey string        `config:"link_signing_key" env:"DASHBOARD_LINK_SIGNING_KEY" redact:"value" usage:"key signing the dashboard links, random at startup when empty"`
+	LinkTTL        time.Duration `config:"link_ttl" env:"DASHBOARD_LINK_TTL" usage:"how long a dashboard link is valid"`
+}
+
+// Default returns the configuration used when no source sets a value
+func Default() *Config {
+	pool := store.DefaultPoolOptions()
+	return &Config{
+		Server: Server{
+			Port:            9001,
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Database: Database{
+			Driver:          store.DialectSQLite.Driver,
+			DSN:             "./data/admoai.db",
+			Migrate:         true,
+			MigrationsDir:   "./migrations",
+			QueryTimeout:    store.DefaultQueryTimeout,
+			MaxOpenConns:    pool.MaxOpenConns,
+			MaxIdleConns:    pool.MaxIdleConns,
+			ConnMaxIdleTime: pool.ConnMaxIdleTime,
+			ConnMaxLifetime: pool.ConnMaxLifetime,
+		},
+		Logging: Logging{
+			Level:  "info",
+			Format: logging.FormatJSON,
+		},
+		Tracing: Tracing{
+			Exporter: tracing.ExporterNone,
+		},
+		Metrics: Metrics{
+			AdCountsMaxAge: metrics.DefaultAdCountsMaxAge,
+		},
+		Alerting: Alerting{
+			Enabled: true,
+		},
+		Serving: Serving{
+			RefreshInterval: serving.DefaultRefreshInterval,
+		},
+		Expiry: Expiry{
+			Enabled:       true,
+			SweepInterval: expiry.DefaultInterval,
+		},
+		Tracking: Tracking{
+			BufferSize:    tracking.DefaultBufferSize,
+			BatchSize:     tracking.DefaultBatchSize,
+			FlushInterval: tracking.DefaultFlushInterval,
+		},
+		Idempotency: Idempotency{
+			KeyTTL:        idempotency.DefaultTTL,
+			PurgeInterval: idempotency.DefaultPurgeInterval,
+		},
+		Admin: Admin{
+			AccountID: store.AccountDefaultID,
+		},
+		Dashboard: Dashboard{
+			LinkTTL: middleware.DefaultLinkTTL,
+		},
+	}
+}
+
+// Validate checks every setting and reports all the invalid ones at once
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("invalid %s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port", "must be between 1 and 65535")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout", "must be a positive duration")
+
+	_, err := store.DialectFor(c.Database.Driver)
+	check(err == nil, "database.driver", "must be sqlite3, postgres or pgx")
+	check(c.Database.DSN != "", "database.dsn", "must not be empty")
+	check(!c.Database.Migrate || c.Database.MigrationsDir != "", "database.migrations_dir", "must not be empty when migrating")
+	check(c.Database.QueryTimeout > 0, "database.query_timeout", "must be a positive duration")
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns", "must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns", "must not be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns", "must not exceed database.max_open_conns")
+	check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time", "must not be negative")
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime", "must not be negative")
+
+	_, err = logging.ParseLevel(c.Logging.Level)
+	check(err == nil, "logging.level", "must be debug, info, warn or error")
+	format := strings.ToLower(c.Logging.Format)
+	check(format == logging.FormatJSON || format == logging.FormatText, "logging.format", "must be json or text")
+
+	check(c.Tracing.Exporter == tracing.ExporterNone || c.Tracing.Exporter == tracing.ExporterOTLP,
+		"tracing.exporter", "must be none or otlp")
+
+	check(c.Metrics.AdCountsMaxAge > 0, "metrics.ad_counts_max_age", "must be a positive duration")
+	if c.Alerting.Enabled {
+		_, err = c.Alerting.Load()
+		check(err == nil, "alerting.rules", "%v", err)
+	}
+	check(c.Serving.RefreshInterval > 0, "serving.refresh_interval", "must be a positive duration")
+	check(c.Expiry.SweepInterval > 0, "expiry.sweep_interval", "must be a positive duration")
+	check(c.Tracking.BufferSize > 0, "tracking.buffer_size", "must be positive")
+	check(c.Tracking.BatchSize > 0, "tracking.batch_size", "must be positive")
+	check(c.Tracking.FlushInterval > 0, "tracking.flush_interval", "must be a positive duration")
+	check(c.Idempotency.KeyTTL > 0, "idempotency.key_ttl", "must be a positive duration")
+	check(c.Idempotency.PurgeInterval > 0, "idempotency.purge_interval", "must be a positive duration")
+	check(c.Admin.AccountID != "", "admin.account_id", "must not be empty")
+	check(c.Dashboard.LinkTTL > 0, "dashboard.link_ttl", "must be a positive duration")
+
+	return errors.Join(errs...)
+}
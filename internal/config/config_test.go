@@ -0,0 +1,232 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtavano/admoai-takehome/internal/alerting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearEnv ignores the variables of the environment running the tests, like
+// the ones exported from dev.env
+func clearEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv(FileEnv, "")
+	for _, s := range settings(Default()) {
+		t.Setenv(s.env, "")
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func load(t *testing.T, args ...string) (*Config, error) {
+	t.Helper()
+	flags, err := ParseFlags(args, &bytes.Buffer{})
+	require.NoError(t, err)
+	return Load(flags)
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t)
+
+	cfg, err := load(t)
+	require.NoError(t, err)
+	assert.Equal(t, Default(), cfg)
+	assert.Equal(t, 9001, cfg.Server.Port)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, "config.yaml", `
+server:
+  port: 7000
+  cors_allowed_origins: [https://a.example, https://b.example]
+database:
+  driver: postgres
+  dsn: postgres://admoai:secret@db/admoai
+  max_open_conns: 40
+  conn_max_lifetime: 5m
+expiry:
+  enabled: false
+logging:
+  level: debug
+`)
+	t.Setenv(FileEnv, path)
+	t.Setenv("API_PORT", "7001")
+	t.Setenv("DB_MAX_OPEN_CONNS", "50")
+	t.Setenv("LOG_LEVEL", "warn")
+
+	cfg, err := load(t, "-server.port=7002", "-expiry.enabled", "-logging.level", "error")
+	require.NoError(t, err)
+
+	// Flags win over the environment, which wins over the file
+	assert.Equal(t, 7002, cfg.Server.Port)
+	assert.Equal(t, "error", cfg.Logging.Level)
+	assert.True(t, cfg.Expiry.Enabled)
+	assert.Equal(t, 50, cfg.Database.MaxOpenConns)
+	assert.Equal(t, "postgres", cfg.Database.Driver)
+	assert.Equal(t, 5*time.Minute, cfg.Database.ConnMaxLifetime)
+	assert.Equal(t, []string{"https://a.example", "https://b.example"}, cfg.Server.CORSAllowedOrigins)
+	// Settings missing from every source keep their default
+	assert.Equal(t, Default().Database.MaxIdleConns, cfg.Database.MaxIdleConns)
+}
+
+func TestLoadTOML(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, "config.toml", `
+[server]
+port = 8000
+shutdown_timeout = "30s"
+
+[tracking]
+batch_size = 100
+`)
+
+	cfg, err := load(t, "-config", path)
+	require.NoError(t, err)
+	assert.Equal(t, 8000, cfg.Server.Port)
+	assert.Equal(t, 30*time.Second, cfg.Server.ShutdownTimeout)
+	assert.Equal(t, 100, cfg.Tracking.BatchSize)
+}
+
+func TestLoadErrors(t *testing.T) {
+	clearEnv(t)
+
+	t.Run("unknown file setting", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "server:\n  prot: 7000\n")
+		_, err := load(t, "-config", path)
+		assert.ErrorContains(t, err, "unknown setting server.prot")
+	})
+
+	t.Run("unsupported file", func(t *testing.T) {
+		path := writeFile(t, "config.json", "{}")
+		_, err := load(t, "-config", path)
+		assert.ErrorContains(t, err, "must be .yaml, .yml or .toml")
+	})
+
+	t.Run("invalid environment variable", func(t *testing.T) {
+		t.Setenv("DB_QUERY_TIMEOUT", "5")
+		_, err := load(t)
+		assert.EqualError(t, err, `invalid DB_QUERY_TIMEOUT "5": must be a duration like 30s`)
+	})
+
+	t.Run("invalid flag", func(t *testing.T) {
+		_, err := load(t, "-server.port=http")
+		assert.EqualError(t, err, `invalid -server.port "http": must be an integer`)
+	})
+
+	t.Run("every invalid setting", func(t *testing.T) {
+		_, err := load(t, "-server.port=0", "-database.driver=mysql", "-tracing.exporter=jaeger")
+		require.Error(t, err)
+		assert.Equal(t, []string{
+			"invalid server.port: must be between 1 and 65535",
+			"invalid database.driver: must be sqlite3, postgres or pgx",
+			"invalid tracing.exporter: must be none or otlp",
+		}, strings.Split(err.Error(), "\n"))
+	})
+}
+
+func TestWrite(t *testing.T) {
+	clearEnv(t)
+
+	cfg := Default()
+	cfg.Server.CORSAllowedOrigins = []string{"https://a.example"}
+	cfg.Admin.APIKey = "admoai_secret"
+	cfg.Dashboard.LinkSigningKey = "secret-signing-key"
+	cfg.Database.DSN = "postgres://admoai:secret@db/admoai?sslmode=disable"
+
+	var out bytes.Buffer
+	require.NoError(t, cfg.Write(&out))
+	assert.NotContains(t, out.String(), "secret")
+	assert.Contains(t, out.String(), `admin.api_key = "REDACTED"`)
+	assert.Contains(t, out.String(), `database.dsn = "postgres://admoai:REDACTED@db/admoai?sslmode=disable"`)
+	assert.Contains(t, out.String(), `server.cors_allowed_origins = ["https://a.example"]`)
+
+	// The printed configuration loads back as a TOML file
+	loaded, err := load(t, "-config", writeFile(t, "printed.toml", out.String()))
+	require.NoError(t, err)
+	cfg.Admin.APIKey = "REDACTED"
+	cfg.Dashboard.LinkSigningKey = "REDACTED"
+	cfg.Database.DSN = "postgres://admoai:REDACTED@db/admoai?sslmode=disable"
+	assert.Equal(t, cfg, loaded)
+}
+
+func TestRedactKeyValueDSN(t *testing.T) {
+	cfg := Default()
+	cfg.Database.DSN = "host=db user=admoai password=secret dbname=admoai"
+
+	var out bytes.Buffer
+	require.NoError(t, cfg.Write(&out))
+	assert.Contains(t, out.String(), `database.dsn = "host=db user=admoai password=REDACTED dbname=admoai"`)
+}
+
+func TestLoadAlertingRules(t *testing.T) {
+	clearEnv(t)
+
+	yamlRules := writeFile(t, "alerting.yaml", `
+interval: 15s
+rules:
+  - name: HighAds
+    metric: admoai_ads_active_current
+    labels:
+      placement: homepage
+    comparator: ">="
+    threshold: 5
+    for: 2m
+notifiers:
+  - type: log
+  - type: alertmanager
+    url: http://localhost:9093
+`)
+	tomlRules := writeFile(t, "alerting.toml", `
+[[rules]]
+name = "HighAds"
+metric = "admoai_ads_active_current"
+comparator = ">"
+threshold = 5
+
+[[notifiers]]
+type = "webhook"
+url = "https://hooks.example.com/alerts"
+timeout = "5s"
+`)
+
+	for _, path := range []string{yamlRules, tomlRules} {
+		cfg, err := load(t, "-alerting.rules", path)
+		require.NoError(t, err, path)
+		rules, err := cfg.Alerting.Load()
+		require.NoError(t, err, path)
+		require.Len(t, rules.Rules, 1)
+		assert.Equal(t, "HighAds", rules.Rules[0].Name)
+		assert.Equal(t, float64(5), rules.Rules[0].Threshold)
+	}
+
+	rules, err := Default().Alerting.Load()
+	require.NoError(t, err)
+	assert.Equal(t, alerting.DefaultConfig(), rules)
+
+	// The rules are checked at startup
+	_, err = load(t, "-alerting.rules", filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "invalid alerting.rules: failed to read")
+	_, err = load(t, "-alerting.rules", writeFile(t, "invalid.yaml", "rules:\n  - name: a\n    comparator: '>'\n"))
+	assert.ErrorContains(t, err, "invalid alerting.rules")
+	_, err = load(t, "-alerting.rules", writeFile(t, "unknown.yaml", "rule:\n  - name: a\n"))
+	assert.ErrorContains(t, err, "unknown field")
+	_, err = load(t, "-alerting.rules", writeFile(t, "alerting.txt", ""))
+	assert.ErrorContains(t, err, "must be .yaml, .yml, .toml or .json")
+
+	// Unless alerting is disabled
+	_, err = load(t, "-alerting.enabled=false", "-alerting.rules", filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,270 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the configuration file when the -config flag is not given
+const FileEnv = "CONFIG_FILE"
+
+// Flags is the parsed command line of the server
+type Flags struct {
+	// File is the configuration file of -config
+	File string
+	// Print asks to print the effective configuration and exit
+	Print bool
+	// overrides are the settings given as flags, in command line order
+	overrides []override
+}
+
+type override struct {
+	key, raw string
+}
+
+// ParseFlags parses the command line arguments, without the program name.
+// Every setting is a flag named after its key, like -database.dsn. Usage is
+// written to output and -h returns flag.ErrHelp.
+func ParseFlags(args []string, output io.Writer) (*Flags, error) {
+	flags := &Flags{}
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&flags.File, "config", "", "YAML or TOML configuration `file` (env "+FileEnv+")")
+	fs.BoolVar(&flags.Print, "print-config", false, "print the effective configuration, secrets redacted, and exit")
+
+	defaults := Default()
+	for _, s := range settings(defaults) {
+		key := s.key
+		usage := fmt.Sprintf("%s (env %s, default %s)", s.usage, s.env, s.format(false))
+		set := func(raw string) error {
+			flags.overrides = append(flags.overrides, override{key: key, raw: raw})
+			return nil
+		}
+		if s.value.Kind() == reflect.Bool {
+			fs.BoolFunc(key, usage, set)
+		} else {
+			fs.Func(key, usage, set)
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+	return flags, nil
+}
+
+// Load returns the configuration of the defaults overridden, in order, by
+// the configuration file, the environment variables and the flags. Empty
+// environment variables are ignored. The configuration is validated.
+func Load(flags *Flags) (*Config, error) {
+	cfg := Default()
+	all := settings(cfg)
+	byKey := map[string]setting{}
+	for _, s := range all {
+		byKey[s.key] = s
+	}
+
+	path := flags.File
+	if path == "" {
+		path = os.Getenv(FileEnv)
+	}
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			s, ok := byKey[value.key]
+			if !ok {
+				return nil, fmt.Errorf("unknown setting %s in %s", value.key, path)
+			}
+			if err := s.set(value.raw); err != nil {
+				return nil, fmt.Errorf("invalid %s %q in %s: %w", value.key, value.raw, path, err)
+			}
+		}
+	}
+
+	for _, s := range all {
+		if raw := os.Getenv(s.env); raw != "" {
+			if err := s.set(raw); err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", s.env, raw, err)
+			}
+		}
+	}
+
+	for _, o := range flags.overrides {
+		if err := byKey[o.key].set(o.raw); err != nil {
+			return nil, fmt.Errorf("invalid -%s %q: %w", o.key, o.raw, err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// readFile returns the settings of a YAML or TOML file, by its extension,
+// flattened to their keys and sorted by key
+func readFile(path string) ([]override, error) {
+	if !isTreeFile(path) {
+		return nil, fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	tree, err := decodeTreeFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []override
+	if err := flatten("", tree, &values); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].key < values[j].key })
+	return values, nil
+}
+
+// isTreeFile reports whether decodeTreeFile reads a file, by its extension
+func isTreeFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".toml":
+		return true
+	}
+	return false
+}
+
+// decodeTreeFile decodes a YAML or TOML file, by its extension
+func decodeTreeFile(path string) (map[string]any, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var tree map[string]any
+	if strings.ToLower(filepath.Ext(path)) == ".toml" {
+		err = toml.NewDecoder(bytes.NewReader(raw)).Decode(&tree)
+	} else {
+		err = yaml.Unmarshal(raw, &tree)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return tree, nil
+}
+
+// flatten turns the sections of a decoded file into dotted keys. Lists are
+// joined with commas, like in the environment variables.
+func flatten(prefix string, tree map[string]any, values *[]override) error {
+	for name, value := range tree {
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		switch v := value.(type) {
+		case map[string]any:
+			if err := flatten(key, v, values); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				if _, ok := item.(map[string]any); ok {
+					return fmt.Errorf("%s must be a list of values", key)
+				}
+				items = append(items, fmt.Sprint(item))
+			}
+			*values = append(*values, override{key: key, raw: strings.Join(items, ",")})
+		case nil:
+			// An empty value keeps the default
+		default:
+			*values = append(*values, override{key: key, raw: fmt.Sprint(v)})
+		}
+	}
+	return nil
+}
+
+// setting is one field of the configuration
+type setting struct {
+	key    string
+	env    string
+	usage  string
+	redact string
+	value  reflect.Value
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// settings returns the settings of cfg in declaration order, their values
+// point into cfg
+func settings(cfg *Config) []setting {
+	var all []setting
+	sections := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Field(i)
+		sectionKey := sections.Type().Field(i).Tag.Get("config")
+		for j := 0; j < section.NumField(); j++ {
+			field := section.Type().Field(j)
+			all = append(all, setting{
+				key:    sectionKey + "." + field.Tag.Get("config"),
+				env:    field.Tag.Get("env"),
+				usage:  field.Tag.Get("usage"),
+				redact: field.Tag.Get("redact"),
+				value:  section.Field(j),
+			})
+		}
+	}
+	return all
+}
+
+// set parses raw into the value of the setting
+func (s setting) set(raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case s.value.Type() == durationType:
+		value, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("must be a duration like 30s")
+		}
+		s.value.SetInt(int64(value))
+	case s.value.Kind() == reflect.Int:
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		s.value.SetInt(int64(value))
+	case s.value.Kind() == reflect.Bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("must be true or false")
+		}
+		s.value.SetBool(value)
+	case s.value.Kind() == reflect.String:
+		s.value.SetString(raw)
+	case s.value.Kind() == reflect.Slice:
+		var values []string
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		s.value.Set(reflect.ValueOf(values))
+	default:
+		panic("config: unsupported type of " + s.key)
+	}
+	return nil
+}
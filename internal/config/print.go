@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redacted replaces the secrets in the printed configuration
+const redacted = "REDACTED"
+
+// Write prints the configuration as TOML, one dotted key per setting, with
+// the secrets redacted. The output can be loaded back as a configuration
+// file once the secrets are filled in.
+func (c *Config) Write(w io.Writer) error {
+	for _, s := range settings(c) {
+		if _, err := fmt.Fprintf(w, "%s = %s\n", s.key, s.format(true)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LogValue implements slog.LogValuer, logging the settings with the secrets
+// redacted
+func (c *Config) LogValue() slog.Value {
+	var attrs []slog.Attr
+	for _, s := range settings(c) {
+		var value any
+		switch {
+		case s.value.Type() == durationType:
+			value = time.Duration(s.value.Int()).String()
+		case s.value.Kind() == reflect.String:
+			value = s.redacted()
+		default:
+			value = s.value.Interface()
+		}
+		attrs = append(attrs, slog.Any(s.key, value))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// format returns the value of the setting as a TOML value
+func (s setting) format(redact bool) string {
+	switch {
+	case s.value.Type() == durationType:
+		return strconv.Quote(time.Duration(s.value.Int()).String())
+	case s.value.Kind() == reflect.String:
+		if redact {
+			return strconv.Quote(s.redacted())
+		}
+		return strconv.Quote(s.value.String())
+	case s.value.Kind() == reflect.Slice:
+		items := make([]string, 0, s.value.Len())
+		for i := 0; i < s.value.Len(); i++ {
+			items = append(items, strconv.Quote(s.value.Index(i).String()))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return fmt.Sprint(s.value.Interface())
+	}
+}
+
+// passwordParam matches the password of a key=value connection string
+var passwordParam = regexp.MustCompile(`(password=)(\S+)`)
+
+// redacted returns the value of a string setting without its secrets
+func (s setting) redacted() string {
+	value := s.value.String()
+	if value == "" {
+		return value
+	}
+
+	switch s.redact {
+	case "value":
+		return redacted
+	case "password":
+		if parsed, err := url.Parse(value); err == nil && parsed.User != nil {
+			if _, ok := parsed.User.Password(); ok {
+				parsed.User = url.UserPassword(parsed.User.Username(), redacted)
+			}
+			return parsed.String()
+		}
+		return passwordParam.ReplaceAllString(value, "${1}"+redacted)
+	}
+	return value
+}
@@ -0,0 +1,109 @@
+// Package expiry runs the background worker that moves expired ads to the
+// terminal expired status.
+package expiry
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mtavano/admoai-takehome/internal/audit"
+	"github.com/mtavano/admoai-takehome/internal/metrics"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+// DefaultInterval is how often the reaper sweeps the ads table
+const DefaultInterval = time.Minute
+
+// Reaper periodically transitions active ads past their expiration
+type Reaper struct {
+	db       store.Database
+	interval time.Duration
+
+	// now returns the current time, replaced in tests
+	now func() time.Time
+}
+
+func NewReaper(db store.Database, interval time.Duration) *Reaper {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Reaper{
+		db:       db,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run sweeps right away and then every interval until ctx is cancelled
+func (r *Reaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Sweep(ctx); err != nil {
+			slog.Error("expiry sweep failed", slog.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep expires every due ad in a single transaction and returns how many
+// ads were transitioned
+func (r *Reaper) Sweep(ctx context.Context) (int64, error) {
+	start := time.Now()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "expiry: Reaper.Sweep begin error")
+	}
+
+	now := r.now()
+	records, err := query.ExpireAds(ctx, tx, now.Unix())
+	if err != nil {
+		tx.Rollback()
+		return 0, errors.Wrap(err, "expiry: Reaper.Sweep expire error")
+	}
+
+	// Every transition is audited in the same transaction
+	entries := make([]*store.AdAuditRecord, 0, len(records))
+	for _, after := range records {
+		before := *after
+		before.Status = store.AdvertiseStatusActive
+
+		entry, err := audit.NewAdEntry(store.AdAuditActionExpire, audit.ExpiryActor, "", &before, after, now)
+		if err != nil {
+			tx.Rollback()
+			return 0, errors.Wrap(err, "expiry: Reaper.Sweep audit error")
+		}
+		entries = append(entries, entry)
+	}
+	if err := query.InsertAdAuditLog(ctx, tx, entries...); err != nil {
+		tx.Rollback()
+		return 0, errors.Wrap(err, "expiry: Reaper.Sweep audit error")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "expiry: Reaper.Sweep commit error")
+	}
+	expired := int64(len(records))
+
+	// Record the sweep
+	collector := metrics.GetCollector()
+	if collector != nil {
+		collector.RecordExpirySweep(expired, time.Since(start))
+	}
+	if expired > 0 {
+		slog.Info("expiry sweep transitioned ads to expired", slog.Int64("ads", expired))
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,101 @@
+package expiry
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mtavano/admoai-takehome/internal/audit"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/mtavano/admoai-takehome/internal/store/storetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(storetest.Main(m))
+}
+
+func TestReaperSweep(t *testing.T) {
+	for _, driver := range storetest.Backends() {
+		t.Run(driver, func(t *testing.T) {
+			db := storetest.Open(t, driver)
+			now := time.Now()
+			past := now.Add(-time.Minute).Unix()
+			future := now.Add(time.Hour).Unix()
+
+			ads := map[string]struct {
+				status    string
+				expiresAt *int64
+				expected  string
+			}{
+				"due":         {store.AdvertiseStatusActive, &past, store.AdvertiseStatusExpired},
+				"not-due":     {store.AdvertiseStatusActive, &future, store.AdvertiseStatusActive},
+				"no-expiry":   {store.AdvertiseStatusActive, nil, store.AdvertiseStatusActive},
+				"deactivated": {store.AdvertiseStatusInactive, &past, store.AdvertiseStatusInactive},
+			}
+			for id, ad := range ads {
+				require.NoError(t, query.InsertAds(context.Background(), db, &store.AdvertiseRecord{
+					ID:        id,
+					AccountID: store.AccountDefaultID,
+					Title:     id,
+					ImageURL:  "https://example.com/" + id + ".jpg",
+					Placement: "homepage",
+					Status:    ad.status,
+					Weight:    1,
+					CreatedAt: now.Unix(),
+					ExpiresAt: ad.expiresAt,
+				}))
+			}
+
+			reaper := NewReaper(db, time.Hour)
+
+			expired, err := reaper.Sweep(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), expired)
+
+			for id, ad := range ads {
+				records, err := query.SelectAds(context.Background(), db, &query.SelectAdsArgs{AccountID: store.AccountDefaultID, ID: id})
+				require.NoError(t, err)
+				require.Len(t, records, 1)
+				assert.Equal(t, ad.expected, records[0].Status, id)
+			}
+
+			// The transition is audited as a change of status only
+			entries, err := query.SelectAdAuditLog(context.Background(), db, &query.SelectAdAuditLogArgs{AccountID: store.AccountDefaultID, AdID: "due"})
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			assert.Equal(t, store.AdAuditActionExpire, entries[0].Action)
+			assert.Equal(t, audit.ExpiryActor, entries[0].Actor)
+			assert.JSONEq(t, `{"status":{"from":"active","to":"expired"}}`, entries[0].Changes.String())
+
+			// Sweeps are idempotent
+			expired, err = reaper.Sweep(context.Background())
+			require.NoError(t, err)
+			assert.Zero(t, expired)
+
+			// Once the clock passes the remaining expiration, Run picks it up
+			reaper.now = func() time.Time { return now.Add(2 * time.Hour) }
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				reaper.Run(ctx)
+				close(done)
+			}()
+
+			require.Eventually(t, func() bool {
+				records, err := query.SelectAds(context.Background(), db, &query.SelectAdsArgs{AccountID: store.AccountDefaultID, ID: "not-due"})
+				return err == nil && len(records) == 1 && records[0].Status == store.AdvertiseStatusExpired
+			}, 5*time.Second, 10*time.Millisecond)
+
+			cancel()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("reaper did not stop after cancellation")
+			}
+		})
+	}
+}
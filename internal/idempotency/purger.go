@@ -0,0 +1,71 @@
+// Package idempotency holds the window of the idempotency keys and runs the
+// background worker that drops the keys past it, whose stored responses are
+// no longer replayed.
+package idempotency
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+// DefaultTTL is how long a key is remembered when unconfigured
+const DefaultTTL = 24 * time.Hour
+
+// DefaultPurgeInterval is how often the expired keys are purged
+const DefaultPurgeInterval = 10 * time.Minute
+
+// Purger periodically deletes the expired idempotency keys
+type Purger struct {
+	db       store.Transaction
+	interval time.Duration
+
+	// now returns the current time, replaced in tests
+	now func() time.Time
+}
+
+func NewPurger(db store.Transaction, interval time.Duration) *Purger {
+	if interval <= 0 {
+		interval = DefaultPurgeInterval
+	}
+
+	return &Purger{
+		db:       db,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run purges right away and then every interval until ctx is cancelled
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.Purge(ctx); err != nil {
+			slog.Error("idempotency keys purge failed", slog.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge deletes the keys past their window and returns how many were deleted
+func (p *Purger) Purge(ctx context.Context) (int64, error) {
+	purged, err := query.DeleteIdempotencyKeys(ctx, p.db, &query.DeleteIdempotencyKeysArgs{ExpiredAt: p.now().Unix()})
+	if err != nil {
+		return 0, errors.Wrap(err, "idempotency: Purger.Purge error")
+	}
+	if purged > 0 {
+		slog.Info("purged expired idempotency keys", slog.Int64("keys", purged))
+	}
+	return purged, nil
+}
@@ -0,0 +1,57 @@
+package idempotency
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/mtavano/admoai-takehome/internal/store/storetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(storetest.Main(m))
+}
+
+func TestPurgerPurge(t *testing.T) {
+	for _, driver := range storetest.Backends() {
+		t.Run(driver, func(t *testing.T) {
+			db := storetest.Open(t, driver)
+			now := time.Now()
+
+			for key, expiresAt := range map[string]time.Time{
+				"expired": now.Add(-time.Minute),
+				"live":    now.Add(time.Hour),
+			} {
+				require.NoError(t, query.InsertIdempotencyKeys(context.Background(), db, &store.IdempotencyKeyRecord{
+					AccountID:   store.AccountDefaultID,
+					Key:         key,
+					RequestHash: key,
+					StatusCode:  201,
+					Response:    []byte(`{}`),
+					CreatedAt:   now.Add(-time.Hour).Unix(),
+					ExpiresAt:   expiresAt.Unix(),
+				}))
+			}
+
+			purged, err := NewPurger(db, time.Hour).Purge(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), purged)
+
+			for key, expected := range map[string]int{"expired": 0, "live": 1} {
+				// Expired keys are hidden by the query anyway, look for any row
+				records, err := query.SelectIdempotencyKeys(context.Background(), db, &query.SelectIdempotencyKeysArgs{
+					AccountID: store.AccountDefaultID,
+					Key:       key,
+					Now:       now.Add(-24 * time.Hour).Unix(),
+				})
+				require.NoError(t, err)
+				assert.Len(t, records, expected, key)
+			}
+		})
+	}
+}
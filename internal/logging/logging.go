@@ -0,0 +1,72 @@
+// Package logging configures the structured logger of the service and
+// carries the logger of each request in its context.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Formats of the log lines
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Config selects the minimum level and the format of the logs
+type Config struct {
+	// Level is one of debug, info, warn or error, info when empty
+	Level string
+	// Format is json or text, json when empty
+	Format string
+}
+
+// New returns a logger writing the lines of cfg to w
+func New(w io.Writer, cfg Config) (*slog.Logger, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(cfg.Format) {
+	case "", FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q: must be json or text", cfg.Format)
+	}
+}
+
+// ParseLevel parses a level name, info when empty
+func ParseLevel(raw string) (slog.Level, error) {
+	if raw == "" {
+		return slog.LevelInfo, nil
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(raw)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q: must be debug, info, warn or error", raw)
+	}
+	return level, nil
+}
+
+type contextKey struct{}
+
+// WithLogger returns a context carrying logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, the default logger when it
+// carries none
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
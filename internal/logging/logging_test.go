@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(&out, Config{Level: "warn"})
+	require.NoError(t, err)
+
+	logger.Info("skipped")
+	logger.Warn("kept", "placement", "homepage")
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(t, "WARN", line["level"])
+	assert.Equal(t, "kept", line["msg"])
+	assert.Equal(t, "homepage", line["placement"])
+
+	out.Reset()
+	logger, err = New(&out, Config{Level: "DEBUG", Format: "text"})
+	require.NoError(t, err)
+	logger.Debug("query", "duration_ms", 3)
+	assert.True(t, strings.HasSuffix(out.String(), "level=DEBUG msg=query duration_ms=3\n"), out.String())
+
+	_, err = New(&out, Config{Level: "verbose"})
+	assert.Error(t, err)
+	_, err = New(&out, Config{Format: "xml"})
+	assert.Error(t, err)
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Same(t, slog.Default(), FromContext(context.Background()))
+
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	assert.Same(t, logger, FromContext(WithLogger(context.Background(), logger)))
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultAdCountsMaxAge is how long the ad counts of a scrape are reused by
+// the next ones
+const DefaultAdCountsMaxAge = 15 * time.Second
+
+// AdCounts is the number of ads of an account in a placement in each status
+type AdCounts struct {
+	Account   string
+	Placement string
+	Active    int64
+	Inactive  int64
+	Expired   int64
+}
+
+// AdCountsFunc reads the current ad counts of every account and placement
+type AdCountsFunc func(ctx context.Context) ([]AdCounts, error)
+
+// adCountsCollector exposes the ad count gauges, read at scrape time. The
+// counts are read at most once per maxAge whatever the number of scrapes,
+// and the last counts are kept when a read fails.
+type adCountsCollector struct {
+	active   *prometheus.Desc
+	inactive *prometheus.Desc
+	expired  *prometheus.Desc
+
+	// mu serializes the scrapes so concurrent ones share a read
+	mu     sync.Mutex
+	read   AdCountsFunc
+	maxAge time.Duration
+	counts []AdCounts
+	readAt time.Time
+}
+
+func newAdCountsCollector() *adCountsCollector {
+	// Tenant specific, see TenantGatherer
+	labels := []string{AccountLabel, "placement"}
+	return &adCountsCollector{
+		active:   prometheus.NewDesc("admoai_ads_active_current", "Current number of active ads by account and placement", labels, nil),
+		inactive: prometheus.NewDesc("admoai_ads_inactive_current", "Current number of inactive ads by account and placement", labels, nil),
+		expired:  prometheus.NewDesc("admoai_ads_expired_current", "Current number of expired ads by account and placement", labels, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (a *adCountsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- a.active
+	ch <- a.inactive
+	ch <- a.expired
+}
+
+// Collect implements prometheus.Collector
+func (a *adCountsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, count := range a.current() {
+		ch <- prometheus.MustNewConstMetric(a.active, prometheus.GaugeValue, float64(count.Active), count.Account, count.Placement)
+		ch <- prometheus.MustNewConstMetric(a.inactive, prometheus.GaugeValue, float64(count.Inactive), count.Account, count.Placement)
+		ch <- prometheus.MustNewConstMetric(a.expired, prometheus.GaugeValue, float64(count.Expired), count.Account, count.Placement)
+	}
+}
+
+// setSource replaces the function reading the counts and drops the counts
+// read by the previous one
+func (a *adCountsCollector) setSource(read AdCountsFunc, maxAge time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.read = read
+	a.maxAge = maxAge
+	a.counts = nil
+	a.readAt = time.Time{}
+}
+
+// current returns the counts, read again when older than maxAge
+func (a *adCountsCollector) current() []AdCounts {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.read == nil || time.Since(a.readAt) < a.maxAge {
+		return a.counts
+	}
+
+	// Scrapes carry no context, the store bounds the query with its
+	// own timeout
+	counts, err := a.read(context.Background())
+	if err != nil {
+		slog.Error("ad counts read failed", slog.String("error", err.Error()))
+		return a.counts
+	}
+
+	a.counts = counts
+	a.readAt = time.Now()
+	return a.counts
+}
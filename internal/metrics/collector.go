@@ -1,7 +1,6 @@
 package metrics
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -10,20 +9,36 @@ import (
 
 type Collector struct {
 	// Ad metrics
-	adsCreatedTotal     prometheus.Counter
-	adsDeactivatedTotal prometheus.Counter
-	adsActiveCurrent    prometheus.Gauge
-	adsInactiveCurrent  prometheus.Gauge
-	adsExpiredCurrent   prometheus.Gauge
+	adsCreatedTotal     *prometheus.CounterVec
+	adsDeactivatedTotal *prometheus.CounterVec
+	adsUpdatedTotal     *prometheus.CounterVec
+	adBatchItemsTotal   *prometheus.CounterVec
+	adCounts            *adCountsCollector
+
+	// Serving metrics
+	adServesTotal *prometheus.CounterVec
+
+	// Expiry metrics
+	adsExpiredTotal     prometheus.Counter
+	expirySweepDuration prometheus.Histogram
+
+	// Tracking metrics
+	adImpressionsTotal   *prometheus.CounterVec
+	adClicksTotal        *prometheus.CounterVec
+	trackingDroppedTotal prometheus.Counter
 
 	// HTTP metrics
 	httpRequestsTotal   *prometheus.CounterVec
 	httpRequestDuration *prometheus.HistogramVec
 
 	// System metrics
-	uptime prometheus.Counter
+	uptime prometheus.CounterFunc
 }
 
+// UnknownPlacement labels the ad decisions of placements that are not known,
+// bounding the series of the public serving route
+const UnknownPlacement = "unknown"
+
 var (
 	collector *Collector
 	startTime = time.Now()
@@ -33,27 +48,74 @@ var (
 func Init() {
 	collector = &Collector{
 		// Ad counters
-		adsCreatedTotal: promauto.NewCounter(prometheus.CounterOpts{
-			Name: "admoai_ads_created_total",
-			Help: "Total number of ads created",
-		}),
-		adsDeactivatedTotal: promauto.NewCounter(prometheus.CounterOpts{
-			Name: "admoai_ads_deactivated_total",
-			Help: "Total number of ads deactivated",
-		}),
+		adsCreatedTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "admoai_ads_created_total",
+				Help: "Total number of ads created by account",
+			},
+			[]string{AccountLabel},
+		),
+		adsDeactivatedTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "admoai_ads_deactivated_total",
+				Help: "Total number of ads deactivated by account",
+			},
+			[]string{AccountLabel},
+		),
+		adsUpdatedTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "admoai_ads_updated_total",
+				Help: "Total number of ads updated by account",
+			},
+			[]string{AccountLabel},
+		),
+
+		adBatchItemsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "admoai_ad_batch_items_total",
+				Help: "Total number of items processed by the batch endpoints by account, operation and result",
+			},
+			[]string{AccountLabel, "operation", "result"},
+		),
+
+		// Serving metrics
+		adServesTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "admoai_ad_serves_total",
+				Help: "Total number of ad decisions by placement and outcome",
+			},
+			[]string{"placement", "outcome"},
+		),
 
-		// Ad gauges
-		adsActiveCurrent: promauto.NewGauge(prometheus.GaugeOpts{
-			Name: "admoai_ads_active_current",
-			Help: "Current number of active ads",
+		// Expiry metrics
+		adsExpiredTotal: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "admoai_ads_expired_total",
+			Help: "Total number of ads transitioned to expired by the reaper",
 		}),
-		adsInactiveCurrent: promauto.NewGauge(prometheus.GaugeOpts{
-			Name: "admoai_ads_inactive_current",
-			Help: "Current number of inactive ads",
+		expirySweepDuration: promauto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "admoai_expiry_sweep_duration_seconds",
+			Help:    "Duration of the expiry reaper sweeps",
+			Buckets: prometheus.DefBuckets,
 		}),
-		adsExpiredCurrent: promauto.NewGauge(prometheus.GaugeOpts{
-			Name: "admoai_ads_expired_current",
-			Help: "Current number of expired ads",
+
+		// Tracking metrics
+		adImpressionsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "admoai_ad_impressions_total",
+				Help: "Total number of tracked impressions by account and placement",
+			},
+			[]string{AccountLabel, "placement"},
+		),
+		adClicksTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "admoai_ad_clicks_total",
+				Help: "Total number of tracked clicks by account and placement",
+			},
+			[]string{AccountLabel, "placement"},
+		),
+		trackingDroppedTotal: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "admoai_tracking_events_dropped_total",
+			Help: "Total number of tracking events dropped because the buffer was full",
 		}),
 
 		// HTTP metrics
@@ -74,20 +136,18 @@ func Init() {
 		),
 
 		// System metrics
-		uptime: promauto.NewCounter(prometheus.CounterOpts{
+		// Uptime is computed at scrape time, no background goroutine needed
+		uptime: promauto.NewCounterFunc(prometheus.CounterOpts{
 			Name: "admoai_uptime_seconds",
 			Help: "Total uptime in seconds",
+		}, func() float64 {
+			return time.Since(startTime).Seconds()
 		}),
 	}
 
-	// Start uptime counter
-	go func() {
-		ticker := time.NewTicker(1 * time.Second)
-		defer ticker.Stop()
-		for range ticker.C {
-			collector.uptime.Add(1)
-		}
-	}()
+	// Ad gauges, read from the database at scrape time
+	collector.adCounts = newAdCountsCollector()
+	prometheus.MustRegister(collector.adCounts)
 }
 
 // GetCollector returns the singleton collector instance
@@ -95,24 +155,64 @@ func GetCollector() *Collector {
 	return collector
 }
 
-// IncrementAdCreated increments the total ads created counter
-func (c *Collector) IncrementAdCreated() {
-	c.adsCreatedTotal.Inc()
+// IncrementAdCreated increments the ads created counter of an account
+func (c *Collector) IncrementAdCreated(account string) {
+	c.adsCreatedTotal.WithLabelValues(account).Inc()
 }
 
-// IncrementAdDeactivated increments the total ads deactivated counter
-func (c *Collector) IncrementAdDeactivated() {
-	c.adsDeactivatedTotal.Inc()
+// IncrementAdDeactivated increments the ads deactivated counter of an account
+func (c *Collector) IncrementAdDeactivated(account string) {
+	c.adsDeactivatedTotal.WithLabelValues(account).Inc()
 }
 
-// UpdateAdCounts updates the current ad counts
-func (c *Collector) UpdateAdCounts(active, inactive, expired int64) {
-	c.adsActiveCurrent.Set(float64(active))
-	c.adsInactiveCurrent.Set(float64(inactive))
-	c.adsExpiredCurrent.Set(float64(expired))
+// IncrementAdUpdated increments the ads updated counter of an account
+func (c *Collector) IncrementAdUpdated(account string) {
+	c.adsUpdatedTotal.WithLabelValues(account).Inc()
+}
+
+// RecordBatchItems counts the items of a batch that succeeded and failed
+func (c *Collector) RecordBatchItems(account, operation string, succeeded, failed int) {
+	c.adBatchItemsTotal.WithLabelValues(account, operation, "succeeded").Add(float64(succeeded))
+	c.adBatchItemsTotal.WithLabelValues(account, operation, "failed").Add(float64(failed))
+}
 
-	// Check for alerts
-	c.checkAlerts(active)
+// WatchAdCounts sets how the ad count gauges are read, at most once per
+// maxAge, DefaultAdCountsMaxAge when zero
+func (c *Collector) WatchAdCounts(read AdCountsFunc, maxAge time.Duration) {
+	if maxAge <= 0 {
+		maxAge = DefaultAdCountsMaxAge
+	}
+	c.adCounts.setSource(read, maxAge)
+}
+
+// RecordAdServed records an ad decision, filled is false when no ad was eligible
+func (c *Collector) RecordAdServed(placement string, filled bool) {
+	outcome := "filled"
+	if !filled {
+		outcome = "empty"
+	}
+	c.adServesTotal.WithLabelValues(placement, outcome).Inc()
+}
+
+// RecordExpirySweep records one expiry reaper sweep
+func (c *Collector) RecordExpirySweep(expired int64, duration time.Duration) {
+	c.adsExpiredTotal.Add(float64(expired))
+	c.expirySweepDuration.Observe(duration.Seconds())
+}
+
+// RecordImpression increments the impressions counter of an account placement
+func (c *Collector) RecordImpression(account, placement string) {
+	c.adImpressionsTotal.WithLabelValues(account, placement).Inc()
+}
+
+// RecordClick increments the clicks counter of an account placement
+func (c *Collector) RecordClick(account, placement string) {
+	c.adClicksTotal.WithLabelValues(account, placement).Inc()
+}
+
+// IncrementTrackingDropped increments the dropped tracking events counter
+func (c *Collector) IncrementTrackingDropped() {
+	c.trackingDroppedTotal.Inc()
 }
 
 // RecordHTTPRequest records an HTTP request
@@ -120,10 +220,3 @@ func (c *Collector) RecordHTTPRequest(method, endpoint, status string, duration
 	c.httpRequestsTotal.WithLabelValues(method, endpoint, status).Inc()
 	c.httpRequestDuration.WithLabelValues(method, endpoint).Observe(duration.Seconds())
 }
-
-// checkAlerts checks for alert conditions
-func (c *Collector) checkAlerts(activeCount int64) {
-	if activeCount > 10 {
-		fmt.Printf("⚠️  ALERT: High number of active ads detected: %d (threshold: 10)\n", activeCount)
-	}
-} 
\ No newline at end of file
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// AccountLabel is the label carrying the tenant of a series
+const AccountLabel = "account"
+
+// TenantGatherer gathers the default registry keeping the series without an
+// account label plus the ones of the given account. An empty account only
+// keeps the series that are not tenant specific.
+func TenantGatherer(account string) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := prometheus.DefaultGatherer.Gather()
+		if err != nil {
+			return nil, err
+		}
+
+		filtered := make([]*dto.MetricFamily, 0, len(families))
+		for _, family := range families {
+			metrics := make([]*dto.Metric, 0, len(family.Metric))
+			for _, metric := range family.Metric {
+				if owner, ok := accountOf(metric); !ok || owner == account {
+					metrics = append(metrics, metric)
+				}
+			}
+			if len(metrics) == 0 {
+				continue
+			}
+			family.Metric = metrics
+			filtered = append(filtered, family)
+		}
+
+		return filtered, nil
+	})
+}
+
+// accountOf returns the account label of a series, ok is false when the
+// series is not tenant specific
+func accountOf(metric *dto.Metric) (string, bool) {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == AccountLabel {
+			return label.GetValue(), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,205 @@
+// Package openapi describes the HTTP API as an OpenAPI 3 document. Schemas are
+// derived from the Go types of the handlers, so the document follows the code
+// instead of being maintained by hand.
+package openapi
+
+import (
+	"strings"
+)
+
+// Version is the OpenAPI version of the documents
+const Version = "3.0.3"
+
+// Document is an OpenAPI 3 document, limited to the parts the API uses
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Tag groups operations in the explorer
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps the lowercase HTTP methods of a path to their operation
+type PathItem map[string]*Operation
+
+// Operation is one method of a path
+type Operation struct {
+	OperationID string       `json:"operationId"`
+	Summary     string       `json:"summary,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	Parameters  []*Parameter `json:"parameters,omitempty"`
+	RequestBody *RequestBody `json:"requestBody,omitempty"`
+	// Responses are keyed by status code or "default"
+	Responses map[string]*Response `json:"responses"`
+	// Security overrides the requirements of the document, an empty list
+	// makes the operation public
+	Security []SecurityRequirement `json:"security"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Locations of the parameters
+const (
+	InPath   = "path"
+	InQuery  = "query"
+	InHeader = "header"
+)
+
+// RequestBody is the body accepted by an operation in each content type
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// Response is one response of an operation, without content when it has no
+// body
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a body in one content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the schemas referenced by the operations
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme is a way of authenticating the requests
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// SecurityRequirement maps a security scheme to the scopes it needs
+type SecurityRequirement map[string][]string
+
+// Schema is a JSON schema as restricted by OpenAPI 3.0
+type Schema struct {
+	Ref         string  `json:"$ref,omitempty"`
+	Type        string  `json:"type,omitempty"`
+	Format      string  `json:"format,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Nullable    bool    `json:"nullable,omitempty"`
+	Enum        []any   `json:"enum,omitempty"`
+	Minimum     *int64  `json:"minimum,omitempty"`
+	Maximum     *int64  `json:"maximum,omitempty"`
+	MinLength   *int64  `json:"minLength,omitempty"`
+	MaxLength   *int64  `json:"maxLength,omitempty"`
+	MinItems    *int64  `json:"minItems,omitempty"`
+	MaxItems    *int64  `json:"maxItems,omitempty"`
+	Items       *Schema `json:"items,omitempty"`
+	// Properties of an object, Required lists the ones that must be present
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+
+	// OmitEmpty makes the zero value (0, "" or an empty array) stand for an
+	// omitted field that skips the other constraints, like the omitempty
+	// binding rule
+	OmitEmpty bool `json:"x-omitempty,omitempty"`
+	// DeferItems leaves the items of an array to the handler, which reports
+	// the invalid ones item by item instead of rejecting the request
+	DeferItems bool `json:"x-defer-items,omitempty"`
+}
+
+// Types of the schemas
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeArray   = "array"
+	TypeObject  = "object"
+)
+
+// refPrefix starts the references to the component schemas
+const refPrefix = "#/components/schemas/"
+
+// Ref returns a schema referencing a component schema
+func Ref(name string) *Schema {
+	return &Schema{Ref: refPrefix + name}
+}
+
+// Resolve follows the reference of a schema to the component it names
+func (d *Document) Resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = d.Components.Schemas[strings.TrimPrefix(schema.Ref, refPrefix)]
+	}
+	return schema
+}
+
+// Operation returns the operation of a method and path, nil when the
+// document does not describe it
+func (d *Document) Operation(method, path string) *Operation {
+	item, ok := d.Paths[path]
+	if !ok {
+		return nil
+	}
+	return item[strings.ToLower(method)]
+}
+
+// AddOperation describes a method of a path
+func (d *Document) AddOperation(method, path string, op *Operation) {
+	if d.Paths == nil {
+		d.Paths = map[string]PathItem{}
+	}
+	item, ok := d.Paths[path]
+	if !ok {
+		item = PathItem{}
+		d.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = op
+}
+
+// PathFromRoute turns a gin route into an OpenAPI path, /ads/:id becomes
+// /ads/{id}
+func PathFromRoute(route string) string {
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if len(segment) > 1 && (segment[0] == ':' || segment[0] == '*') {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// PathParams returns the names of the parameters of an OpenAPI path
+func PathParams(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, segment[1:len(segment)-1])
+		}
+	}
+	return names
+}
@@ -0,0 +1,246 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// Generator derives schemas from Go types. Named structs become component
+// schemas referenced by the others, read from their json tags and from the
+// binding tags gin validates them with.
+type Generator struct {
+	schemas map[string]*Schema
+	// types tells apart structs of different packages with the same name
+	types map[string]reflect.Type
+}
+
+// NewGenerator returns a Generator without schemas
+func NewGenerator() *Generator {
+	return &Generator{
+		schemas: map[string]*Schema{},
+		types:   map[string]reflect.Type{},
+	}
+}
+
+// Schemas returns the component schemas of the types seen so far
+func (g *Generator) Schemas() map[string]*Schema {
+	return g.schemas
+}
+
+// Schema returns the schema of the type of value, a reference for structs
+func (g *Generator) Schema(value any) *Schema {
+	return g.schemaOf(reflect.TypeOf(value))
+}
+
+func (g *Generator) schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	// Types with their own encoding, like raw JSON, can hold anything
+	if t.Kind() != reflect.Struct && t.Implements(jsonMarshalerType) {
+		return &Schema{Nullable: nullable}
+	}
+
+	var schema *Schema
+	switch t.Kind() {
+	case reflect.String:
+		schema = &Schema{Type: TypeString}
+	case reflect.Bool:
+		schema = &Schema{Type: TypeBoolean}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		schema = &Schema{Type: TypeInteger, Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		schema = &Schema{Type: TypeInteger, Format: "int32"}
+	case reflect.Float32:
+		schema = &Schema{Type: TypeNumber, Format: "float"}
+	case reflect.Float64:
+		schema = &Schema{Type: TypeNumber, Format: "double"}
+	case reflect.Slice, reflect.Array:
+		schema = &Schema{Type: TypeArray, Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		schema = &Schema{Type: TypeObject, AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		// References cannot be nullable in OpenAPI 3.0, optional objects
+		// are told apart by not being required
+		return g.structRef(t)
+	default:
+		// Interfaces accept any value
+		schema = &Schema{}
+	}
+
+	schema.Nullable = nullable
+	return schema
+}
+
+// structRef registers the component schema of a struct and references it
+func (g *Generator) structRef(t reflect.Type) *Schema {
+	name := t.Name()
+	if name == "" {
+		return g.structSchema(t)
+	}
+
+	if seen, ok := g.types[name]; ok && seen != t {
+		// Same name in another package, qualify it with the package name
+		path := strings.Split(t.PkgPath(), "/")
+		name = strings.ToUpper(path[len(path)-1][:1]) + path[len(path)-1][1:] + name
+	}
+	if _, ok := g.types[name]; !ok {
+		g.types[name] = t
+		// Registered before the fields so recursive types terminate
+		g.schemas[name] = &Schema{}
+		*g.schemas[name] = *g.structSchema(t)
+	}
+
+	return Ref(name)
+}
+
+// structSchema returns the object schema of the fields of a struct,
+// embedded structs are flattened like encoding/json does
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: TypeObject, Properties: map[string]*Schema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				inner := g.structSchema(embedded)
+				for prop, propSchema := range inner.Properties {
+					schema.Properties[prop] = propSchema
+				}
+				schema.Required = append(schema.Required, inner.Required...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		propSchema := g.schemaOf(field.Type)
+		if applyBinding(propSchema, field.Tag.Get("binding")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = propSchema
+	}
+
+	return schema
+}
+
+// jsonName returns the name of a field in its json tag, empty when the tag
+// does not name it. ok is false for the fields left out of the encoding.
+func jsonName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	return name, true
+}
+
+// applyBinding adds the constraints of a gin binding tag to a schema and
+// reports whether the field is required. Rules after dive apply to the
+// items of an array, which gin leaves unchecked without it when they are
+// structs.
+func applyBinding(schema *Schema, tag string) bool {
+	rules := strings.Split(tag, ",")
+	if schema.Type == TypeArray && schema.Items != nil && schema.Items.Ref != "" && !slices.Contains(rules, "dive") {
+		schema.DeferItems = true
+	}
+	if tag == "" {
+		return false
+	}
+
+	required := false
+	target := schema
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			if target == schema {
+				required = true
+			}
+			// Strings are required not to be empty
+			if target.Type == TypeString && target.MinLength == nil {
+				one := int64(1)
+				target.MinLength = &one
+			}
+		case "omitempty":
+			// Pointers only skip the other rules when nil
+			if !target.Nullable {
+				target.OmitEmpty = true
+			}
+		case "dive":
+			if target.Items == nil {
+				return required
+			}
+			target = target.Items
+		case "url":
+			target.Format = "uri"
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				target.Enum = append(target.Enum, enumValue(target, value))
+			}
+		case "min", "max":
+			bound, err := strconv.ParseInt(param, 10, 64)
+			if err != nil {
+				continue
+			}
+			setBound(target, name == "min", bound)
+		}
+	}
+	return required
+}
+
+// setBound sets the minimum or maximum that applies to the type of a schema
+func setBound(schema *Schema, lower bool, bound int64) {
+	var min, max **int64
+	switch schema.Type {
+	case TypeString:
+		min, max = &schema.MinLength, &schema.MaxLength
+	case TypeArray:
+		min, max = &schema.MinItems, &schema.MaxItems
+	case TypeInteger, TypeNumber:
+		min, max = &schema.Minimum, &schema.Maximum
+	default:
+		return
+	}
+	if lower {
+		*min = &bound
+	} else {
+		*max = &bound
+	}
+}
+
+// enumValue returns a value of a oneof rule in the type of the schema
+func enumValue(schema *Schema, value string) any {
+	if schema.Type == TypeInteger {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return parsed
+		}
+	}
+	return value
+}
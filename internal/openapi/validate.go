@@ -0,0 +1,271 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Violation is a value that does not match its schema
+type Violation struct {
+	// Field is the path of the value, like ads[2].title
+	Field   string
+	Code    string
+	Message string
+}
+
+// Codes of the violations
+const (
+	CodeRequired      = "required"
+	CodeInvalidType   = "invalid_type"
+	CodeInvalidValue  = "invalid_value"
+	CodeInvalidFormat = "invalid_format"
+	CodeTooShort      = "too_short"
+	CodeTooLong       = "too_long"
+	CodeTooSmall      = "too_small"
+	CodeTooLarge      = "too_large"
+	CodeTooFewItems   = "too_few_items"
+	CodeTooManyItems  = "too_many_items"
+)
+
+// ValidateParam checks the raw value of a path, query or header parameter,
+// present tells an empty value apart from a missing one
+func (d *Document) ValidateParam(param *Parameter, raw string, present bool) []Violation {
+	if !present || raw == "" {
+		if param.Required {
+			return []Violation{{Field: param.Name, Code: CodeRequired, Message: param.Name + " is required"}}
+		}
+		return nil
+	}
+
+	schema := d.Resolve(param.Schema)
+	if schema == nil {
+		return nil
+	}
+
+	var value any = raw
+	switch schema.Type {
+	case TypeInteger:
+		if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+			return []Violation{invalidType(param.Name, "an integer")}
+		}
+		value = json.Number(raw)
+	case TypeNumber:
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return []Violation{invalidType(param.Name, "a number")}
+		}
+		value = json.Number(raw)
+	case TypeBoolean:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return []Violation{invalidType(param.Name, "a boolean")}
+		}
+		value = parsed
+	}
+
+	return d.ValidateValue(param.Name, schema, value)
+}
+
+// ValidateValue checks a value decoded from JSON, with numbers decoded as
+// json.Number. Like encoding/json, a null stands for an omitted value unless
+// the schema is nullable.
+func (d *Document) ValidateValue(field string, schema *Schema, value any) []Violation {
+	schema = d.Resolve(schema)
+	if schema == nil || value == nil {
+		return nil
+	}
+	if schema.OmitEmpty && isZero(value) {
+		return nil
+	}
+
+	switch schema.Type {
+	case TypeString:
+		text, ok := value.(string)
+		if !ok {
+			return []Violation{invalidType(field, "a string")}
+		}
+		return d.validateString(field, schema, text)
+	case TypeInteger, TypeNumber:
+		number, ok := value.(json.Number)
+		if !ok {
+			return []Violation{invalidType(field, "a number")}
+		}
+		return d.validateNumber(field, schema, number)
+	case TypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return []Violation{invalidType(field, "a boolean")}
+		}
+	case TypeArray:
+		items, ok := value.([]any)
+		if !ok {
+			return []Violation{invalidType(field, "an array")}
+		}
+		return d.validateArray(field, schema, items)
+	case TypeObject:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return []Violation{invalidType(field, "an object")}
+		}
+		return d.validateObject(field, schema, object)
+	}
+	return nil
+}
+
+func (d *Document) validateString(field string, schema *Schema, text string) []Violation {
+	if len(schema.Enum) > 0 && !enumContains(schema.Enum, text) {
+		return []Violation{invalidValue(field, schema.Enum)}
+	}
+
+	length := int64(utf8.RuneCountInString(text))
+	if schema.MinLength != nil && length < *schema.MinLength {
+		if *schema.MinLength == 1 {
+			return []Violation{{Field: field, Code: CodeTooShort, Message: field + " must not be empty"}}
+		}
+		return []Violation{{Field: field, Code: CodeTooShort, Message: fmt.Sprintf("%s must be at least %d characters", field, *schema.MinLength)}}
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		return []Violation{{Field: field, Code: CodeTooLong, Message: fmt.Sprintf("%s must be at most %d characters", field, *schema.MaxLength)}}
+	}
+
+	if schema.Format == "uri" {
+		parsed, err := url.ParseRequestURI(text)
+		if err != nil || parsed.Scheme == "" {
+			return []Violation{{Field: field, Code: CodeInvalidFormat, Message: field + " must be a valid URL"}}
+		}
+	}
+	return nil
+}
+
+func (d *Document) validateNumber(field string, schema *Schema, number json.Number) []Violation {
+	if schema.Type == TypeInteger {
+		value, err := number.Int64()
+		if err != nil {
+			return []Violation{invalidType(field, "an integer")}
+		}
+		if len(schema.Enum) > 0 && !enumContains(schema.Enum, value) {
+			return []Violation{invalidValue(field, schema.Enum)}
+		}
+		if schema.Minimum != nil && value < *schema.Minimum {
+			return []Violation{{Field: field, Code: CodeTooSmall, Message: fmt.Sprintf("%s must be at least %d", field, *schema.Minimum)}}
+		}
+		if schema.Maximum != nil && value > *schema.Maximum {
+			return []Violation{{Field: field, Code: CodeTooLarge, Message: fmt.Sprintf("%s must be at most %d", field, *schema.Maximum)}}
+		}
+		return nil
+	}
+
+	value, err := number.Float64()
+	if err != nil {
+		return []Violation{invalidType(field, "a number")}
+	}
+	if schema.Minimum != nil && value < float64(*schema.Minimum) {
+		return []Violation{{Field: field, Code: CodeTooSmall, Message: fmt.Sprintf("%s must be at least %d", field, *schema.Minimum)}}
+	}
+	if schema.Maximum != nil && value > float64(*schema.Maximum) {
+		return []Violation{{Field: field, Code: CodeTooLarge, Message: fmt.Sprintf("%s must be at most %d", field, *schema.Maximum)}}
+	}
+	return nil
+}
+
+func (d *Document) validateArray(field string, schema *Schema, items []any) []Violation {
+	count := int64(len(items))
+	if schema.MinItems != nil && count < *schema.MinItems {
+		return []Violation{{Field: field, Code: CodeTooFewItems, Message: fmt.Sprintf("%s must have at least %d items", field, *schema.MinItems)}}
+	}
+	if schema.MaxItems != nil && count > *schema.MaxItems {
+		return []Violation{{Field: field, Code: CodeTooManyItems, Message: fmt.Sprintf("%s must have at most %d items", field, *schema.MaxItems)}}
+	}
+	if schema.DeferItems || schema.Items == nil {
+		return nil
+	}
+
+	var violations []Violation
+	for i, item := range items {
+		violations = append(violations, d.ValidateValue(fmt.Sprintf("%s[%d]", field, i), schema.Items, item)...)
+	}
+	return violations
+}
+
+func (d *Document) validateObject(field string, schema *Schema, object map[string]any) []Violation {
+	var violations []Violation
+	for _, name := range schema.Required {
+		if object[name] == nil {
+			path := joinField(field, name)
+			violations = append(violations, Violation{Field: path, Code: CodeRequired, Message: path + " is required"})
+		}
+	}
+
+	// Sorted so the violations of a request are always reported in the
+	// same order
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop, ok := schema.Properties[name]
+		if !ok {
+			prop = schema.AdditionalProperties
+		}
+		if prop == nil {
+			continue
+		}
+		violations = append(violations, d.ValidateValue(joinField(field, name), prop, object[name])...)
+	}
+	return violations
+}
+
+// isZero reports whether a decoded value is the zero value of its type
+func isZero(value any) bool {
+	switch v := value.(type) {
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case json.Number:
+		parsed, err := v.Float64()
+		return err == nil && parsed == 0
+	case []any:
+		return len(v) == 0
+	}
+	return false
+}
+
+func enumContains(enum []any, value any) bool {
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// invalidType reports a value of the wrong type, an empty field is the
+// whole body
+func invalidType(field, expected string) Violation {
+	name := field
+	if name == "" {
+		name = "body"
+	}
+	return Violation{Field: field, Code: CodeInvalidType, Message: name + " must be " + expected}
+}
+
+func invalidValue(field string, enum []any) Violation {
+	values := make([]string, 0, len(enum))
+	for _, value := range enum {
+		values = append(values, fmt.Sprint(value))
+	}
+	return Violation{Field: field, Code: CodeInvalidValue, Message: field + " must be one of " + strings.Join(values, ", ")}
+}
@@ -0,0 +1,174 @@
+// Package serving keeps an in-memory index of the ads that can be served so
+// ad decisions do not hit the database on every request.
+package serving
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+// DefaultRefreshInterval is how often the index is reloaded from the database
+const DefaultRefreshInterval = 30 * time.Second
+
+// Index holds the active, non expired ads grouped by placement. Ads are
+// loaded including the ones scheduled in the future; the schedule is checked
+// again when picking since the snapshot can be up to one interval old.
+type Index struct {
+	db       store.Transaction
+	interval time.Duration
+
+	mu          sync.RWMutex
+	byPlacement map[string][]*store.AdvertiseRecord
+	byID        map[string]*store.AdvertiseRecord
+	// registered holds the placements of the registry
+	registered  map[string]bool
+	refreshedAt time.Time
+
+	// randN returns a random number in [0, n), replaced in tests
+	randN func(n int64) int64
+	// now returns the current time, replaced in tests
+	now func() time.Time
+}
+
+func NewIndex(db store.Transaction, interval time.Duration) *Index {
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+
+	return &Index{
+		db:          db,
+		interval:    interval,
+		byPlacement: map[string][]*store.AdvertiseRecord{},
+		byID:        map[string]*store.AdvertiseRecord{},
+		registered:  map[string]bool{},
+		randN:       rand.Int64N,
+		now:         time.Now,
+	}
+}
+
+// Refresh reloads the whole index from the database
+func (idx *Index) Refresh(ctx context.Context) error {
+	// Every account competes for the placements
+	records, err := query.SelectAds(ctx, idx.db, &query.SelectAdsArgs{
+		AllAccounts:            true,
+		Status:                 store.AdvertiseStatusActive,
+		FilterByExpired:        true,
+		ExcludePausedCampaigns: true,
+		// A stable order keeps the rotation deterministic for a given random point
+		Order: &query.SortOrder{Field: "created_at"},
+	})
+	if err != nil {
+		return errors.Wrap(err, "serving: Index.Refresh query error")
+	}
+
+	placements, err := query.SelectPlacements(ctx, idx.db, &query.SelectPlacementsArgs{})
+	if err != nil {
+		return errors.Wrap(err, "serving: Index.Refresh placements error")
+	}
+	registered := make(map[string]bool, len(placements))
+	for _, placement := range placements {
+		registered[placement.Name] = true
+	}
+
+	byPlacement := make(map[string][]*store.AdvertiseRecord)
+	byID := make(map[string]*store.AdvertiseRecord, len(records))
+	for _, rec := range records {
+		byPlacement[rec.Placement] = append(byPlacement[rec.Placement], rec)
+		byID[rec.ID] = rec
+	}
+
+	idx.mu.Lock()
+	idx.byPlacement = byPlacement
+	idx.byID = byID
+	idx.registered = registered
+	idx.refreshedAt = idx.now()
+	idx.mu.Unlock()
+
+	return nil
+}
+
+// Run refreshes the index every interval until ctx is cancelled
+func (idx *Index) Run(ctx context.Context) {
+	ticker := time.NewTicker(idx.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := idx.Refresh(ctx); err != nil {
+				slog.Error("serving index refresh failed", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// RefreshedAt returns when the index was last loaded
+func (idx *Index) RefreshedAt() time.Time {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.refreshedAt
+}
+
+// Lookup returns the indexed ad with the given ID, nil when it is not
+// active or was created after the last refresh
+func (idx *Index) Lookup(id string) *store.AdvertiseRecord {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.byID[id]
+}
+
+// Known reports whether a placement is in the registry or has ads in the
+// index, as of the last refresh
+func (idx *Index) Known(placement string) bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.registered[placement] || len(idx.byPlacement[placement]) > 0
+}
+
+// Pick selects one live ad of the placement using weighted random rotation.
+// It returns nil when no ad is eligible.
+func (idx *Index) Pick(placement string) *store.AdvertiseRecord {
+	idx.mu.RLock()
+	candidates := idx.byPlacement[placement]
+	idx.mu.RUnlock()
+
+	now := idx.now()
+	eligible := make([]*store.AdvertiseRecord, 0, len(candidates))
+	var total int64
+	for _, rec := range candidates {
+		if !rec.LiveAt(now) {
+			continue
+		}
+		eligible = append(eligible, rec)
+		total += weightOf(rec)
+	}
+	if total == 0 {
+		return nil
+	}
+
+	// Walk the cumulative weights until the random point is reached
+	point := idx.randN(total)
+	for _, rec := range eligible {
+		point -= weightOf(rec)
+		if point < 0 {
+			return rec
+		}
+	}
+	return eligible[len(eligible)-1]
+}
+
+func weightOf(rec *store.AdvertiseRecord) int64 {
+	if rec.Weight < 1 {
+		return store.AdvertiseDefaultWeight
+	}
+	return rec.Weight
+}
@@ -0,0 +1,80 @@
+package serving
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/mtavano/admoai-takehome/internal/store/storetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(storetest.Main(m))
+}
+
+func TestIndexPick(t *testing.T) {
+	for _, driver := range storetest.Backends() {
+		t.Run(driver, func(t *testing.T) {
+			db := storetest.Open(t, driver)
+			now := time.Now()
+			past := now.Add(-time.Hour).Unix()
+			soon := now.Add(time.Minute).Unix()
+			later := now.Add(time.Hour).Unix()
+
+			ads := []*store.AdvertiseRecord{
+				{ID: "light", Placement: "homepage", Status: store.AdvertiseStatusActive, Weight: 1},
+				// Ads of every account compete for the same placement
+				{ID: "heavy", AccountID: "acme", Placement: "homepage", Status: store.AdvertiseStatusActive, Weight: 3, ExpiresAt: &later},
+				{ID: "starting-soon", Placement: "homepage", Status: store.AdvertiseStatusActive, Weight: 5, StartsAt: &soon},
+				{ID: "expiring-soon", Placement: "homepage", Status: store.AdvertiseStatusActive, Weight: 5, ExpiresAt: &soon},
+				{ID: "expired", Placement: "homepage", Status: store.AdvertiseStatusActive, Weight: 5, ExpiresAt: &past},
+				{ID: "inactive", Placement: "homepage", Status: store.AdvertiseStatusInactive, Weight: 5},
+				{ID: "sidebar", Placement: "sidebar", Status: store.AdvertiseStatusActive, Weight: 1},
+			}
+			for _, ad := range ads {
+				if ad.AccountID == "" {
+					ad.AccountID = store.AccountDefaultID
+				}
+				ad.Title = ad.ID
+				ad.ImageURL = "https://example.com/" + ad.ID + ".jpg"
+				ad.CreatedAt = now.Unix()
+				require.NoError(t, query.InsertAds(context.Background(), db, ad))
+			}
+
+			idx := NewIndex(db, time.Minute)
+			require.NoError(t, idx.Refresh(context.Background()))
+			assert.False(t, idx.RefreshedAt().IsZero())
+			assert.True(t, idx.Known("sidebar"))
+			assert.False(t, idx.Known("footer"))
+
+			// Two minutes later the scheduled ad went live and the other one expired
+			idx.now = func() time.Time { return now.Add(2 * time.Minute) }
+
+			// Eligible ads sorted by ID: heavy(3), light(1), starting-soon(5)
+			picks := []int64{0, 2, 3, 4, 8}
+			expected := []string{"heavy", "heavy", "light", "starting-soon", "starting-soon"}
+
+			var totals []int64
+			idx.randN = func(n int64) int64 {
+				totals = append(totals, n)
+				return picks[len(totals)-1]
+			}
+
+			for _, want := range expected {
+				rec := idx.Pick("homepage")
+				require.NotNil(t, rec)
+				assert.Equal(t, want, rec.ID)
+			}
+			for _, total := range totals {
+				assert.Equal(t, int64(9), total)
+			}
+
+			assert.Nil(t, idx.Pick("footer"))
+		})
+	}
+}
@@ -5,16 +5,20 @@ import (
 	"database/sql"
 )
 
-// minimal interfaces to work with postgres/sqlx  properly
+// minimal interfaces to work with postgres/sqlx  properly. Every statement
+// takes the context of the operation it belongs to, so it is cancelled with
+// it and bounded by the query timeout of the store.
 
 type QueryContext interface {
-	Exec(query string, params ...any) (sql.Result, error)
-	Query(query string, params ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, params ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, params ...any) (*sql.Rows, error)
 }
 
 type Transaction interface {
-	Get(dest any, query string, args ...any) error
-	Select(dest any, query string, args ...any) error
+	// DriverName is used to resolve the Dialect of the connection
+	DriverName() string
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
 	QueryContext
 }
 
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Dialect describes the differences between the SQL engines SqlStore can talk to
+type Dialect struct {
+	// Name is the canonical name of the engine
+	Name string
+	// Driver is the database/sql driver registered for the engine
+	Driver string
+	// Goose is the dialect name understood by goose.SetDialect
+	Goose string
+	// Placeholder is the bind variable format used when building queries
+	Placeholder squirrel.PlaceholderFormat
+}
+
+var (
+	DialectSQLite = Dialect{
+		Name:        "sqlite",
+		Driver:      "sqlite3",
+		Goose:       "sqlite3",
+		Placeholder: squirrel.Question,
+	}
+	DialectPostgres = Dialect{
+		Name:        "postgres",
+		Driver:      "pgx",
+		Goose:       "postgres",
+		Placeholder: squirrel.Dollar,
+	}
+)
+
+// dialectsByDriver maps every accepted DB_DRIVER value to its dialect
+var dialectsByDriver = map[string]Dialect{
+	"sqlite":   DialectSQLite,
+	"sqlite3":  DialectSQLite,
+	"postgres": DialectPostgres,
+	"pgx":      DialectPostgres,
+}
+
+// DialectFor returns the dialect for the given driver name
+func DialectFor(driver string) (Dialect, error) {
+	d, ok := dialectsByDriver[driver]
+	if !ok {
+		return Dialect{}, fmt.Errorf("store: unsupported database driver %q", driver)
+	}
+	return d, nil
+}
+
+// DialectOf returns the dialect spoken by tx, falling back to SQLite when the
+// driver is unknown (e.g. mocks)
+func DialectOf(tx Transaction) Dialect {
+	if d, err := DialectFor(tx.DriverName()); err == nil {
+		return d
+	}
+	return DialectSQLite
+}
+
+// Builder returns a squirrel statement builder using the dialect placeholders
+func (d Dialect) Builder() squirrel.StatementBuilderType {
+	return squirrel.StatementBuilder.PlaceholderFormat(d.Placeholder)
+}
@@ -3,32 +3,68 @@ package store
 import (
 	"context"
 	"database/sql"
+	"log/slog"
 	"time"
 
+	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/mtavano/admoai-takehome/internal/logging"
 	"github.com/pkg/errors"
 )
 
+// DefaultQueryTimeout bounds every statement unless configured otherwise
+const DefaultQueryTimeout = 5 * time.Second
+
 // Store is the database wrapper
 type SqlStore struct {
 	*sqlx.DB
+	Dialect Dialect
+	// QueryTimeout bounds each statement on top of the deadline of its
+	// context, zero disables it
+	QueryTimeout time.Duration
+}
+
+// PoolOptions configures the connection pool, with the semantics of the
+// setters of sql.DB: zero sizes and durations mean no limit, except for
+// MaxIdleConns where zero keeps no idle connection
+type PoolOptions struct {
+	// MaxOpenConns bounds the connections open at once
+	MaxOpenConns int
+	// MaxIdleConns bounds the connections kept open while idle
+	MaxIdleConns int
+	// ConnMaxIdleTime closes the connections idle for longer
+	ConnMaxIdleTime time.Duration
+	// ConnMaxLifetime closes the connections open for longer
+	ConnMaxLifetime time.Duration
+}
+
+// DefaultPoolOptions returns the pool settings of the service
+func DefaultPoolOptions() PoolOptions {
+	return PoolOptions{
+		MaxIdleConns:    20,
+		ConnMaxIdleTime: 1 * time.Second,
+		ConnMaxLifetime: 30 * time.Second,
+	}
 }
 
-func NewSqlStore(driver, dsn string) (*SqlStore, error) {
-	db, err := sqlx.Open(driver, dsn)
+func NewSqlStore(driver, dsn string, pool PoolOptions) (*SqlStore, error) {
+	dialect, err := DialectFor(driver)
 	if err != nil {
 		return nil, err
 	}
 
-	// Maximum Idle Connections
-	db.SetMaxIdleConns(20)
-	// Idle Connection Timeout
-	db.SetConnMaxIdleTime(1 * time.Second)
-	// Connection Lifetime
-	db.SetConnMaxLifetime(30 * time.Second)
+	db, err := sqlx.Open(dialect.Driver, dsn)
+	if err != nil {
+		return nil, err
+	}
 
-	return &SqlStore{db}, nil
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+
+	return &SqlStore{DB: db, Dialect: dialect, QueryTimeout: DefaultQueryTimeout}, nil
 }
 
 func (st *SqlStore) BeginTx(ctx context.Context) (Transactioner, error) {
@@ -38,9 +74,99 @@ func (st *SqlStore) BeginTx(ctx context.Context) (Transactioner, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "database: Store.BeginTx st.BeginTxx error")
 	}
-	return tx, nil
+	return &sqlTx{Tx: tx, timeout: st.QueryTimeout}, nil
 }
 
-//func (st *SqlStore) Exec(query string, params ...interface{}) (sql.Result, error) {
-//return st.Exec(query, params...)
-//}
+func (st *SqlStore) ExecContext(ctx context.Context, query string, params ...any) (result sql.Result, err error) {
+	err = runStatement(ctx, st.QueryTimeout, query, func(ctx context.Context) error {
+		result, err = st.DB.ExecContext(ctx, query, params...)
+		return err
+	})
+	return result, err
+}
+
+func (st *SqlStore) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	return runStatement(ctx, st.QueryTimeout, query, func(ctx context.Context) error {
+		return st.DB.GetContext(ctx, dest, query, args...)
+	})
+}
+
+func (st *SqlStore) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	return runStatement(ctx, st.QueryTimeout, query, func(ctx context.Context) error {
+		return st.DB.SelectContext(ctx, dest, query, args...)
+	})
+}
+
+// QueryContext is not bounded by the query timeout: the rows are read after
+// it returns, so they only follow the deadline of ctx
+func (st *SqlStore) QueryContext(ctx context.Context, query string, params ...any) (rows *sql.Rows, err error) {
+	err = runStatement(ctx, 0, query, func(ctx context.Context) error {
+		rows, err = st.DB.QueryContext(ctx, query, params...)
+		return err
+	})
+	return rows, err
+}
+
+// sqlTx applies the query timeout of the store to the statements of a
+// transaction
+type sqlTx struct {
+	*sqlx.Tx
+	timeout time.Duration
+}
+
+func (tx *sqlTx) ExecContext(ctx context.Context, query string, params ...any) (result sql.Result, err error) {
+	err = runStatement(ctx, tx.timeout, query, func(ctx context.Context) error {
+		result, err = tx.Tx.ExecContext(ctx, query, params...)
+		return err
+	})
+	return result, err
+}
+
+func (tx *sqlTx) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	return runStatement(ctx, tx.timeout, query, func(ctx context.Context) error {
+		return tx.Tx.GetContext(ctx, dest, query, args...)
+	})
+}
+
+func (tx *sqlTx) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	return runStatement(ctx, tx.timeout, query, func(ctx context.Context) error {
+		return tx.Tx.SelectContext(ctx, dest, query, args...)
+	})
+}
+
+func (tx *sqlTx) QueryContext(ctx context.Context, query string, params ...any) (rows *sql.Rows, err error) {
+	err = runStatement(ctx, 0, query, func(ctx context.Context) error {
+		rows, err = tx.Tx.QueryContext(ctx, query, params...)
+		return err
+	})
+	return rows, err
+}
+
+// runStatement runs a statement bounded by timeout, none when zero, and
+// logs it at debug level with the logger of ctx. The arguments are left out
+// of the logs as they can hold secrets, like API key hashes.
+func runStatement(ctx context.Context, timeout time.Duration, query string, fn func(ctx context.Context) error) error {
+	// Rows outlive QueryContext, so no context is derived without timeout
+	stmtCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		stmtCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := fn(stmtCtx)
+
+	logger := logging.FromContext(ctx)
+	if logger.Enabled(ctx, slog.LevelDebug) {
+		attrs := []slog.Attr{
+			slog.String("sql", query),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		logger.LogAttrs(ctx, slog.LevelDebug, "query", attrs...)
+	}
+	return err
+}
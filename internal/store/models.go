@@ -1,25 +1,215 @@
 package store
 
-import "time"
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+// AccountDefaultID is the account owning the rows created before tenancy
+const AccountDefaultID = "default"
+
+// AccountRecord is an advertiser account, the tenant every ad, event and API
+// key belongs to
+type AccountRecord struct {
+	ID        string `db:"id" json:"id"`
+	Name      string `db:"name" json:"name"`
+	CreatedAt int64  `db:"created_at" json:"createdAt"`
+}
 
 var (
 	AdvertiseStatusActive   = "active"
 	AdvertiseStatusInactive = "inactive"
+	// AdvertiseStatusExpired is the terminal status set by the expiry reaper
+	AdvertiseStatusExpired = "expired"
+)
+
+// AdvertiseDefaultWeight is the serving weight of ads created without one
+const AdvertiseDefaultWeight int64 = 1
+
+// Lifecycle of an ad derived from its schedule at read time
+var (
+	AdvertiseLifecycleScheduled = "scheduled"
+	AdvertiseLifecycleLive      = "live"
+	AdvertiseLifecycleExpired   = "expired"
 )
 
 type AdvertiseRecord struct {
-	ID        string `db:"id" json:"id"`
-	Title     string `db:"title" json:"title"`
-	ImageURL  string `db:"image_url" json:"imageUrl"`
-	Placement string `db:"placement" json:"placement"`
-	Status    string `db:"status" json:"status"`
+	ID        string  `db:"id" json:"id"`
+	AccountID string  `db:"account_id" json:"accountId"`
+	Title     string  `db:"title" json:"title"`
+	ImageURL  string  `db:"image_url" json:"imageUrl"`
+	ClickURL  *string `db:"click_url" json:"clickUrl"`
+	// CampaignID is the campaign grouping the ad, nil for standalone ads
+	CampaignID *string `db:"campaign_id" json:"campaignId"`
+	Placement  string  `db:"placement" json:"placement"`
+	Status     string  `db:"status" json:"status"`
+	Weight     int64   `db:"weight" json:"weight"`
+	// Width and Height are the creative size in pixels, when declared
+	Width     *int64 `db:"width" json:"width"`
+	Height    *int64 `db:"height" json:"height"`
 	CreatedAt int64  `db:"created_at" json:"createdAt"`
+	StartsAt  *int64 `db:"starts_at" json:"startsAt"`
 	ExpiresAt *int64 `db:"expires_at" json:"expiresAt"`
 	Expired   bool   `db:"-" json:"expired"`
+	Lifecycle string `db:"-" json:"lifecycle"`
 }
 
+// CalculateAndSetExpired sets the Expired flag and the derived Lifecycle
 func (r *AdvertiseRecord) CalculateAndSetExpired() {
-	if r.ExpiresAt != nil && time.Now().Unix() > *r.ExpiresAt {
+	r.CalculateAndSetExpiredAt(time.Now())
+}
+
+// CalculateAndSetExpiredAt is CalculateAndSetExpired at the given time. An ad
+// is expired from its expires_at on, like the queries and the reaper see it.
+func (r *AdvertiseRecord) CalculateAndSetExpiredAt(t time.Time) {
+	now := t.Unix()
+	if r.ExpiresAt != nil && now >= *r.ExpiresAt {
 		r.Expired = true
 	}
+
+	switch {
+	case r.Expired:
+		r.Lifecycle = AdvertiseLifecycleExpired
+	case r.StartsAt != nil && now < *r.StartsAt:
+		r.Lifecycle = AdvertiseLifecycleScheduled
+	default:
+		r.Lifecycle = AdvertiseLifecycleLive
+	}
+}
+
+// LiveAt reports whether the ad is within its schedule at the given time
+func (r *AdvertiseRecord) LiveAt(t time.Time) bool {
+	now := t.Unix()
+	if r.StartsAt != nil && now < *r.StartsAt {
+		return false
+	}
+	return r.ExpiresAt == nil || now < *r.ExpiresAt
+}
+
+// PlacementRecord is a slot ads are served in and the constraints their
+// creatives must meet. Empty constraints accept any value.
+type PlacementRecord struct {
+	Name string `db:"name" json:"name"`
+	// Sizes lists the allowed creative sizes as "<width>x<height>"
+	Sizes StringList `db:"sizes" json:"sizes"`
+	// MaxTitleLength caps the title length in characters, 0 is unlimited
+	MaxTitleLength int64 `db:"max_title_length" json:"maxTitleLength"`
+	// ImageFormats lists the allowed image file extensions
+	ImageFormats StringList `db:"image_formats" json:"imageFormats"`
+	CreatedAt    int64      `db:"created_at" json:"createdAt"`
+}
+
+// AdvertiserRecord is a brand running campaigns within an account
+type AdvertiserRecord struct {
+	ID        string `db:"id" json:"id"`
+	AccountID string `db:"account_id" json:"accountId"`
+	Name      string `db:"name" json:"name"`
+	CreatedAt int64  `db:"created_at" json:"createdAt"`
+}
+
+// Statuses of a campaign, the ads of a paused campaign are not served
+var (
+	CampaignStatusActive = "active"
+	CampaignStatusPaused = "paused"
+)
+
+// CampaignRecord groups the ads of an advertiser so they can be managed
+// together
+type CampaignRecord struct {
+	ID           string `db:"id" json:"id"`
+	AccountID    string `db:"account_id" json:"accountId"`
+	AdvertiserID string `db:"advertiser_id" json:"advertiserId"`
+	Name         string `db:"name" json:"name"`
+	Status       string `db:"status" json:"status"`
+	CreatedAt    int64  `db:"created_at" json:"createdAt"`
+}
+
+var (
+	AdEventTypeImpression = "impression"
+	AdEventTypeClick      = "click"
+)
+
+// AdEventRecord is a tracked impression or click of an ad
+type AdEventRecord struct {
+	ID        string `db:"id" json:"id"`
+	AccountID string `db:"account_id" json:"accountId"`
+	AdID      string `db:"ad_id" json:"adId"`
+	Type      string `db:"type" json:"type"`
+	CreatedAt int64  `db:"created_at" json:"createdAt"`
+}
+
+// AdEventBucket aggregates the events of an ad over a time bucket
+type AdEventBucket struct {
+	Start       int64 `db:"bucket" json:"start"`
+	Impressions int64 `db:"impressions" json:"impressions"`
+	Clicks      int64 `db:"clicks" json:"clicks"`
+}
+
+// AdCountRecord counts the ads of an account in a placement by status.
+// Active ads past their expiration count as expired before the reaper
+// transitions them.
+type AdCountRecord struct {
+	AccountID string `db:"account_id" json:"account_id"`
+	Placement string `db:"placement" json:"placement"`
+	Active    int64  `db:"active" json:"active"`
+	Inactive  int64  `db:"inactive" json:"inactive"`
+	Expired   int64  `db:"expired" json:"expired"`
+}
+
+// Actions recorded in the ad audit log
+var (
+	AdAuditActionCreate     = "create"
+	AdAuditActionUpdate     = "update"
+	AdAuditActionDeactivate = "deactivate"
+	AdAuditActionExpire     = "expire"
+)
+
+// AdAuditRecord is one mutation of an ad with the snapshots around it.
+// Before is null on creation.
+type AdAuditRecord struct {
+	ID        string `db:"id" json:"id"`
+	AccountID string `db:"account_id" json:"accountId"`
+	AdID      string `db:"ad_id" json:"adId"`
+	Action    string `db:"action" json:"action"`
+	// Actor is "api_key:<id>" for requests and "system:<job>" for workers
+	Actor     string          `db:"actor" json:"actor"`
+	RequestID string          `db:"request_id" json:"requestId"`
+	Before    *types.JSONText `db:"before_snapshot" json:"before"`
+	After     *types.JSONText `db:"after_snapshot" json:"after"`
+	// Changes maps every changed field to its previous and new value
+	Changes   types.JSONText `db:"changes" json:"changes"`
+	CreatedAt int64          `db:"created_at" json:"createdAt"`
+}
+
+// IdempotencyKeyRecord is the response stored for a request carrying an
+// Idempotency-Key header, replayed when the request is retried
+type IdempotencyKeyRecord struct {
+	AccountID string `db:"account_id" json:"accountId"`
+	Key       string `db:"idempotency_key" json:"key"`
+	// RequestHash tells a retry apart from a different request reusing the key
+	RequestHash string         `db:"request_hash" json:"requestHash"`
+	StatusCode  int            `db:"status_code" json:"statusCode"`
+	Response    types.JSONText `db:"response" json:"response"`
+	CreatedAt   int64          `db:"created_at" json:"createdAt"`
+	ExpiresAt   int64          `db:"expires_at" json:"expiresAt"`
+}
+
+// Roles granted to API keys, every role includes the ones before it
+var (
+	APIKeyRoleReader = "reader"
+	APIKeyRoleEditor = "editor"
+	APIKeyRoleAdmin  = "admin"
+)
+
+// APIKeyRecord is a hashed API key and the role it grants
+type APIKeyRecord struct {
+	ID        string `db:"id" json:"id"`
+	AccountID string `db:"account_id" json:"accountId"`
+	Name      string `db:"name" json:"name"`
+	KeyHash   string `db:"key_hash" json:"-"`
+	KeyPrefix string `db:"key_prefix" json:"keyPrefix"`
+	Role      string `db:"role" json:"role"`
+	CreatedAt int64  `db:"created_at" json:"createdAt"`
+	RevokedAt *int64 `db:"revoked_at" json:"revokedAt"`
 }
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateAndSetExpiredAtBoundary(t *testing.T) {
+	expiresAt := time.Now().Unix()
+
+	testCases := []struct {
+		name      string
+		at        int64
+		expired   bool
+		lifecycle string
+	}{
+		{"before expires_at", expiresAt - 1, false, AdvertiseLifecycleLive},
+		{"at expires_at", expiresAt, true, AdvertiseLifecycleExpired},
+		{"after expires_at", expiresAt + 1, true, AdvertiseLifecycleExpired},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := &AdvertiseRecord{Status: AdvertiseStatusActive, ExpiresAt: &expiresAt}
+			rec.CalculateAndSetExpiredAt(time.Unix(tc.at, 0))
+			assert.Equal(t, tc.expired, rec.Expired)
+			assert.Equal(t, tc.lifecycle, rec.Lifecycle)
+			// Served until the same second it is reported expired
+			assert.Equal(t, !tc.expired, rec.LiveAt(time.Unix(tc.at, 0)))
+		})
+	}
+}
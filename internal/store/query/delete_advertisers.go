@@ -0,0 +1,43 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+// DeleteAdvertisers removes an advertiser of the account. Callers check it
+// has no campaigns left.
+func DeleteAdvertisers(ctx context.Context, tx store.Transaction, accountID, id string) error {
+	scope, err := accountScope(accountID, false)
+	if err != nil {
+		return err
+	}
+
+	sql, args, err := store.DialectOf(tx).Builder().
+		Delete("advertisers").
+		Where(squirrel.Eq{"id": id}).
+		Where(scope).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build delete query: %w", err)
+	}
+
+	result, err := execContext(ctx, tx, "DeleteAdvertisers", sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete advertiser: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w with ID: %s", ErrAdvertiserNotFound, id)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+type DeleteIdempotencyKeysArgs struct {
+	// AccountID and Key remove a single key, both empty remove the expired
+	// keys of every account
+	AccountID string
+	Key       string
+	// ExpiredAt only removes the keys expired at that unix timestamp
+	ExpiredAt int64
+}
+
+// DeleteIdempotencyKeys removes expired keys and returns how many were
+// removed. The sweep across accounts is a system job.
+func DeleteIdempotencyKeys(ctx context.Context, tx store.Transaction, args *DeleteIdempotencyKeysArgs) (int64, error) {
+	query := store.DialectOf(tx).Builder().
+		Delete("idempotency_keys").
+		Where(squirrel.LtOrEq{"expires_at": args.ExpiredAt})
+	if args.AccountID != "" || args.Key != "" {
+		scope, err := accountScope(args.AccountID, false)
+		if err != nil {
+			return 0, err
+		}
+		query = query.Where(scope).Where(squirrel.Eq{"idempotency_key": args.Key})
+	}
+
+	sql, queryArgs, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build delete query: %w", err)
+	}
+
+	result, err := execContext(ctx, tx, "DeleteIdempotencyKeys", sql, queryArgs...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete idempotency keys: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
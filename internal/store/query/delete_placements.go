@@ -0,0 +1,37 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+// DeletePlacements removes a placement from the registry. Callers check no
+// ad uses it anymore.
+func DeletePlacements(ctx context.Context, tx store.Transaction, name string) error {
+	sql, args, err := store.DialectOf(tx).Builder().
+		Delete("placements").
+		Where(squirrel.Eq{"name": name}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build delete query: %w", err)
+	}
+
+	result, err := execContext(ctx, tx, "DeletePlacements", sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete placement: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w with name: %s", ErrPlacementNotFound, name)
+	}
+
+	return nil
+}
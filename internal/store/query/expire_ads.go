@@ -0,0 +1,37 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+// ExpireAds moves the active ads whose expiration is at or before now to the
+// expired status and returns the transitioned ads, as they are after the
+// change. It is a system job and sweeps the ads of every account.
+func ExpireAds(ctx context.Context, tx store.Transaction, now int64) ([]*store.AdvertiseRecord, error) {
+	// RETURNING is supported by both engines and reports exactly the rows
+	// this statement changed
+	query := store.DialectOf(tx).Builder().
+		Update("ads").
+		Set("status", store.AdvertiseStatusExpired).
+		Where(squirrel.Eq{"status": store.AdvertiseStatusActive}).
+		Where(squirrel.NotEq{"expires_at": nil}).
+		Where(squirrel.LtOrEq{"expires_at": now}).
+		Suffix("RETURNING *")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expire query: %w", err)
+	}
+
+	records := make([]*store.AdvertiseRecord, 0)
+	err = selectContext(ctx, tx, "ExpireAds", &records, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire ads: %w", err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,26 @@
+package query
+
+import (
+	"context"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/pkg/errors"
+)
+
+func InsertAccounts(ctx context.Context, tx store.Transaction, record *store.AccountRecord) error {
+	sql, args, err := store.DialectOf(tx).Builder().
+		Insert("accounts").
+		Columns("id", "name", "created_at").
+		Values(record.ID, record.Name, record.CreatedAt).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "query: InsertAccounts build error")
+	}
+
+	_, err = execContext(ctx, tx, "InsertAccounts", sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "query: InsertAccounts error")
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package query
+
+import (
+	"context"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/pkg/errors"
+)
+
+// InsertAdAuditLog writes audit entries, meant to run in the transaction of
+// the mutation they describe
+func InsertAdAuditLog(ctx context.Context, tx store.Transaction, records ...*store.AdAuditRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	insert := store.DialectOf(tx).Builder().
+		Insert("ad_audit_log").
+		Columns("id", "account_id", "ad_id", "action", "actor", "request_id", "before_snapshot", "after_snapshot", "changes", "created_at")
+	for _, record := range records {
+		if record.AccountID == "" {
+			return ErrMissingAccount
+		}
+		insert = insert.Values(
+			record.ID,
+			record.AccountID,
+			record.AdID,
+			record.Action,
+			record.Actor,
+			record.RequestID,
+			record.Before,
+			record.After,
+			record.Changes,
+			record.CreatedAt,
+		)
+	}
+
+	sql, args, err := insert.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "query: InsertAdAuditLog build error")
+	}
+
+	_, err = execContext(ctx, tx, "InsertAdAuditLog", sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "query: InsertAdAuditLog error")
+	}
+
+	return nil
+}
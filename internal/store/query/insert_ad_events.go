@@ -0,0 +1,38 @@
+package query
+
+import (
+	"context"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/pkg/errors"
+)
+
+// InsertAdEvents writes a batch of tracking events in a single statement
+func InsertAdEvents(ctx context.Context, tx store.Transaction, events []*store.AdEventRecord) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	insert := store.DialectOf(tx).Builder().
+		Insert("ad_events").
+		Columns("id", "account_id", "ad_id", "type", "created_at")
+	for _, ev := range events {
+		// Events carry the account of their ad so stats never join across tenants
+		if ev.AccountID == "" {
+			return ErrMissingAccount
+		}
+		insert = insert.Values(ev.ID, ev.AccountID, ev.AdID, ev.Type, ev.CreatedAt)
+	}
+
+	sql, args, err := insert.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "query: InsertAdEvents build error")
+	}
+
+	_, err = execContext(ctx, tx, "InsertAdEvents", sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "query: InsertAdEvents error")
+	}
+
+	return nil
+}
@@ -1,22 +1,42 @@
 package query
 
 import (
+	"context"
+
 	"github.com/mtavano/admoai-takehome/internal/store"
 	"github.com/pkg/errors"
 )
 
-func InsertAds(tx store.Transaction, record *store.AdvertiseRecord) error {
-	_, err := tx.Exec(`
-		INSERT INTO ads (id, title, image_url, placement, status, created_at, expires_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?);`,
-		record.ID,
-		record.Title,
-		record.ImageURL,
-		record.Placement,
-		record.Status,
-		record.CreatedAt,
-		record.ExpiresAt,
-	)
+func InsertAds(ctx context.Context, tx store.Transaction, record *store.AdvertiseRecord) error {
+	if record.AccountID == "" {
+		return ErrMissingAccount
+	}
+
+	sql, args, err := store.DialectOf(tx).Builder().
+		Insert("ads").
+		Columns("id", "account_id", "campaign_id", "title", "image_url", "click_url", "placement", "status", "weight", "width", "height", "created_at", "starts_at", "expires_at").
+		Values(
+			record.ID,
+			record.AccountID,
+			record.CampaignID,
+			record.Title,
+			record.ImageURL,
+			record.ClickURL,
+			record.Placement,
+			record.Status,
+			record.Weight,
+			record.Width,
+			record.Height,
+			record.CreatedAt,
+			record.StartsAt,
+			record.ExpiresAt,
+		).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "query: InsertAds build error")
+	}
+
+	_, err = execContext(ctx, tx, "InsertAds", sql, args...)
 	if err != nil {
 		return errors.Wrap(err, "query: InsertAds error")
 	}
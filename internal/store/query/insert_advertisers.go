@@ -0,0 +1,30 @@
+package query
+
+import (
+	"context"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/pkg/errors"
+)
+
+func InsertAdvertisers(ctx context.Context, tx store.Transaction, record *store.AdvertiserRecord) error {
+	if record.AccountID == "" {
+		return ErrMissingAccount
+	}
+
+	sql, args, err := store.DialectOf(tx).Builder().
+		Insert("advertisers").
+		Columns("id", "account_id", "name", "created_at").
+		Values(record.ID, record.AccountID, record.Name, record.CreatedAt).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "query: InsertAdvertisers build error")
+	}
+
+	_, err = execContext(ctx, tx, "InsertAdvertisers", sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "query: InsertAdvertisers error")
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package query
+
+import (
+	"context"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/pkg/errors"
+)
+
+func InsertAPIKeys(ctx context.Context, tx store.Transaction, record *store.APIKeyRecord) error {
+	if record.AccountID == "" {
+		return ErrMissingAccount
+	}
+
+	sql, args, err := store.DialectOf(tx).Builder().
+		Insert("api_keys").
+		Columns("id", "account_id", "name", "key_hash", "key_prefix", "role", "created_at", "revoked_at").
+		Values(
+			record.ID,
+			record.AccountID,
+			record.Name,
+			record.KeyHash,
+			record.KeyPrefix,
+			record.Role,
+			record.CreatedAt,
+			record.RevokedAt,
+		).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "query: InsertAPIKeys build error")
+	}
+
+	_, err = execContext(ctx, tx, "InsertAPIKeys", sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "query: InsertAPIKeys error")
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package query
+
+import (
+	"context"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/pkg/errors"
+)
+
+func InsertCampaigns(ctx context.Context, tx store.Transaction, record *store.CampaignRecord) error {
+	if record.AccountID == "" {
+		return ErrMissingAccount
+	}
+
+	sql, args, err := store.DialectOf(tx).Builder().
+		Insert("campaigns").
+		Columns("id", "account_id", "advertiser_id", "name", "status", "created_at").
+		Values(
+			record.ID,
+			record.AccountID,
+			record.AdvertiserID,
+			record.Name,
+			record.Status,
+			record.CreatedAt,
+		).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "query: InsertCampaigns build error")
+	}
+
+	_, err = execContext(ctx, tx, "InsertCampaigns", sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "query: InsertCampaigns error")
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package query
+
+import (
+	"context"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/pkg/errors"
+)
+
+func InsertIdempotencyKeys(ctx context.Context, tx store.Transaction, record *store.IdempotencyKeyRecord) error {
+	if record.AccountID == "" {
+		return ErrMissingAccount
+	}
+
+	sql, args, err := store.DialectOf(tx).Builder().
+		Insert("idempotency_keys").
+		Columns("account_id", "idempotency_key", "request_hash", "status_code", "response", "created_at", "expires_at").
+		Values(
+			record.AccountID,
+			record.Key,
+			record.RequestHash,
+			record.StatusCode,
+			record.Response,
+			record.CreatedAt,
+			record.ExpiresAt,
+		).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "query: InsertIdempotencyKeys build error")
+	}
+
+	_, err = execContext(ctx, tx, "InsertIdempotencyKeys", sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "query: InsertIdempotencyKeys error")
+	}
+
+	return nil
+}
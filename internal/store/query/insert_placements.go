@@ -0,0 +1,32 @@
+package query
+
+import (
+	"context"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/pkg/errors"
+)
+
+func InsertPlacements(ctx context.Context, tx store.Transaction, record *store.PlacementRecord) error {
+	sql, args, err := store.DialectOf(tx).Builder().
+		Insert("placements").
+		Columns("name", "sizes", "max_title_length", "image_formats", "created_at").
+		Values(
+			record.Name,
+			record.Sizes,
+			record.MaxTitleLength,
+			record.ImageFormats,
+			record.CreatedAt,
+		).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "query: InsertPlacements build error")
+	}
+
+	_, err = execContext(ctx, tx, "InsertPlacements", sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "query: InsertPlacements error")
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+// sortColumns maps the public sort fields to the SQL expression ads are
+// ordered by. Ads without expiration sort as if they expired last so the
+// ordering is identical on every dialect (NULL placement is not).
+var sortColumns = map[string]string{
+	"created_at": "created_at",
+	"expires_at": fmt.Sprintf("COALESCE(expires_at, %d)", int64(math.MaxInt64)),
+	"title":      "title",
+}
+
+// DefaultSortOrder is the order used when none is requested
+var DefaultSortOrder = SortOrder{Field: "created_at", Desc: true}
+
+// SortOrder is a sort field and its direction. The ad ID is always used as
+// tie breaker so the order is total and stable across pages.
+type SortOrder struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSortOrder parses "field" or "field:asc|desc"
+func ParseSortOrder(raw string) (SortOrder, error) {
+	if raw == "" {
+		return DefaultSortOrder, nil
+	}
+
+	field, dir, _ := strings.Cut(raw, ":")
+	if _, ok := sortColumns[field]; !ok {
+		return SortOrder{}, fmt.Errorf("unsupported sort field %q", field)
+	}
+
+	switch strings.ToLower(dir) {
+	case "", "asc":
+		return SortOrder{Field: field}, nil
+	case "desc":
+		return SortOrder{Field: field, Desc: true}, nil
+	default:
+		return SortOrder{}, fmt.Errorf("unsupported sort direction %q", dir)
+	}
+}
+
+func (o SortOrder) String() string {
+	if o.Desc {
+		return o.Field + ":desc"
+	}
+	return o.Field + ":asc"
+}
+
+func (o SortOrder) apply(query squirrel.SelectBuilder) squirrel.SelectBuilder {
+	dir := "ASC"
+	if o.Desc {
+		dir = "DESC"
+	}
+	return query.OrderBy(sortColumns[o.Field]+" "+dir, "id "+dir)
+}
+
+// Cursor points right after the last ad of a page. It is handed out to
+// clients as an opaque string.
+type Cursor struct {
+	Sort   string `json:"s"`
+	Int    int64  `json:"i,omitempty"`
+	Str    string `json:"t,omitempty"`
+	LastID string `json:"id"`
+}
+
+// NewCursor returns the cursor that continues after rec in the given order
+func NewCursor(order SortOrder, rec *store.AdvertiseRecord) *Cursor {
+	cur := &Cursor{Sort: order.String(), LastID: rec.ID}
+	switch order.Field {
+	case "created_at":
+		cur.Int = rec.CreatedAt
+	case "expires_at":
+		cur.Int = math.MaxInt64
+		if rec.ExpiresAt != nil {
+			cur.Int = *rec.ExpiresAt
+		}
+	case "title":
+		cur.Str = rec.Title
+	}
+	return cur
+}
+
+// Encode serializes the cursor into its opaque representation
+func (cur *Cursor) Encode() string {
+	raw, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a cursor produced by Encode
+func DecodeCursor(raw string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+
+	var cur Cursor
+	if err := json.Unmarshal(data, &cur); err != nil || cur.LastID == "" {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	if _, err := ParseSortOrder(cur.Sort); err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	return &cur, nil
+}
+
+// seek restricts the query to the rows that come after the cursor
+func (cur *Cursor) seek(order SortOrder, query squirrel.SelectBuilder) squirrel.SelectBuilder {
+	var value any = cur.Int
+	if order.Field == "title" {
+		value = cur.Str
+	}
+
+	cmp := ">"
+	if order.Desc {
+		cmp = "<"
+	}
+
+	column := sortColumns[order.Field]
+	return query.Where(squirrel.Or{
+		squirrel.Expr(column+" "+cmp+" ?", value),
+		squirrel.And{
+			squirrel.Expr(column+" = ?", value),
+			squirrel.Expr("id "+cmp+" ?", cur.LastID),
+		},
+	})
+}
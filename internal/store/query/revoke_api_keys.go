@@ -0,0 +1,50 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+// ErrAPIKeyNotFound is returned when no active key matches the ID
+var ErrAPIKeyNotFound = errors.New("no api keys found")
+
+// RevokeAPIKeys marks an active API key of the account as revoked at the
+// given time
+func RevokeAPIKeys(ctx context.Context, tx store.Transaction, accountID, id string, revokedAt int64) error {
+	scope, err := accountScope(accountID, false)
+	if err != nil {
+		return err
+	}
+
+	query := store.DialectOf(tx).Builder().
+		Update("api_keys").
+		Set("revoked_at", revokedAt).
+		Where(scope).
+		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.Eq{"revoked_at": nil})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build revoke query: %w", err)
+	}
+
+	result, err := execContext(ctx, tx, "RevokeAPIKeys", sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w with ID: %s", ErrAPIKeyNotFound, id)
+	}
+
+	return nil
+}
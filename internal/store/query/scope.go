@@ -0,0 +1,24 @@
+package query
+
+import (
+	"errors"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// ErrMissingAccount is returned by tenant scoped queries run without an
+// account, so a forgotten scope fails instead of reading every tenant
+var ErrMissingAccount = errors.New("account scope is required")
+
+// accountScope returns the condition restricting a query to one account.
+// allAccounts lifts the restriction, it is reserved to system jobs and the
+// public delivery endpoints and yields a nil condition.
+func accountScope(accountID string, allAccounts bool) (squirrel.Sqlizer, error) {
+	if allAccounts {
+		return nil, nil
+	}
+	if accountID == "" {
+		return nil, ErrMissingAccount
+	}
+	return squirrel.Eq{"account_id": accountID}, nil
+}
@@ -0,0 +1,34 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+type SelectAccountsArgs struct {
+	ID string
+}
+
+func SelectAccounts(ctx context.Context, tx store.Transaction, args *SelectAccountsArgs) ([]*store.AccountRecord, error) {
+	query := store.DialectOf(tx).Builder().Select("*").From("accounts")
+
+	if args.ID != "" {
+		query = query.Where(squirrel.Eq{"id": args.ID})
+	}
+
+	sql, queryArgs, err := query.OrderBy("created_at", "id").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	records := make([]*store.AccountRecord, 0)
+	err = selectContext(ctx, tx, "SelectAccounts", &records, sql, queryArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select accounts: %w", err)
+	}
+
+	return records, nil
+}
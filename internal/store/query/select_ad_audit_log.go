@@ -0,0 +1,44 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+type SelectAdAuditLogArgs struct {
+	// AccountID scopes the entries to one tenant
+	AccountID string
+	AdID      string
+}
+
+// SelectAdAuditLog returns the audit entries of an ad, oldest first
+func SelectAdAuditLog(ctx context.Context, tx store.Transaction, args *SelectAdAuditLogArgs) ([]*store.AdAuditRecord, error) {
+	scope, err := accountScope(args.AccountID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// IDs are time ordered, they break the ties within the same second
+	query := store.DialectOf(tx).Builder().
+		Select("*").
+		From("ad_audit_log").
+		Where(scope).
+		Where(squirrel.Eq{"ad_id": args.AdID}).
+		OrderBy("created_at", "id")
+
+	sql, queryArgs, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	records := make([]*store.AdAuditRecord, 0)
+	err = selectContext(ctx, tx, "SelectAdAuditLog", &records, sql, queryArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select ad audit log: %w", err)
+	}
+
+	return records, nil
+}
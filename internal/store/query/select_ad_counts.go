@@ -0,0 +1,53 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+// SelectAdCounts counts the ads of every account by account, placement and
+// status in a single grouped query. Active ads whose expiration is at or before now
+// count as expired, like they are read everywhere else.
+func SelectAdCounts(ctx context.Context, tx store.Transaction, now int64) ([]*store.AdCountRecord, error) {
+	expired := squirrel.And{
+		squirrel.NotEq{"expires_at": nil},
+		squirrel.LtOrEq{"expires_at": now},
+	}
+	expiredSQL, expiredArgs, err := expired.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expired condition: %w", err)
+	}
+
+	// The expired condition appears twice, once for each of the columns
+	active := fmt.Sprintf("SUM(CASE WHEN status = '%s' AND NOT %s THEN 1 ELSE 0 END) AS active",
+		store.AdvertiseStatusActive, expiredSQL)
+	inactive := fmt.Sprintf("SUM(CASE WHEN status = '%s' THEN 1 ELSE 0 END) AS inactive",
+		store.AdvertiseStatusInactive)
+	expiredCount := fmt.Sprintf("SUM(CASE WHEN status = '%s' OR (status = '%s' AND %s) THEN 1 ELSE 0 END) AS expired",
+		store.AdvertiseStatusExpired, store.AdvertiseStatusActive, expiredSQL)
+
+	query := store.DialectOf(tx).Builder().
+		Select("account_id", "placement").
+		Column(squirrel.Expr(active, expiredArgs...)).
+		Column(inactive).
+		Column(squirrel.Expr(expiredCount, expiredArgs...)).
+		From("ads").
+		GroupBy("account_id", "placement").
+		OrderBy("account_id", "placement")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build ad counts query: %w", err)
+	}
+
+	records := make([]*store.AdCountRecord, 0)
+	err = selectContext(ctx, tx, "SelectAdCounts", &records, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count ads: %w", err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,63 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+type SelectAdEventStatsArgs struct {
+	// AccountID scopes the events to one tenant
+	AccountID string
+	AdID      string
+	// From and To bound the events by created_at, [From, To)
+	From int64
+	To   int64
+	// BucketSeconds is the width of every time bucket
+	BucketSeconds int64
+}
+
+// SelectAdEventStats counts impressions and clicks of an ad per time bucket.
+// Buckets without events are not returned.
+func SelectAdEventStats(ctx context.Context, tx store.Transaction, args *SelectAdEventStatsArgs) ([]*store.AdEventBucket, error) {
+	if args.BucketSeconds <= 0 {
+		return nil, fmt.Errorf("bucket width must be positive")
+	}
+
+	scope, err := accountScope(args.AccountID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Integer division floors the timestamps to the start of their bucket
+	bucket := fmt.Sprintf("(created_at / %d) * %d", args.BucketSeconds, args.BucketSeconds)
+
+	query := store.DialectOf(tx).Builder().
+		Select(
+			bucket+" AS bucket",
+			"SUM(CASE WHEN type = '"+store.AdEventTypeImpression+"' THEN 1 ELSE 0 END) AS impressions",
+			"SUM(CASE WHEN type = '"+store.AdEventTypeClick+"' THEN 1 ELSE 0 END) AS clicks",
+		).
+		From("ad_events").
+		Where(scope).
+		Where(squirrel.Eq{"ad_id": args.AdID}).
+		Where(squirrel.GtOrEq{"created_at": args.From}).
+		Where(squirrel.Lt{"created_at": args.To}).
+		GroupBy(bucket).
+		OrderBy("bucket")
+
+	sql, queryArgs, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build stats query: %w", err)
+	}
+
+	buckets := make([]*store.AdEventBucket, 0)
+	err = selectContext(ctx, tx, "SelectAdEventStats", &buckets, sql, queryArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select ad event stats: %w", err)
+	}
+
+	return buckets, nil
+}
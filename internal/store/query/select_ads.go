@@ -1,6 +1,7 @@
 package query
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -9,21 +10,49 @@ import (
 )
 
 type SelectAdsArgs struct {
+	// AccountID scopes the query to one tenant, required unless AllAccounts
+	AccountID string
+	// AllAccounts reads every tenant, only for system jobs and ad delivery
+	AllAccounts bool
+
 	ID              string
 	Title           string
 	Status          string
 	Placement       string
+	CampaignID      string
 	FilterByExpired bool
+	// Lifecycle keeps only the ads that are scheduled, live or expired now.
+	// Live ads exclude the ones of paused campaigns.
+	Lifecycle string
+	// ExcludePausedCampaigns leaves out the ads of paused campaigns
+	ExcludePausedCampaigns bool
+	// IDs keeps only the ads with one of the ids
+	IDs []string
+
+	// Pagination, ignored by CountAds. A nil Order leaves the rows unsorted
+	// and After requires Order to be set.
+	Order *SortOrder
+	After *Cursor
+	Limit uint64
 }
 
-func SelectAds(tx store.Transaction, args *SelectAdsArgs) ([]*store.AdvertiseRecord, error) {
-	// Build query using squirrel
-	query := squirrel.Select("*").From("ads")
+// filter adds the WHERE conditions shared by SelectAds and CountAds
+func (args *SelectAdsArgs) filter(query squirrel.SelectBuilder) (squirrel.SelectBuilder, error) {
+	scope, err := accountScope(args.AccountID, args.AllAccounts)
+	if err != nil {
+		return query, err
+	}
+	if scope != nil {
+		query = query.Where(scope)
+	}
 
 	// Add conditions based on provided fields
 	if args.ID != "" {
 		query = query.Where(squirrel.Eq{"id": args.ID})
 	}
+	if len(args.IDs) > 0 {
+		query = query.Where(squirrel.Eq{"id": args.IDs})
+	}
 	if args.Title != "" {
 		query = query.Where(squirrel.Eq{"title": args.Title})
 	}
@@ -33,28 +62,101 @@ func SelectAds(tx store.Transaction, args *SelectAdsArgs) ([]*store.AdvertiseRec
 	if args.Placement != "" {
 		query = query.Where(squirrel.Eq{"placement": args.Placement})
 	}
+	if args.CampaignID != "" {
+		query = query.Where(squirrel.Eq{"campaign_id": args.CampaignID})
+	}
+
+	// Pausing a campaign hides its ads without touching their own status
+	if args.ExcludePausedCampaigns || args.Lifecycle == store.AdvertiseLifecycleLive {
+		query = query.Where(squirrel.Or{
+			squirrel.Eq{"campaign_id": nil},
+			squirrel.Expr(
+				"campaign_id NOT IN (SELECT id FROM campaigns WHERE status = ?)",
+				store.CampaignStatusPaused,
+			),
+		})
+	}
+
+	currentTimestamp := time.Now().Unix()
 
 	// Filter out expired records
 	if args.FilterByExpired {
-		currentTimestamp := time.Now().Unix()
 		query = query.Where(squirrel.Or{
 			squirrel.Eq{"expires_at": nil},              // Records without expiration
 			squirrel.Gt{"expires_at": currentTimestamp}, // Records that haven't expired yet
 		})
 	}
 
-	// Generate SQL query with placeholders for SQLite
-	sql, queryArgs, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
+	// Filter by the lifecycle derived from starts_at and expires_at
+	switch args.Lifecycle {
+	case store.AdvertiseLifecycleScheduled:
+		query = query.Where(squirrel.And{
+			squirrel.Gt{"starts_at": currentTimestamp},
+			squirrel.Or{squirrel.Eq{"expires_at": nil}, squirrel.Gt{"expires_at": currentTimestamp}},
+		})
+	case store.AdvertiseLifecycleLive:
+		query = query.Where(squirrel.And{
+			squirrel.Or{squirrel.Eq{"starts_at": nil}, squirrel.LtOrEq{"starts_at": currentTimestamp}},
+			squirrel.Or{squirrel.Eq{"expires_at": nil}, squirrel.Gt{"expires_at": currentTimestamp}},
+		})
+	case store.AdvertiseLifecycleExpired:
+		query = query.Where(squirrel.LtOrEq{"expires_at": currentTimestamp})
+	}
+
+	return query, nil
+}
+
+func SelectAds(ctx context.Context, tx store.Transaction, args *SelectAdsArgs) ([]*store.AdvertiseRecord, error) {
+	// Build query using squirrel with the placeholders of the connection dialect
+	query, err := args.filter(store.DialectOf(tx).Builder().Select("*").From("ads"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Keyset pagination: seek past the cursor and sort by (field, id)
+	if args.Order != nil {
+		if args.After != nil {
+			query = args.After.seek(*args.Order, query)
+		}
+		query = args.Order.apply(query)
+	}
+	if args.Limit > 0 {
+		query = query.Limit(args.Limit)
+	}
+
+	// Generate SQL query
+	sql, queryArgs, err := query.ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build query: %w", err)
 	}
 
 	// Execute the query
 	record := make([]*store.AdvertiseRecord, 0)
-	err = tx.Select(&record, sql, queryArgs...)
+	err = selectContext(ctx, tx, "SelectAds", &record, sql, queryArgs...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to select ads: %w", err)
 	}
 
 	return record, nil
 }
+
+// CountAds returns the number of ads matching the filters of args
+func CountAds(ctx context.Context, tx store.Transaction, args *SelectAdsArgs) (int64, error) {
+	query, err := args.filter(store.DialectOf(tx).Builder().Select("COUNT(*)").From("ads"))
+	if err != nil {
+		return 0, err
+	}
+
+	sql, queryArgs, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build count query: %w", err)
+	}
+
+	var total int64
+	err = getContext(ctx, tx, "CountAds", &total, sql, queryArgs...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count ads: %w", err)
+	}
+
+	return total, nil
+}
@@ -0,0 +1,41 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+type SelectAdvertisersArgs struct {
+	// AccountID scopes the query to one tenant
+	AccountID string
+	ID        string
+}
+
+func SelectAdvertisers(ctx context.Context, tx store.Transaction, args *SelectAdvertisersArgs) ([]*store.AdvertiserRecord, error) {
+	scope, err := accountScope(args.AccountID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	query := store.DialectOf(tx).Builder().Select("*").From("advertisers").Where(scope)
+
+	if args.ID != "" {
+		query = query.Where(squirrel.Eq{"id": args.ID})
+	}
+
+	sql, queryArgs, err := query.OrderBy("created_at", "id").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	records := make([]*store.AdvertiserRecord, 0)
+	err = selectContext(ctx, tx, "SelectAdvertisers", &records, sql, queryArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select advertisers: %w", err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,53 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+type SelectAPIKeysArgs struct {
+	// AccountID scopes the query to one tenant, required unless AllAccounts
+	AccountID string
+	// AllAccounts reads every tenant, only to authenticate a key by its hash
+	AllAccounts bool
+
+	ID      string
+	KeyHash string
+	// ActiveOnly leaves out revoked keys
+	ActiveOnly bool
+}
+
+func SelectAPIKeys(ctx context.Context, tx store.Transaction, args *SelectAPIKeysArgs) ([]*store.APIKeyRecord, error) {
+	scope, err := accountScope(args.AccountID, args.AllAccounts)
+	if err != nil {
+		return nil, err
+	}
+
+	query := store.DialectOf(tx).Builder().Select("*").From("api_keys").Where(scope)
+
+	if args.ID != "" {
+		query = query.Where(squirrel.Eq{"id": args.ID})
+	}
+	if args.KeyHash != "" {
+		query = query.Where(squirrel.Eq{"key_hash": args.KeyHash})
+	}
+	if args.ActiveOnly {
+		query = query.Where(squirrel.Eq{"revoked_at": nil})
+	}
+
+	sql, queryArgs, err := query.OrderBy("created_at", "id").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	records := make([]*store.APIKeyRecord, 0)
+	err = selectContext(ctx, tx, "SelectAPIKeys", &records, sql, queryArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select api keys: %w", err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,49 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+type SelectCampaignsArgs struct {
+	// AccountID scopes the query to one tenant
+	AccountID    string
+	ID           string
+	AdvertiserID string
+	Status       string
+}
+
+func SelectCampaigns(ctx context.Context, tx store.Transaction, args *SelectCampaignsArgs) ([]*store.CampaignRecord, error) {
+	scope, err := accountScope(args.AccountID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	query := store.DialectOf(tx).Builder().Select("*").From("campaigns").Where(scope)
+
+	if args.ID != "" {
+		query = query.Where(squirrel.Eq{"id": args.ID})
+	}
+	if args.AdvertiserID != "" {
+		query = query.Where(squirrel.Eq{"advertiser_id": args.AdvertiserID})
+	}
+	if args.Status != "" {
+		query = query.Where(squirrel.Eq{"status": args.Status})
+	}
+
+	sql, queryArgs, err := query.OrderBy("created_at", "id").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	records := make([]*store.CampaignRecord, 0)
+	err = selectContext(ctx, tx, "SelectCampaigns", &records, sql, queryArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select campaigns: %w", err)
+	}
+
+	return records, nil
+}
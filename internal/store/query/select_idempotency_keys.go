@@ -0,0 +1,43 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+type SelectIdempotencyKeysArgs struct {
+	// AccountID scopes the keys to one tenant
+	AccountID string
+	Key       string
+	// Now hides the keys expired at that unix timestamp
+	Now int64
+}
+
+func SelectIdempotencyKeys(ctx context.Context, tx store.Transaction, args *SelectIdempotencyKeysArgs) ([]*store.IdempotencyKeyRecord, error) {
+	scope, err := accountScope(args.AccountID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	sql, queryArgs, err := store.DialectOf(tx).Builder().
+		Select("*").
+		From("idempotency_keys").
+		Where(scope).
+		Where(squirrel.Eq{"idempotency_key": args.Key}).
+		Where(squirrel.Gt{"expires_at": args.Now}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	records := make([]*store.IdempotencyKeyRecord, 0)
+	err = selectContext(ctx, tx, "SelectIdempotencyKeys", &records, sql, queryArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select idempotency keys: %w", err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,36 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+// Placements are shared by every account since their ads compete for them,
+// so the registry is not tenant scoped
+type SelectPlacementsArgs struct {
+	Name string
+}
+
+func SelectPlacements(ctx context.Context, tx store.Transaction, args *SelectPlacementsArgs) ([]*store.PlacementRecord, error) {
+	query := store.DialectOf(tx).Builder().Select("*").From("placements")
+
+	if args.Name != "" {
+		query = query.Where(squirrel.Eq{"name": args.Name})
+	}
+
+	sql, queryArgs, err := query.OrderBy("name").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	records := make([]*store.PlacementRecord, 0)
+	err = selectContext(ctx, tx, "SelectPlacements", &records, sql, queryArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select placements: %w", err)
+	}
+
+	return records, nil
+}
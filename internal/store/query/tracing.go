@@ -0,0 +1,69 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The statements of the query functions run through these helpers, which
+// trace each one in a span named after its function. Spans are only started
+// as children of the span of ctx, like the one of a request, so background
+// workers do not start a trace per statement.
+
+func execContext(ctx context.Context, tx store.Transaction, name, statement string, args ...any) (result sql.Result, err error) {
+	err = traced(ctx, tx, name, statement, func(ctx context.Context) error {
+		result, err = tx.ExecContext(ctx, statement, args...)
+		return err
+	})
+	return result, err
+}
+
+func selectContext(ctx context.Context, tx store.Transaction, name string, dest any, statement string, args ...any) error {
+	return traced(ctx, tx, name, statement, func(ctx context.Context) error {
+		return tx.SelectContext(ctx, dest, statement, args...)
+	})
+}
+
+func getContext(ctx context.Context, tx store.Transaction, name string, dest any, statement string, args ...any) error {
+	return traced(ctx, tx, name, statement, func(ctx context.Context) error {
+		return tx.GetContext(ctx, dest, statement, args...)
+	})
+}
+
+// traced runs a statement in the span of its query function
+func traced(ctx context.Context, tx store.Transaction, name, statement string, run func(ctx context.Context) error) error {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return run(ctx)
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "query."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			dbSystem(store.DialectOf(tx)),
+			semconv.DBQueryText(statement),
+		),
+	)
+	defer span.End()
+
+	err := run(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// dbSystem returns the db.system.name attribute of a dialect
+func dbSystem(dialect store.Dialect) attribute.KeyValue {
+	if dialect.Name == store.DialectPostgres.Name {
+		return semconv.DBSystemNamePostgreSQL
+	}
+	return semconv.DBSystemNameSQLite
+}
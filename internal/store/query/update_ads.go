@@ -1,47 +1,98 @@
 package query
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/mtavano/admoai-takehome/internal/store"
 )
 
+// ErrAdNotFound is returned when a mutation does not match any ad
+var ErrAdNotFound = errors.New("no ads found")
+
 type UpdateAdsArgs struct {
+	// AccountID scopes the update to the ads of one tenant
+	AccountID string
 	ID        string
 	Title     *string
 	ImageURL  *string
-	Placement *string
+	ClickURL  *string
+	// CampaignID moves the ad to another campaign
+	CampaignID *string
+	Placement  *string
+	// Status is never set on an expired ad, expired is terminal and the ad is
+	// reported as not found
 	Status    *string
+	Weight    *int64
+	Width     *int64
+	Height    *int64
+	StartsAt  *int64
+	ExpiresAt *int64
+	// ClearStartsAt makes the ad live immediately, taking precedence over StartsAt
+	ClearStartsAt bool
+	// ClearExpiresAt removes the expiration, taking precedence over ExpiresAt
+	ClearExpiresAt bool
+	// ClearCampaignID takes the ad out of its campaign, taking precedence over CampaignID
+	ClearCampaignID bool
 }
 
-func UpdateAds(tx store.Transaction, args *UpdateAdsArgs) error {
+func UpdateAds(ctx context.Context, tx store.Transaction, args *UpdateAdsArgs) error {
 	// Validate that ID is provided
 	if args.ID == "" {
 		return fmt.Errorf("ID is required for update")
 	}
 
-	// Build update query using squirrel
-	query := squirrel.Update("ads").Where(squirrel.Eq{"id": args.ID})
+	scope, err := accountScope(args.AccountID, false)
+	if err != nil {
+		return err
+	}
+
+	// Build update query using squirrel with the placeholders of the connection dialect
+	// An ad of another account is reported as not found
+	query := store.DialectOf(tx).Builder().Update("ads").Where(squirrel.Eq{"id": args.ID}).Where(scope)
+	if args.Status != nil {
+		query = query.Where(squirrel.NotEq{"status": store.AdvertiseStatusExpired})
+	}
 
 	// Add update fields using COALESCE for conditional updates
 	updateMap := make(map[string]interface{})
 
 	updateMap["title"] = squirrel.Expr("COALESCE(?, title)", args.Title)
 	updateMap["image_url"] = squirrel.Expr("COALESCE(?, image_url)", args.ImageURL)
+	updateMap["click_url"] = squirrel.Expr("COALESCE(?, click_url)", args.ClickURL)
+	if args.ClearCampaignID {
+		updateMap["campaign_id"] = nil
+	} else {
+		updateMap["campaign_id"] = squirrel.Expr("COALESCE(?, campaign_id)", args.CampaignID)
+	}
 	updateMap["placement"] = squirrel.Expr("COALESCE(?, placement)", args.Placement)
 	updateMap["status"] = squirrel.Expr("COALESCE(?, status)", args.Status)
+	updateMap["weight"] = squirrel.Expr("COALESCE(?, weight)", args.Weight)
+	updateMap["width"] = squirrel.Expr("COALESCE(?, width)", args.Width)
+	updateMap["height"] = squirrel.Expr("COALESCE(?, height)", args.Height)
+	if args.ClearStartsAt {
+		updateMap["starts_at"] = nil
+	} else {
+		updateMap["starts_at"] = squirrel.Expr("COALESCE(?, starts_at)", args.StartsAt)
+	}
+	if args.ClearExpiresAt {
+		updateMap["expires_at"] = nil
+	} else {
+		updateMap["expires_at"] = squirrel.Expr("COALESCE(?, expires_at)", args.ExpiresAt)
+	}
 
 	query = query.SetMap(updateMap)
 
-	// Generate SQL query with placeholders for SQLite
-	sql, queryArgs, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
+	// Generate SQL query
+	sql, queryArgs, err := query.ToSql()
 	if err != nil {
 		return fmt.Errorf("failed to build update query: %w", err)
 	}
 
 	// Execute the update
-	result, err := tx.Exec(sql, queryArgs...)
+	result, err := execContext(ctx, tx, "UpdateAds", sql, queryArgs...)
 	if err != nil {
 		return fmt.Errorf("failed to update ads: %w", err)
 	}
@@ -53,7 +104,7 @@ func UpdateAds(tx store.Transaction, args *UpdateAdsArgs) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no ads found with ID: %s", args.ID)
+		return fmt.Errorf("%w with ID: %s", ErrAdNotFound, args.ID)
 	}
 
 	return nil
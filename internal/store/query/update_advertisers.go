@@ -0,0 +1,58 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+// ErrAdvertiserNotFound is returned when a mutation does not match any advertiser
+var ErrAdvertiserNotFound = errors.New("no advertisers found")
+
+type UpdateAdvertisersArgs struct {
+	// AccountID scopes the update to the advertisers of one tenant
+	AccountID string
+	ID        string
+	Name      *string
+}
+
+func UpdateAdvertisers(ctx context.Context, tx store.Transaction, args *UpdateAdvertisersArgs) error {
+	if args.ID == "" {
+		return fmt.Errorf("ID is required for update")
+	}
+
+	scope, err := accountScope(args.AccountID, false)
+	if err != nil {
+		return err
+	}
+
+	query := store.DialectOf(tx).Builder().
+		Update("advertisers").
+		Set("name", squirrel.Expr("COALESCE(?, name)", args.Name)).
+		Where(squirrel.Eq{"id": args.ID}).
+		Where(scope)
+
+	sql, queryArgs, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build update query: %w", err)
+	}
+
+	result, err := execContext(ctx, tx, "UpdateAdvertisers", sql, queryArgs...)
+	if err != nil {
+		return fmt.Errorf("failed to update advertisers: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w with ID: %s", ErrAdvertiserNotFound, args.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+// ErrCampaignNotFound is returned when a mutation does not match any campaign
+var ErrCampaignNotFound = errors.New("no campaigns found")
+
+type UpdateCampaignsArgs struct {
+	// AccountID scopes the update to the campaigns of one tenant
+	AccountID string
+	ID        string
+	Name      *string
+	// Status pauses or resumes every ad of the campaign at once
+	Status *string
+}
+
+func UpdateCampaigns(ctx context.Context, tx store.Transaction, args *UpdateCampaignsArgs) error {
+	if args.ID == "" {
+		return fmt.Errorf("ID is required for update")
+	}
+
+	scope, err := accountScope(args.AccountID, false)
+	if err != nil {
+		return err
+	}
+
+	query := store.DialectOf(tx).Builder().
+		Update("campaigns").
+		SetMap(map[string]interface{}{
+			"name":   squirrel.Expr("COALESCE(?, name)", args.Name),
+			"status": squirrel.Expr("COALESCE(?, status)", args.Status),
+		}).
+		Where(squirrel.Eq{"id": args.ID}).
+		Where(scope)
+
+	sql, queryArgs, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build update query: %w", err)
+	}
+
+	result, err := execContext(ctx, tx, "UpdateCampaigns", sql, queryArgs...)
+	if err != nil {
+		return fmt.Errorf("failed to update campaigns: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w with ID: %s", ErrCampaignNotFound, args.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mtavano/admoai-takehome/internal/store"
+)
+
+// ErrPlacementNotFound is returned when a mutation does not match any placement
+var ErrPlacementNotFound = errors.New("no placements found")
+
+// UpdatePlacementsArgs replaces the constraints that are not nil, an empty
+// list or a zero length removes the constraint
+type UpdatePlacementsArgs struct {
+	Name           string
+	Sizes          *store.StringList
+	MaxTitleLength *int64
+	ImageFormats   *store.StringList
+}
+
+func UpdatePlacements(ctx context.Context, tx store.Transaction, args *UpdatePlacementsArgs) error {
+	if args.Name == "" {
+		return fmt.Errorf("name is required for update")
+	}
+
+	updateMap := make(map[string]interface{})
+	if args.Sizes != nil {
+		updateMap["sizes"] = *args.Sizes
+	}
+	if args.MaxTitleLength != nil {
+		updateMap["max_title_length"] = *args.MaxTitleLength
+	}
+	if args.ImageFormats != nil {
+		updateMap["image_formats"] = *args.ImageFormats
+	}
+	if len(updateMap) == 0 {
+		return fmt.Errorf("at least one field is required for update")
+	}
+
+	query := store.DialectOf(tx).Builder().
+		Update("placements").
+		SetMap(updateMap).
+		Where(squirrel.Eq{"name": args.Name})
+
+	sql, queryArgs, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build update query: %w", err)
+	}
+
+	result, err := execContext(ctx, tx, "UpdatePlacements", sql, queryArgs...)
+	if err != nil {
+		return fmt.Errorf("failed to update placements: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w with name: %s", ErrPlacementNotFound, args.Name)
+	}
+
+	return nil
+}
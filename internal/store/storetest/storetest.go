@@ -0,0 +1,253 @@
+// Package storetest provisions migrated databases for tests. SQLite databases
+// live in a temporary file; PostgreSQL databases are created either on the
+// server pointed to by TEST_POSTGRES_DSN or on a throwaway local postgres
+// process started from the binaries found in PG_BIN or PATH.
+package storetest
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/migrations"
+	"github.com/pressly/goose/v3"
+)
+
+// Backends returns the drivers the database backed tests should run against.
+// It defaults to every supported engine and can be narrowed with
+// TEST_DB_DRIVERS (comma separated, e.g. "sqlite3" or "pgx").
+func Backends() []string {
+	if env := os.Getenv("TEST_DB_DRIVERS"); env != "" {
+		return strings.Split(env, ",")
+	}
+	return []string{store.DialectSQLite.Driver, store.DialectPostgres.Driver}
+}
+
+// Open returns a freshly migrated store for driver. The test is skipped when
+// the engine is not available on this machine.
+func Open(t testing.TB, driver string) *store.SqlStore {
+	t.Helper()
+
+	dialect, err := store.DialectFor(driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dsn string
+	switch dialect.Name {
+	case store.DialectSQLite.Name:
+		dsn = filepath.Join(t.TempDir(), "admoai.db")
+	case store.DialectPostgres.Name:
+		dsn = postgresDatabase(t)
+	}
+
+	st, err := store.NewSqlStore(driver, dsn, store.DefaultPoolOptions())
+	if err != nil {
+		t.Fatalf("storetest: open %s: %v", driver, err)
+	}
+	t.Cleanup(func() { st.Close() })
+
+	goose.SetLogger(goose.NopLogger())
+	if err := migrations.Up(st.DB.DB, dialect, migrationsDir()); err != nil {
+		t.Fatalf("storetest: migrate %s: %v", driver, err)
+	}
+
+	return st
+}
+
+// Main runs the tests of a package and tears down the local postgres
+// process if one was started. Use it from TestMain:
+//
+//	func TestMain(m *testing.M) { os.Exit(storetest.Main(m)) }
+func Main(m *testing.M) int {
+	code := m.Run()
+	local.stop()
+	return code
+}
+
+// migrationsDir points goose at the repository migrations folder, which
+// only holds Go migrations registered through the blank import above
+func migrationsDir() string {
+	dir, _ := os.Getwd()
+	for {
+		candidate := filepath.Join(dir, "migrations")
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "migrations"
+		}
+		dir = parent
+	}
+}
+
+// postgresDatabase creates an empty database and returns its DSN
+func postgresDatabase(t testing.TB) string {
+	t.Helper()
+
+	serverDSN := os.Getenv("TEST_POSTGRES_DSN")
+	if serverDSN == "" {
+		var err error
+		serverDSN, err = local.start()
+		if err != nil {
+			t.Skipf("storetest: postgres not available: %v", err)
+		}
+	}
+
+	admin, err := sql.Open(store.DialectPostgres.Driver, serverDSN)
+	if err != nil {
+		t.Fatalf("storetest: connect postgres: %v", err)
+	}
+	defer admin.Close()
+
+	name := "admoai_test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if _, err := admin.Exec("CREATE DATABASE " + name); err != nil {
+		t.Fatalf("storetest: create database: %v", err)
+	}
+	t.Cleanup(func() {
+		admin, err := sql.Open(store.DialectPostgres.Driver, serverDSN)
+		if err != nil {
+			return
+		}
+		defer admin.Close()
+		admin.Exec("DROP DATABASE IF EXISTS " + name + " WITH (FORCE)")
+	})
+
+	return withDatabase(serverDSN, name)
+}
+
+// withDatabase replaces the database name of a URL style DSN
+func withDatabase(dsn, name string) string {
+	base, query, _ := strings.Cut(dsn, "?")
+	if idx := strings.LastIndex(base, "/"); idx > len("postgres://") {
+		base = base[:idx]
+	}
+	out := base + "/" + name
+	if query != "" {
+		out += "?" + query
+	}
+	return out
+}
+
+// localPostgres is a postgres cluster initialised in a temporary directory
+// and shared by every test of the package
+type localPostgres struct {
+	once sync.Once
+	dir  string
+	cmd  *exec.Cmd
+	dsn  string
+	err  error
+}
+
+var local = &localPostgres{}
+
+func (lp *localPostgres) start() (string, error) {
+	lp.once.Do(func() {
+		lp.dsn, lp.err = lp.boot()
+	})
+	return lp.dsn, lp.err
+}
+
+func (lp *localPostgres) boot() (string, error) {
+	initdb, err := pgBinary("initdb")
+	if err != nil {
+		return "", err
+	}
+	postgres, err := pgBinary("postgres")
+	if err != nil {
+		return "", err
+	}
+
+	lp.dir, err = os.MkdirTemp("", "admoai-pg-")
+	if err != nil {
+		return "", err
+	}
+	data := filepath.Join(lp.dir, "data")
+
+	out, err := exec.Command(initdb, "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("initdb: %v: %s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return "", err
+	}
+
+	lp.cmd = exec.Command(postgres,
+		"-D", data,
+		"-p", fmt.Sprint(port),
+		"-k", lp.dir,
+		"-c", "listen_addresses=127.0.0.1",
+		"-c", "fsync=off",
+	)
+	if err := lp.cmd.Start(); err != nil {
+		return "", fmt.Errorf("postgres: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port)
+	if err := waitReady(dsn, 30*time.Second); err != nil {
+		return "", err
+	}
+	return dsn, nil
+}
+
+func (lp *localPostgres) stop() {
+	if lp.cmd != nil && lp.cmd.Process != nil {
+		lp.cmd.Process.Signal(os.Interrupt)
+		lp.cmd.Wait()
+	}
+	if lp.dir != "" {
+		os.RemoveAll(lp.dir)
+	}
+}
+
+// pgBinary looks up a postgres binary in PG_BIN first and PATH afterwards
+func pgBinary(name string) (string, error) {
+	if dir := os.Getenv("PG_BIN"); dir != "" {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return exec.LookPath(name)
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func waitReady(dsn string, timeout time.Duration) error {
+	db, err := sql.Open(store.DialectPostgres.Driver, dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := db.Ping()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("postgres did not become ready: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
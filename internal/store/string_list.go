@@ -0,0 +1,47 @@
+package store
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// StringList is a list of values stored as a comma separated TEXT column,
+// which both database engines support without extensions
+type StringList []string
+
+// Value implements driver.Valuer
+func (l StringList) Value() (driver.Value, error) {
+	return strings.Join(l, ","), nil
+}
+
+// Scan implements sql.Scanner, an empty column is an empty list
+func (l *StringList) Scan(src any) error {
+	var raw string
+	switch v := src.(type) {
+	case nil:
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", src)
+	}
+
+	list := StringList{}
+	if raw != "" {
+		list = strings.Split(raw, ",")
+	}
+	*l = list
+	return nil
+}
+
+// Contains reports whether the list holds value
+func (l StringList) Contains(value string) bool {
+	for _, item := range l {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
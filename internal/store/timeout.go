@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// IsTimeout reports whether err comes from a statement that ran past its
+// deadline, either the query timeout or the one of its context
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	// SQLite reports statements stopped mid-way as interrupted
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrInterrupt
+}
+
+// IsCanceled reports whether err comes from a statement whose context was
+// cancelled, e.g. the client went away or the server is shutting down
+func IsCanceled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
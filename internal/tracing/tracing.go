@@ -0,0 +1,109 @@
+// Package tracing sets up OpenTelemetry tracing: the tracer provider, the
+// exporter of the spans and the W3C trace context propagation.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// TracerName is the instrumentation scope of the spans of the service
+const TracerName = "github.com/mtavano/admoai-takehome"
+
+// DefaultServiceName names the service of the spans unless OTEL_SERVICE_NAME
+// is set
+const DefaultServiceName = "admoai"
+
+// Exporters of the spans
+const (
+	ExporterNone = "none"
+	ExporterOTLP = "otlp"
+)
+
+// Config selects where the spans are exported
+type Config struct {
+	// Exporter is none or otlp, none when empty. The OTLP exporter sends the
+	// spans over HTTP and is configured by the standard
+	// OTEL_EXPORTER_OTLP_* variables, like OTEL_EXPORTER_OTLP_ENDPOINT.
+	Exporter string
+	// ServiceName is DefaultServiceName when empty
+	ServiceName string
+}
+
+// Setup installs the tracer provider of cfg and the W3C trace context
+// propagator as the global ones. The returned function flushes the spans
+// not exported yet and stops the exporter.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	// Incoming trace context is kept even when the spans are not exported
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+	default:
+		return nil, fmt.Errorf("invalid tracing exporter %q: must be none or otlp", cfg.Exporter)
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	// Later options win, so OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES
+	// override the defaults
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe tracing resource: %w", err)
+	}
+
+	// The sampler follows OTEL_TRACES_SAMPLER, sampling every trace by
+	// default
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// SetupInMemory installs a tracer provider keeping the spans in memory as
+// soon as they end, for tests. The returned function restores a tracer
+// provider without spans.
+func SetupInMemory() (*tracetest.InMemoryExporter, func()) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return exporter, func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	}
+}
+
+// Tracer returns the tracer of the service, from the global tracer provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
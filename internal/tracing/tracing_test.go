@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestSetupOTLP(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", collector.URL)
+	shutdown, err := Setup(context.Background(), Config{Exporter: ExporterOTLP})
+	require.NoError(t, err)
+	t.Cleanup(func() { Setup(context.Background(), Config{}) })
+
+	_, span := Tracer().Start(context.Background(), "test")
+	span.End()
+
+	// Shutting down flushes the batch of spans
+	require.NoError(t, shutdown(context.Background()))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"POST /v1/traces"}, paths)
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Config{})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	// The trace context of the callers is propagated without exporter
+	carrier := propagation.HeaderCarrier{"Traceparent": []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}}
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+	_, span := Tracer().Start(ctx, "test")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	span.End()
+
+	_, err = Setup(context.Background(), Config{Exporter: "zipkin"})
+	assert.Error(t, err)
+}
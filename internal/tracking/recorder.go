@@ -0,0 +1,134 @@
+// Package tracking buffers impression and click events in memory and writes
+// them to the database in batches.
+package tracking
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mtavano/admoai-takehome/internal/metrics"
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/mtavano/admoai-takehome/internal/store/query"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultBufferSize    = 10000
+	DefaultBatchSize     = 500
+	DefaultFlushInterval = 2 * time.Second
+)
+
+type Options struct {
+	// BufferSize is the number of events kept in memory before dropping
+	BufferSize int
+	// BatchSize is the maximum number of events written per transaction
+	BatchSize int
+	// FlushInterval is the longest an event waits before being written
+	FlushInterval time.Duration
+}
+
+// Recorder accepts events without blocking and persists them from Run
+type Recorder struct {
+	db     store.Database
+	opts   Options
+	events chan *store.AdEventRecord
+}
+
+func NewRecorder(db store.Database, opts Options) *Recorder {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultBufferSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultFlushInterval
+	}
+
+	return &Recorder{
+		db:     db,
+		opts:   opts,
+		events: make(chan *store.AdEventRecord, opts.BufferSize),
+	}
+}
+
+// Record queues an event, it returns false when the buffer is full and the
+// event was dropped
+func (r *Recorder) Record(ev *store.AdEventRecord) bool {
+	select {
+	case r.events <- ev:
+		return true
+	default:
+		if collector := metrics.GetCollector(); collector != nil {
+			collector.IncrementTrackingDropped()
+		}
+		return false
+	}
+}
+
+// Run writes the queued events until ctx is cancelled, then flushes whatever
+// is still buffered before returning
+func (r *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*store.AdEventRecord, 0, r.opts.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := r.write(batch); err != nil {
+			slog.Error("tracking flush failed", slog.Int("events", len(batch)), slog.String("error", err.Error()))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case ev := <-r.events:
+			batch = append(batch, ev)
+			if len(batch) >= r.opts.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-ctx.Done():
+			// Drain the buffer so no accepted event is lost on shutdown
+			for {
+				select {
+				case ev := <-r.events:
+					batch = append(batch, ev)
+					if len(batch) >= r.opts.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// write inserts a batch in its own transaction. It is not bound to the
+// context of Run, the last batch is written after it is cancelled.
+func (r *Recorder) write(batch []*store.AdEventRecord) error {
+	ctx := context.Background()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "tracking: Recorder.write begin error")
+	}
+
+	if err := query.InsertAdEvents(ctx, tx, batch); err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "tracking: Recorder.write insert error")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "tracking: Recorder.write commit error")
+	}
+
+	return nil
+}
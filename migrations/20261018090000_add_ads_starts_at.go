@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddAdsStartsAt, downAddAdsStartsAt)
+}
+
+func upAddAdsStartsAt(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is applied.
+	// NULL means the ad goes live as soon as it is created.
+	_, err := tx.Exec(`ALTER TABLE ads ADD COLUMN starts_at BIGINT;`)
+
+	return err
+}
+
+func downAddAdsStartsAt(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is rolled back.
+	_, err := tx.Exec(`ALTER TABLE ads DROP COLUMN starts_at;`)
+
+	return err
+}
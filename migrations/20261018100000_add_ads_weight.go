@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddAdsWeight, downAddAdsWeight)
+}
+
+func upAddAdsWeight(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is applied.
+	// The weight is the relative share of an ad in the serving rotation.
+	_, err := tx.Exec(`ALTER TABLE ads ADD COLUMN weight INTEGER NOT NULL DEFAULT 1;`)
+
+	return err
+}
+
+func downAddAdsWeight(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is rolled back.
+	_, err := tx.Exec(`ALTER TABLE ads DROP COLUMN weight;`)
+
+	return err
+}
@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddAdsClickURL, downAddAdsClickURL)
+}
+
+func upAddAdsClickURL(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is applied.
+	// Landing page the click tracker redirects to.
+	_, err := tx.Exec(`ALTER TABLE ads ADD COLUMN click_url TEXT;`)
+
+	return err
+}
+
+func downAddAdsClickURL(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is rolled back.
+	_, err := tx.Exec(`ALTER TABLE ads DROP COLUMN click_url;`)
+
+	return err
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCreateAdEvents, downCreateAdEvents)
+}
+
+func upCreateAdEvents(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is applied.
+	_, err := tx.Exec(`
+		CREATE TABLE ad_events (
+			id TEXT NOT NULL PRIMARY KEY,
+			ad_id TEXT NOT NULL,
+			type TEXT NOT NULL,
+			created_at BIGINT NOT NULL
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`CREATE INDEX ad_events_ad_id_created_at ON ad_events (ad_id, created_at);`)
+
+	return err
+}
+
+func downCreateAdEvents(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is rolled back.
+	_, err := tx.Exec(`DROP TABLE ad_events;`)
+
+	return err
+}
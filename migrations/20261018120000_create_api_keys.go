@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCreateAPIKeys, downCreateAPIKeys)
+}
+
+func upCreateAPIKeys(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is applied.
+	// Only the SHA-256 of every key is stored, the prefix helps to identify it.
+	_, err := tx.Exec(`
+		CREATE TABLE api_keys (
+			id TEXT NOT NULL PRIMARY KEY,
+			name TEXT NOT NULL,
+			key_hash TEXT NOT NULL UNIQUE,
+			key_prefix TEXT NOT NULL,
+			role TEXT NOT NULL,
+			created_at BIGINT NOT NULL,
+			revoked_at BIGINT
+		);
+	`)
+
+	return err
+}
+
+func downCreateAPIKeys(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is rolled back.
+	_, err := tx.Exec(`DROP TABLE api_keys;`)
+
+	return err
+}
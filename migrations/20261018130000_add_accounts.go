@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddAccounts, downAddAccounts)
+}
+
+// accountScopedTables hold rows owned by one advertiser account
+var accountScopedTables = []string{"ads", "ad_events", "api_keys"}
+
+func upAddAccounts(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is applied.
+	_, err := tx.Exec(`
+		CREATE TABLE accounts (
+			id TEXT NOT NULL PRIMARY KEY,
+			name TEXT NOT NULL,
+			created_at BIGINT NOT NULL
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Rows created before tenancy belong to the default account
+	_, err = tx.Exec(fmt.Sprintf(
+		`INSERT INTO accounts (id, name, created_at) VALUES ('default', 'Default', %d);`,
+		time.Now().Unix(),
+	))
+	if err != nil {
+		return err
+	}
+
+	for _, table := range accountScopedTables {
+		_, err = tx.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN account_id TEXT NOT NULL DEFAULT 'default';`, table))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`CREATE INDEX ads_account_id_created_at ON ads (account_id, created_at);`)
+
+	return err
+}
+
+func downAddAccounts(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is rolled back.
+	_, err := tx.Exec(`DROP INDEX ads_account_id_created_at;`)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range accountScopedTables {
+		_, err = tx.Exec(fmt.Sprintf(`ALTER TABLE %s DROP COLUMN account_id;`, table))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`DROP TABLE accounts;`)
+
+	return err
+}
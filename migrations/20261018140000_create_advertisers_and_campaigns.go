@@ -0,0 +1,73 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCreateAdvertisersAndCampaigns, downCreateAdvertisersAndCampaigns)
+}
+
+func upCreateAdvertisersAndCampaigns(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is applied.
+	_, err := tx.Exec(`
+		CREATE TABLE advertisers (
+			id TEXT NOT NULL PRIMARY KEY,
+			account_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			created_at BIGINT NOT NULL
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		CREATE TABLE campaigns (
+			id TEXT NOT NULL PRIMARY KEY,
+			account_id TEXT NOT NULL,
+			advertiser_id TEXT NOT NULL REFERENCES advertisers (id),
+			name TEXT NOT NULL,
+			status TEXT NOT NULL,
+			created_at BIGINT NOT NULL
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Ads created before campaigns existed stay outside of any campaign
+	_, err = tx.Exec(`ALTER TABLE ads ADD COLUMN campaign_id TEXT REFERENCES campaigns (id);`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`CREATE INDEX ads_campaign_id ON ads (campaign_id);`)
+
+	return err
+}
+
+func downCreateAdvertisersAndCampaigns(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is rolled back.
+	_, err := tx.Exec(`DROP INDEX ads_campaign_id;`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`ALTER TABLE ads DROP COLUMN campaign_id;`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DROP TABLE campaigns;`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DROP TABLE advertisers;`)
+
+	return err
+}
@@ -0,0 +1,67 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCreatePlacements, downCreatePlacements)
+}
+
+func upCreatePlacements(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is applied.
+	// sizes and image_formats are comma separated lists, empty means any.
+	_, err := tx.Exec(`
+		CREATE TABLE placements (
+			name TEXT NOT NULL PRIMARY KEY,
+			sizes TEXT NOT NULL DEFAULT '',
+			max_title_length INTEGER NOT NULL DEFAULT 0,
+			image_formats TEXT NOT NULL DEFAULT '',
+			created_at BIGINT NOT NULL
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Register the placements already in use, without constraints
+	_, err = tx.Exec(fmt.Sprintf(`
+		INSERT INTO placements (name, created_at)
+		SELECT DISTINCT placement, %d FROM ads;
+	`, time.Now().Unix()))
+	if err != nil {
+		return err
+	}
+
+	// Creative size in pixels, checked against the sizes of the placement
+	_, err = tx.Exec(`ALTER TABLE ads ADD COLUMN width INTEGER;`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`ALTER TABLE ads ADD COLUMN height INTEGER;`)
+
+	return err
+}
+
+func downCreatePlacements(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is rolled back.
+	_, err := tx.Exec(`ALTER TABLE ads DROP COLUMN height;`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`ALTER TABLE ads DROP COLUMN width;`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DROP TABLE placements;`)
+
+	return err
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCreateAdAuditLog, downCreateAdAuditLog)
+}
+
+func upCreateAdAuditLog(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is applied.
+	// Snapshots and changes are JSON documents stored as TEXT.
+	_, err := tx.Exec(`
+		CREATE TABLE ad_audit_log (
+			id TEXT NOT NULL PRIMARY KEY,
+			account_id TEXT NOT NULL,
+			ad_id TEXT NOT NULL,
+			action TEXT NOT NULL,
+			actor TEXT NOT NULL,
+			request_id TEXT NOT NULL,
+			before_snapshot TEXT,
+			after_snapshot TEXT,
+			changes TEXT NOT NULL,
+			created_at BIGINT NOT NULL
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`CREATE INDEX ad_audit_log_ad_id_created_at ON ad_audit_log (ad_id, created_at);`)
+
+	return err
+}
+
+func downCreateAdAuditLog(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is rolled back.
+	_, err := tx.Exec(`DROP TABLE ad_audit_log;`)
+
+	return err
+}
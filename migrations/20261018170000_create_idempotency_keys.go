@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCreateIdempotencyKeys, downCreateIdempotencyKeys)
+}
+
+func upCreateIdempotencyKeys(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is applied.
+	// Keys are chosen by the clients, so they are unique per account only.
+	_, err := tx.Exec(`
+		CREATE TABLE idempotency_keys (
+			account_id TEXT NOT NULL,
+			idempotency_key TEXT NOT NULL,
+			request_hash TEXT NOT NULL,
+			status_code INTEGER NOT NULL,
+			response TEXT NOT NULL,
+			created_at BIGINT NOT NULL,
+			expires_at BIGINT NOT NULL,
+			PRIMARY KEY (account_id, idempotency_key)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`CREATE INDEX idempotency_keys_expires_at ON idempotency_keys (expires_at);`)
+
+	return err
+}
+
+func downCreateIdempotencyKeys(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is rolled back.
+	_, err := tx.Exec(`DROP TABLE idempotency_keys;`)
+
+	return err
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upWidenAdsTimestamps, downWidenAdsTimestamps)
+}
+
+// The timestamps of the first ads table are INTEGER, 64 bits on SQLite but
+// 32 bits on PostgreSQL, where they overflow in 2038
+func upWidenAdsTimestamps(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is applied.
+	postgres, err := isPostgres()
+	if err != nil || !postgres {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		ALTER TABLE ads
+			ALTER COLUMN created_at TYPE BIGINT,
+			ALTER COLUMN expires_at TYPE BIGINT;
+	`)
+
+	return err
+}
+
+func downWidenAdsTimestamps(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is rolled back.
+	postgres, err := isPostgres()
+	if err != nil || !postgres {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		ALTER TABLE ads
+			ALTER COLUMN created_at TYPE INTEGER,
+			ALTER COLUMN expires_at TYPE INTEGER;
+	`)
+
+	return err
+}
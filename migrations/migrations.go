@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/mtavano/admoai-takehome/internal/store"
+	"github.com/pressly/goose/v3"
+)
+
+// dialect is the engine the migrations run on, set by Up for the migrations
+// whose statements differ between engines
+var dialect store.Dialect
+
+// Up applies the pending migrations of dir to db, which runs on the engine of
+// d. Run the migrations through it rather than goose.Up, so they know their
+// engine.
+func Up(db *sql.DB, d store.Dialect, dir string) error {
+	if err := goose.SetDialect(d.Goose); err != nil {
+		return err
+	}
+	dialect = d
+	return goose.Up(db, dir)
+}
+
+// isPostgres reports whether the migrations run on PostgreSQL, it fails when
+// they were not started by Up
+func isPostgres() (bool, error) {
+	if dialect.Name == "" {
+		return false, errors.New("migrations: unknown dialect, run the migrations with migrations.Up")
+	}
+	return dialect.Name == store.DialectPostgres.Name, nil
+}